@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"log-analyzer/backend/internal/response"
+	"log-analyzer/backend/internal/rules"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ActionTargetRequest struct {
+	Target string `json:"target" binding:"required"`
+}
+
+// ActionsResponse lists the enabled actions with the blocks in effect.
+type ActionsResponse struct {
+	Actions []rules.Action   `json:"actions"`
+	Active  []response.Block `json:"active"`
+}
+
+func (h *Handler) GetActions(c *gin.Context) {
+	c.JSON(http.StatusOK, ActionsResponse{Actions: h.responder.Actions(), Active: h.responder.Active()})
+}
+
+// GetActionLog returns the action log, newest first, filtered by action,
+// kind (block or unblock), target and result.
+func (h *Handler) GetActionLog(c *gin.Context) {
+	filter := response.Filter{
+		Action: c.Query("action"),
+		Kind:   c.Query("kind"),
+		Target: c.Query("target"),
+		Result: c.Query("result"),
+		Limit:  200,
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	c.JSON(http.StatusOK, h.responder.Log(filter))
+}
+
+// BlockTarget runs an action for a target by hand.
+func (h *Handler) BlockTarget(c *gin.Context) {
+	var req ActionTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entry, err := h.responder.Block(c.Param("name"), strings.TrimSpace(req.Target), actor(c))
+	h.respondAction(c, entry, err)
+}
+
+// UnblockTarget undoes an action for a target before its TTL expires.
+func (h *Handler) UnblockTarget(c *gin.Context) {
+	var req ActionTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entry, err := h.responder.Unblock(c.Param("name"), strings.TrimSpace(req.Target), actor(c))
+	h.respondAction(c, entry, err)
+}
+
+// respondAction answers with the logged run; a run that failed is a 502,
+// a request that never ran is a 400 or 404.
+func (h *Handler) respondAction(c *gin.Context, entry response.Entry, err error) {
+	switch {
+	case errors.Is(err, response.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil && entry.ID == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.saveState(actionsFile, h.responder.Marshal)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "entry": entry})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"log-analyzer/backend/internal/cases"
+	"log-analyzer/backend/internal/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaseRequest opens a case by hand. Severity is empty to follow the alerts.
+type CaseRequest struct {
+	Title    string   `json:"title"`
+	Severity string   `json:"severity"`
+	AlertIDs []string `json:"alertIds"`
+	Note     string   `json:"note"`
+}
+
+type CaseAlertsRequest struct {
+	AlertIDs []string `json:"alertIds"`
+}
+
+type CaseStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type CaseSeverityRequest struct {
+	Severity string `json:"severity"`
+}
+
+type CaseNoteRequest struct {
+	Text string `json:"text"`
+}
+
+// CaseResponse is a case with those of its alerts still in the alert store.
+type CaseResponse struct {
+	cases.Case
+	Alerts []AlertResponse `json:"alerts"`
+}
+
+// correlate files a new live alert under a case by its entities.
+func (h *Handler) correlate(alert AlertResponse) {
+	member, window := h.caseMember(alert)
+	if _, ok := h.cases.Correlate(member, window); ok {
+		h.saveState(casesFile, h.cases.Marshal)
+	}
+}
+
+// caseMember finds the correlation entities of a stored alert and returns
+// them with the correlation window.
+func (h *Handler) caseMember(alert AlertResponse) (cases.Member, time.Duration) {
+	entry := parser.NewEntry(alert.Line)
+	entry.Host = alert.Host
+	entry.Fields = alert.Fields
+	entities, window := h.ruleManager.CaseEntities(entry)
+	return cases.Member{Alert: alert, Entities: entities}, window
+}
+
+// caseMembers looks up alerts by ID for a case request.
+func (h *Handler) caseMembers(ids []string) ([]cases.Member, error) {
+	members := make([]cases.Member, 0, len(ids))
+	for _, id := range ids {
+		alert, ok := h.alerts.Get(id)
+		if !ok {
+			return nil, errors.New("alert " + id + " not found")
+		}
+		member, _ := h.caseMember(alert)
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (h *Handler) caseResponse(c cases.Case) CaseResponse {
+	response := CaseResponse{Case: c, Alerts: []AlertResponse{}}
+	for _, id := range c.AlertIDs {
+		if alert, ok := h.alerts.Get(id); ok {
+			response.Alerts = append(response.Alerts, alert)
+		}
+	}
+	return response
+}
+
+func (h *Handler) GetCases(c *gin.Context) {
+	filter := cases.Filter{Limit: 100}
+	if value := c.Query("status"); value != "" {
+		status, err := cases.ParseStatus(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Status = status
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+	c.JSON(http.StatusOK, h.cases.List(filter))
+}
+
+func (h *Handler) GetCase(c *gin.Context) {
+	found, ok := h.cases.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": cases.ErrNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h.caseResponse(found))
+}
+
+func (h *Handler) CreateCase(c *gin.Context) {
+	var req CaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	members, err := h.caseMembers(req.AlertIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	severity := ""
+	if req.Severity != "" {
+		severity = severityToTurkish(req.Severity)
+	}
+	created := h.cases.Create(req.Title, severity, actor(c), req.Note, members)
+	h.saveState(casesFile, h.cases.Marshal)
+	c.JSON(http.StatusCreated, h.caseResponse(created))
+}
+
+func (h *Handler) AddCaseAlerts(c *gin.Context) {
+	var req CaseAlertsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	members, err := h.caseMembers(req.AlertIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.cases.AddAlerts(c.Param("id"), members, actor(c))
+	h.respondCaseChange(c, updated, err)
+}
+
+func (h *Handler) SetCaseStatus(c *gin.Context) {
+	var req CaseStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status, err := cases.ParseStatus(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.cases.SetStatus(c.Param("id"), status, actor(c), req.Note)
+	h.respondCaseChange(c, updated, err)
+}
+
+func (h *Handler) SetCaseSeverity(c *gin.Context) {
+	var req CaseSeverityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.cases.SetSeverity(c.Param("id"), severityToTurkish(req.Severity), actor(c))
+	h.respondCaseChange(c, updated, err)
+}
+
+func (h *Handler) AddCaseNote(c *gin.Context) {
+	var req CaseNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.cases.AddNote(c.Param("id"), actor(c), req.Text)
+	h.respondCaseChange(c, updated, err)
+}
+
+func (h *Handler) respondCaseChange(c *gin.Context, updated cases.Case, err error) {
+	if errors.Is(err, cases.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.saveState(casesFile, h.cases.Marshal)
+	c.JSON(http.StatusOK, h.caseResponse(updated))
+}
+
+// ExportCase downloads a case with its timeline and alerts as JSON or
+// Markdown.
+func (h *Handler) ExportCase(c *gin.Context) {
+	found, ok := h.cases.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": cases.ErrNotFound.Error()})
+		return
+	}
+	response := h.caseResponse(found)
+	filename := "vaka-" + found.ID + "-" + time.Now().Format("20060102-150405")
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		c.JSON(http.StatusOK, response)
+	case "markdown", "md":
+		var buf bytes.Buffer
+		if err := cases.WriteMarkdown(&buf, found, response.Alerts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.md"`)
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", buf.Bytes())
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format " + format, "formats": []string{"json", "markdown"}})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDNSStats reports the DNS cache and lookup counters.
+func (h *Handler) GetDNSStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.resolver != nil, "stats": h.resolver.Stats()})
+}
+
+// addDNSFields attaches names resolved after the alert was stored and sends
+// the updated alert to the dashboards; the outputs were notified already.
+// It runs on a resolver worker, so it only queues the alert through
+// broadcastAlert and never writes to a connection itself.
+func (h *Handler) addDNSFields(id string, fields map[string]string) {
+	updated, err := h.alerts.SetFields(id, fields)
+	if err != nil {
+		log.Printf("DNS names for alert %s could not be added: %v", id, err)
+		return
+	}
+	h.broadcastAlert(updated)
+}
+
+// withFields returns a copy of fields with extra added.
+func withFields(fields, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return fields
+	}
+	merged := make(map[string]string, len(fields)+len(extra))
+	for key, value := range fields {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/parser"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTopEntities = 10
+	maxTopEntities     = 100
+)
+
+// EntityCount is how often an entity appeared in the stored alerts: in how
+// many alerts and, counting grouped repeats, how many times.
+type EntityCount struct {
+	Value    string    `json:"value"`
+	Alerts   int       `json:"alerts"`
+	Hits     int       `json:"hits"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// GetTopEntities lists the most frequent entities of the stored alerts per
+// type. type (comma separated), since (a duration such as "24h"), status
+// and limit (per type) narrow the result.
+func (h *Handler) GetTopEntities(c *gin.Context) {
+	types := parser.EntityTypes
+	if value := c.Query("type"); value != "" {
+		types = nil
+		for _, typ := range strings.Split(value, ",") {
+			typ = strings.ToLower(strings.TrimSpace(typ))
+			if !parser.IsEntityType(typ) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown entity type " + strconv.Quote(typ)})
+				return
+			}
+			types = append(types, typ)
+		}
+	}
+	filter := alerts.Filter{}
+	if value := c.Query("status"); value != "" {
+		status, err := alerts.ParseStatus(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Status = status
+	}
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+			return
+		}
+		since = time.Now().Add(-duration)
+	}
+	limit := defaultTopEntities
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		if limit > maxTopEntities {
+			limit = maxTopEntities
+		}
+	}
+
+	counts := make(map[string]map[string]*EntityCount)
+	for _, typ := range types {
+		counts[typ] = make(map[string]*EntityCount)
+	}
+	scanned := 0
+	for _, alert := range h.alerts.List(filter) {
+		if alert.LastSeen.Before(since) {
+			continue
+		}
+		scanned++
+		hits := alert.Count
+		if hits < 1 {
+			hits = 1
+		}
+		// Alerts stored before entities were kept on them are extracted
+		// again.
+		entities := alert.Entities
+		if entities == nil {
+			extracted := parser.ExtractEntities(alert.Line)
+			entities = &extracted
+		}
+		for _, entity := range entities.All() {
+			byValue, ok := counts[entity.Type]
+			if !ok {
+				continue
+			}
+			count, ok := byValue[entity.Value]
+			if !ok {
+				count = &EntityCount{Value: entity.Value}
+				byValue[entity.Value] = count
+			}
+			count.Alerts++
+			count.Hits += hits
+			if alert.LastSeen.After(count.LastSeen) {
+				count.LastSeen = alert.LastSeen
+			}
+		}
+	}
+
+	result := make(map[string][]EntityCount, len(types))
+	for _, typ := range types {
+		list := make([]EntityCount, 0, len(counts[typ]))
+		for _, count := range counts[typ] {
+			list = append(list, *count)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Hits != list[j].Hits {
+				return list[i].Hits > list[j].Hits
+			}
+			if list[i].Alerts != list[j].Alerts {
+				return list[i].Alerts > list[j].Alerts
+			}
+			return list[i].Value < list[j].Value
+		})
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		result[typ] = list
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": scanned, "entities": result})
+}
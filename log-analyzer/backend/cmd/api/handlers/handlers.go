@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"log-analyzer/backend/internal/alerts"
 	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/cases"
+	"log-analyzer/backend/internal/intel"
+	"log-analyzer/backend/internal/notify"
 	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/resolver"
+	"log-analyzer/backend/internal/response"
 	"log-analyzer/backend/internal/rules"
 	"log-analyzer/backend/internal/tailer"
 
@@ -16,30 +27,90 @@ import (
 )
 
 type Handler struct {
-	ruleManager   *rules.Manager
-	analyzer      *analyzer.Analyzer
-	tailer        *tailer.Tailer
-	alerts        []AlertResponse
-	mu            sync.RWMutex
-	wsConnections map[*websocket.Conn]struct{}
-	wsMu          sync.RWMutex
-	upgrader      websocket.Upgrader
-}
-
-type AlertResponse struct {
-	Timestamp    time.Time `json:"timestamp"`
-	Source       string    `json:"source"`
-	LogFile      string    `json:"logFile"`
-	Line         string    `json:"line"`
-	Summary      string    `json:"summary"`
-	MatchedRules []string  `json:"matchedRules"`
-	Severity     string    `json:"severity"`
+	ruleManager *rules.Manager
+	analyzer    *analyzer.Analyzer
+	tailer      *tailer.Tailer
+	alerts      *alerts.Store
+	cases       *cases.Store
+	notifier    *notify.Dispatcher
+	responder   *response.Responder
+	intel       *intel.Matcher
+	resolver    *resolver.Resolver
+	wsClients   map[*wsClient]struct{}
+	wsMu        sync.Mutex
+	upgrader    websocket.Upgrader
+	// groupBroadcasts throttles updates of grouped alerts to the dashboards.
+	groupBroadcasts map[string]time.Time
+	groupMu         sync.Mutex
+	saveMu          sync.Mutex
+	stop            chan struct{}
+	collected       chan struct{}
 }
 
+// AlertResponse is the alert as sent to the dashboard; live alerts carry
+// their lifecycle state.
+type AlertResponse = alerts.Alert
+
 type AnalyzeRequest struct {
 	Files []string `json:"files"`
 }
 
+type ExportRequest struct {
+	Files  []string `json:"files"`
+	Format string   `json:"format"`
+}
+
+// RuleTestRequest optionally carries unsaved rules to test instead of the
+// loaded config.
+type RuleTestRequest struct {
+	Rules []rules.Rule `json:"rules"`
+	Rule  string       `json:"rule"`
+}
+
+// RuleLintRequest selects the rules and corpus for the linter. Without
+// files or corpus lines the enabled log files are sampled.
+type RuleLintRequest struct {
+	Rules    []rules.Rule `json:"rules"`
+	Files    []string     `json:"files"`
+	Corpus   []string     `json:"corpus"`
+	MaxLines int          `json:"maxLines"`
+	NoCorpus bool         `json:"noCorpus"`
+}
+
+// BacktestRequest carries an unsaved rule and the logs to run it over.
+// Bucket is a Go duration such as "15m"; empty picks one automatically.
+type BacktestRequest struct {
+	Rule    rules.Rule `json:"rule"`
+	Files   []string   `json:"files"`
+	From    time.Time  `json:"from"`
+	To      time.Time  `json:"to"`
+	Bucket  string     `json:"bucket"`
+	Samples int        `json:"samples"`
+}
+
+// SuppressionRequest adds a suppression. Duration such as "72h" is an
+// alternative to an absolute ExpiresAt; AddedBy defaults to the logged-in
+// user.
+type SuppressionRequest struct {
+	rules.Suppression
+	Duration string `json:"duration"`
+}
+
+// AlertStatusRequest moves an alert along its workflow. The actor is always
+// the logged-in user.
+type AlertStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type AlertAssignRequest struct {
+	Assignee string `json:"assignee"`
+}
+
+type AlertCommentRequest struct {
+	Text string `json:"text"`
+}
+
 type TailRequest struct {
 	Files []string `json:"files"`
 }
@@ -68,6 +139,9 @@ type StatsResponse struct {
 	WatchedFiles     int            `json:"watchedFiles"`
 	IsTailing        bool           `json:"isTailing"`
 	WatchedFilesList []string       `json:"watchedFilesList"`
+	ActiveInputs     []string       `json:"activeInputs"`
+	StatusCount      map[string]int `json:"statusCount"`
+	DroppedAlerts    int64          `json:"droppedAlerts"`
 }
 
 func severityToTurkish(severity string) string {
@@ -91,21 +165,118 @@ func severityToTurkish(severity string) string {
 
 func NewHandler(ruleManager *rules.Manager) *Handler {
 	h := &Handler{
-		ruleManager:   ruleManager,
-		analyzer:      analyzer.NewAnalyzer(ruleManager),
-		tailer:        tailer.NewTailer(ruleManager),
-		alerts:        make([]AlertResponse, 0),
-		wsConnections: make(map[*websocket.Conn]struct{}),
+		ruleManager:     ruleManager,
+		analyzer:        analyzer.NewAnalyzer(ruleManager),
+		tailer:          tailer.NewTailer(ruleManager),
+		alerts:          alerts.NewStore(1000),
+		cases:           cases.NewStore(500),
+		notifier:        notify.NewDispatcher(ruleManager.GetEnabledOutputs()),
+		responder:       response.NewResponder(ruleManager.GetEnabledActions(), ruleManager.RuleActions()),
+		intel:           intel.NewMatcher(ruleManager.GetThreatIntel()),
+		resolver:        resolver.New(ruleManager.GetDNS()),
+		wsClients:       make(map[*wsClient]struct{}),
+		groupBroadcasts: make(map[string]time.Time),
+		stop:            make(chan struct{}),
+		collected:       make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
 			},
 		},
 	}
+	h.loadState(alertsFile, h.alerts.Load)
+	h.loadState(casesFile, h.cases.Load)
+	h.loadState(deadLettersFile, h.notifier.Load)
+	h.loadState(actionsFile, h.responder.Load)
+	h.analyzer.SetIntel(h.intel)
+	h.tailer.SetIntel(h.intel)
 	go h.collectAlerts()
+	go h.persistStats()
+	h.startInputs()
 	return h
 }
 
+const (
+	statsSaveInterval = 30 * time.Second
+	alertsFile        = "alerts.json"
+	casesFile         = "cases.json"
+	deadLettersFile   = "deadletters.json"
+	actionsFile       = "actions.json"
+)
+
+func (h *Handler) persistStats() {
+	ticker := time.NewTicker(statsSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ticker.C:
+			h.saveAll()
+		case <-h.responder.Changes():
+			h.saveState(actionsFile, h.responder.Marshal)
+		}
+	}
+}
+
+func (h *Handler) saveAll() {
+	if err := h.ruleManager.SaveStats(); err != nil {
+		log.Printf("Rule stats could not be saved: %v", err)
+	}
+	h.saveState(alertsFile, h.alerts.Marshal)
+	h.saveState(casesFile, h.cases.Marshal)
+	h.saveState(deadLettersFile, h.notifier.Marshal)
+	h.saveState(actionsFile, h.responder.Marshal)
+}
+
+// Close stops the live sources, lets the collector finish with the alerts
+// already read, stops the outputs, response actions and feed reloads and
+// saves all state, so a restart loses no counts.
+func (h *Handler) Close() {
+	close(h.stop)
+	h.tailer.Stop()
+	<-h.collected
+	h.resolver.Close()
+	h.notifier.Close()
+	h.responder.Close()
+	h.intel.Close()
+	h.saveAll()
+}
+
+func (h *Handler) loadState(name string, load func([]byte) error) {
+	data, err := h.ruleManager.ReadState(name)
+	if err == nil && data != nil {
+		err = load(data)
+	}
+	if err != nil {
+		log.Printf("Saved state %s could not be loaded: %v", name, err)
+	}
+}
+
+// saveState writes a store to the state directory if it changed, so alert
+// and case workflows survive a restart.
+func (h *Handler) saveState(name string, marshal func() ([]byte, bool, error)) {
+	// Saves run from requests, the periodic save and shutdown at once; one
+	// at a time keeps an older snapshot from being written last.
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+	data, changed, err := marshal()
+	if err == nil && changed {
+		err = h.ruleManager.WriteState(name, data)
+	}
+	if err != nil {
+		log.Printf("State %s could not be saved: %v", name, err)
+	}
+}
+
+func (h *Handler) startInputs() {
+	for _, input := range h.ruleManager.GetEnabledInputs() {
+		if err := h.tailer.StartInput(input); err != nil {
+			log.Printf("Input %s could not be started: %v", input.Name, err)
+		}
+	}
+}
+
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -119,11 +290,21 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, LoginResponse{Success: true, Token: authToken})
 }
 
-func AuthToken() string {
-	return authToken
+// userKey holds the authenticated user in the gin context.
+const userKey = "user"
+
+// Authenticate checks a request token and records its user for the
+// handlers.
+func Authenticate(c *gin.Context, token string) bool {
+	if token != authToken {
+		return false
+	}
+	c.Set(userKey, authUsername)
+	return true
 }
 
 func (h *Handler) collectAlerts() {
+	defer close(h.collected)
 	for alert := range h.tailer.Alerts() {
 		summary := parser.ParseLogLineToSummary(alert.Line)
 		if summary == "" {
@@ -137,33 +318,91 @@ func (h *Handler) collectAlerts() {
 			Summary:      summary,
 			MatchedRules: alert.MatchedRules,
 			Severity:     severityToTurkish(alert.Severity),
+			Host:         alert.Host,
+			Fields:       alert.Fields,
+			Entities:     alert.Entities,
+			Geo:          h.ruleManager.Locate(parser.NewEntry(alert.Line)),
+			Count:        alert.Count,
+			LastSeen:     alert.LastSeen,
+			GroupKey:     alert.GroupKey,
+		}
+		// Names already cached are attached now; the rest are looked up in
+		// the background and added to the stored alert, so a slow resolver
+		// never holds up alerts. The alert was matched and grouped by the
+		// tailer before this, and outputs and actions only see the names
+		// cached by now.
+		dnsFields, resolved := h.resolver.Cached(alert.Line)
+		alertResp.Fields = withFields(alertResp.Fields, dnsFields)
+
+		if alert.Count > 1 {
+			if updated, ok := h.alerts.UpdateGroup(alertResp); ok {
+				if h.shouldBroadcastGroup(updated) {
+					h.broadcastAlert(updated)
+				}
+				h.responder.Handle(updated)
+				continue
+			}
 		}
 
-		h.mu.Lock()
-		h.alerts = append(h.alerts, alertResp)
-		if len(h.alerts) > 1000 {
-			h.alerts = h.alerts[len(h.alerts)-1000:]
+		stored := h.alerts.Add(alertResp)
+		if !resolved {
+			h.resolver.Resolve(stored.Line, func(fields map[string]string) {
+				h.addDNSFields(stored.ID, fields)
+			})
 		}
-		h.mu.Unlock()
+		h.broadcastAlert(stored)
+		h.notifier.Notify(stored)
+		h.responder.Handle(stored)
+		h.correlate(stored)
+	}
+}
+
+const groupBroadcastInterval = time.Second
 
-		h.broadcastAlert(alertResp)
+// shouldBroadcastGroup sends an updated group at most once per interval so a
+// flood does not turn into a flood of WebSocket messages.
+func (h *Handler) shouldBroadcastGroup(alert AlertResponse) bool {
+	h.groupMu.Lock()
+	defer h.groupMu.Unlock()
+	last, ok := h.groupBroadcasts[alert.GroupKey]
+	if ok && alert.LastSeen.Sub(last) < groupBroadcastInterval {
+		return false
 	}
+	if len(h.groupBroadcasts) > 10000 {
+		h.groupBroadcasts = make(map[string]time.Time)
+	}
+	h.groupBroadcasts[alert.GroupKey] = alert.LastSeen
+	return true
 }
 
+const (
+	// wsSendBuffer is how many alerts may wait for a dashboard before it
+	// is considered stuck and disconnected.
+	wsSendBuffer = 256
+	// wsWriteTimeout bounds a single write so a dead peer frees its
+	// goroutine.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsClient is one dashboard connection. Only its WebSocketAlerts goroutine
+// writes to conn; other goroutines queue alerts on send.
+type wsClient struct {
+	conn *websocket.Conn
+	send chan AlertResponse
+}
+
+// broadcastAlert queues an alert for every dashboard without waiting for
+// any of them.
 func (h *Handler) broadcastAlert(alert AlertResponse) {
-	h.wsMu.RLock()
-	conns := make([]*websocket.Conn, 0, len(h.wsConnections))
-	for conn := range h.wsConnections {
-		conns = append(conns, conn)
-	}
-	h.wsMu.RUnlock()
-
-	for _, conn := range conns {
-		if err := conn.WriteJSON(alert); err != nil {
-			h.wsMu.Lock()
-			delete(h.wsConnections, conn)
-			h.wsMu.Unlock()
-			conn.Close()
+	h.wsMu.Lock()
+	defer h.wsMu.Unlock()
+	for client := range h.wsClients {
+		select {
+		case client.send <- alert:
+		default:
+			// The dashboard reloads the alert list when it reconnects.
+			delete(h.wsClients, client)
+			close(client.send)
 		}
 	}
 }
@@ -173,6 +412,181 @@ func (h *Handler) GetRules(c *gin.Context) {
 	c.JSON(http.StatusOK, rules)
 }
 
+func (h *Handler) TestRules(c *gin.Context) {
+	var req RuleTestRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ruleList := req.Rules
+	if len(ruleList) == 0 {
+		ruleList = h.ruleManager.GetRules()
+	} else {
+		h.ruleManager.UseGeoIP(ruleList)
+	}
+
+	results := []rules.TestResult{}
+	failed := 0
+	for _, result := range rules.RunTests(ruleList) {
+		if req.Rule != "" && result.Rule != req.Rule {
+			continue
+		}
+		if result.Failed() {
+			failed++
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"tested":  len(results),
+		"failed":  failed,
+		"passed":  failed == 0,
+	})
+}
+
+func (h *Handler) LintRules(c *gin.Context) {
+	var req RuleLintRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	ruleList := req.Rules
+	if len(ruleList) == 0 {
+		ruleList = h.ruleManager.GetRules()
+	} else {
+		h.ruleManager.UseGeoIP(ruleList)
+	}
+	if req.MaxLines <= 0 {
+		req.MaxLines = 10000
+	}
+
+	corpus := req.Corpus
+	if !req.NoCorpus {
+		files := req.Files
+		if len(files) == 0 && len(corpus) == 0 {
+			for _, file := range h.ruleManager.GetEnabledLogFiles() {
+				if info, err := os.Stat(file.Path); err == nil && info.Mode().IsRegular() {
+					files = append(files, file.Path)
+				}
+			}
+		}
+		lines, err := rules.ReadCorpus(files, req.MaxLines)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		corpus = append(corpus, lines...)
+	} else {
+		corpus = nil
+	}
+
+	findings := rules.Lint(ruleList, corpus)
+	if findings == nil {
+		findings = []rules.LintFinding{}
+	}
+	count := make(map[string]int)
+	for _, finding := range findings {
+		count[finding.Severity]++
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"findings":      findings,
+		"severityCount": count,
+		"corpusLines":   len(corpus),
+	})
+}
+
+func (h *Handler) BacktestRule(c *gin.Context) {
+	var req BacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts := analyzer.BacktestOptions{Files: req.Files, From: req.From, To: req.To, Samples: req.Samples}
+	if req.Bucket != "" {
+		bucket, err := time.ParseDuration(req.Bucket)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		opts.Bucket = bucket
+	}
+	if len(opts.Files) == 0 {
+		for _, file := range h.ruleManager.GetEnabledLogFiles() {
+			opts.Files = append(opts.Files, file.Path)
+		}
+	}
+
+	result, err := h.analyzer.Backtest(req.Rule, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetRuleStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ruleManager.RuleStats())
+}
+
+func (h *Handler) ResetRuleStats(c *gin.Context) {
+	h.ruleManager.ResetStats()
+	if err := h.ruleManager.SaveStats(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rule stats reset"})
+}
+
+func (h *Handler) GetSuppressions(c *gin.Context) {
+	c.JSON(http.StatusOK, h.ruleManager.Suppressions())
+}
+
+func (h *Handler) AddSuppression(c *gin.Context) {
+	var req SuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		expires := time.Now().Add(duration)
+		req.ExpiresAt = &expires
+	}
+	req.AddedBy = actor(c)
+	suppression, err := h.ruleManager.AddSuppression(req.Suppression)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, suppression)
+}
+
+func (h *Handler) RemoveSuppression(c *gin.Context) {
+	if err := h.ruleManager.RemoveSuppression(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Suppression removed"})
+}
+
+// PruneSuppressions deletes every expired suppression.
+func (h *Handler) PruneSuppressions(c *gin.Context) {
+	removed, err := h.ruleManager.RemoveExpiredSuppressions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
+
 func (h *Handler) GetLogFiles(c *gin.Context) {
 	files := h.ruleManager.GetLogFiles()
 	c.JSON(http.StatusOK, files)
@@ -202,14 +616,26 @@ func (h *Handler) AnalyzeFiles(c *gin.Context) {
 		if summary == "" {
 			summary = entry.Line
 		}
+		timestamp := parseTime(entry.Timestamp)
+		lastSeen := timestamp
+		if entry.LastSeen != "" {
+			lastSeen = parseTime(entry.LastSeen)
+		}
 		alerts = append(alerts, AlertResponse{
-			Timestamp:    parseTime(entry.Timestamp),
+			Timestamp:    timestamp,
 			Source:       entry.Source,
 			LogFile:      entry.LogFile,
 			Line:         entry.Line,
 			Summary:      summary,
 			MatchedRules: entry.MatchedRules,
 			Severity:     severityToTurkish(entry.Severity),
+			Host:         entry.Host,
+			Fields:       entry.Fields,
+			Entities:     entry.Entities,
+			Geo:          h.ruleManager.Locate(parser.NewEntry(entry.Line)),
+			Count:        entry.Occurrences(),
+			LastSeen:     lastSeen,
+			GroupKey:     entry.GroupKey,
 		})
 	}
 
@@ -219,6 +645,44 @@ func (h *Handler) AnalyzeFiles(c *gin.Context) {
 	})
 }
 
+// ExportReport analyzes the requested files and returns the results as a
+// downloadable report in the requested format.
+func (h *Handler) ExportReport(c *gin.Context) {
+	var req ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "json"
+	}
+	exporter, err := analyzer.NewExporter(req.Format, h.ruleManager.GetRules())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "formats": analyzer.ExportFormats()})
+		return
+	}
+
+	if len(req.Files) == 0 {
+		for _, file := range h.ruleManager.GetEnabledLogFiles() {
+			req.Files = append(req.Files, file.Path)
+		}
+	}
+	entries, err := h.analyzer.AnalyzeMultipleFiles(req.Files)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := exporter.Export(&buf, entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filename := "log-analiz-" + time.Now().Format("20060102-150405") + exporter.Extension()
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, exporter.ContentType(), buf.Bytes())
+}
+
 func (h *Handler) StartTailing(c *gin.Context) {
 	var req TailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -263,20 +727,97 @@ func (h *Handler) StopTailing(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Tailing stopped"})
 }
 
+// GetAlerts lists live alerts, optionally filtered by status and assignee.
 func (h *Handler) GetAlerts(c *gin.Context) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	limit := 100
-	if len(h.alerts) < limit {
-		limit = len(h.alerts)
+	filter := alerts.Filter{Assignee: c.Query("assignee"), Limit: 100}
+	if value := c.Query("status"); value != "" {
+		status, err := alerts.ParseStatus(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Status = status
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	result := h.alerts.List(filter)
+	if result == nil {
+		result = []AlertResponse{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetAlert(c *gin.Context) {
+	alert, ok := h.alerts.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": alerts.ErrNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+func (h *Handler) SetAlertStatus(c *gin.Context) {
+	var req AlertStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status, err := alerts.ParseStatus(req.Status)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	alert, err := h.alerts.SetStatus(c.Param("id"), status, actor(c), req.Note)
+	h.respondAlertChange(c, alert, err)
+}
+
+func (h *Handler) AssignAlert(c *gin.Context) {
+	var req AlertAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	alert, err := h.alerts.Assign(c.Param("id"), strings.TrimSpace(req.Assignee), actor(c))
+	h.respondAlertChange(c, alert, err)
+}
 
-	alerts := h.alerts
-	if len(alerts) > limit {
-		alerts = alerts[len(alerts)-limit:]
+func (h *Handler) CommentAlert(c *gin.Context) {
+	var req AlertCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	alert, err := h.alerts.AddComment(c.Param("id"), actor(c), req.Text)
+	h.respondAlertChange(c, alert, err)
+}
 
-	c.JSON(http.StatusOK, alerts)
+// respondAlertChange answers a lifecycle request and pushes the changed alert
+// to every open dashboard.
+func (h *Handler) respondAlertChange(c *gin.Context, alert AlertResponse, err error) {
+	if errors.Is(err, alerts.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.saveState(alertsFile, h.alerts.Marshal)
+	h.broadcastAlert(alert)
+	c.JSON(http.StatusOK, alert)
+}
+
+// actor is the user the auth middleware identified for the request. Request
+// bodies cannot name a different one.
+func actor(c *gin.Context) string {
+	return c.GetString(userKey)
 }
 
 func (h *Handler) WebSocketAlerts(c *gin.Context) {
@@ -287,24 +828,18 @@ func (h *Handler) WebSocketAlerts(c *gin.Context) {
 	}
 	defer conn.Close()
 
+	client := &wsClient{conn: conn, send: make(chan AlertResponse, wsSendBuffer)}
 	h.wsMu.Lock()
-	h.wsConnections[conn] = struct{}{}
+	h.wsClients[client] = struct{}{}
 	h.wsMu.Unlock()
 	defer func() {
 		h.wsMu.Lock()
-		delete(h.wsConnections, conn)
+		delete(h.wsClients, client)
 		h.wsMu.Unlock()
 	}()
 
-	h.mu.RLock()
-	recentAlerts := h.alerts
-	if len(recentAlerts) > 50 {
-		recentAlerts = recentAlerts[len(recentAlerts)-50:]
-	}
-	h.mu.RUnlock()
-
-	for _, alert := range recentAlerts {
-		if err := conn.WriteJSON(alert); err != nil {
+	for _, alert := range h.alerts.List(alerts.Filter{Limit: 50}) {
+		if err := client.write(alert); err != nil {
 			return
 		}
 	}
@@ -326,7 +861,15 @@ func (h *Handler) WebSocketAlerts(c *gin.Context) {
 		select {
 		case <-done:
 			return
+		case alert, ok := <-client.send:
+			if !ok {
+				return
+			}
+			if err := client.write(alert); err != nil {
+				return
+			}
 		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
 			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -334,25 +877,36 @@ func (h *Handler) WebSocketAlerts(c *gin.Context) {
 	}
 }
 
-func (h *Handler) GetStats(c *gin.Context) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+func (c *wsClient) write(alert AlertResponse) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(alert)
+}
 
+func (h *Handler) GetStats(c *gin.Context) {
+	stored := h.alerts.List(alerts.Filter{})
 	severityCount := make(map[string]int)
-	for _, alert := range h.alerts {
+	for _, alert := range stored {
 		severityCount[severityToTurkish(alert.Severity)]++
 	}
+	statusCount := make(map[string]int)
+	for status, count := range h.alerts.CountByStatus() {
+		statusCount[string(status)] = count
+	}
 
 	watchedFiles := h.tailer.GetWatchedFiles()
-	isTailing := len(watchedFiles) > 0
+	activeInputs := h.tailer.GetInputs()
+	isTailing := len(watchedFiles) > 0 || len(activeInputs) > 0
 
 	stats := StatsResponse{
-		TotalAlerts:      len(h.alerts),
+		TotalAlerts:      len(stored),
 		SeverityCount:    severityCount,
 		ActiveRules:      len(h.ruleManager.GetEnabledRules()),
 		WatchedFiles:     len(watchedFiles),
 		IsTailing:        isTailing,
 		WatchedFilesList: watchedFiles,
+		ActiveInputs:     activeInputs,
+		StatusCount:      statusCount,
+		DroppedAlerts:    h.tailer.DroppedAlerts(),
 	}
 
 	c.JSON(http.StatusOK, stats)
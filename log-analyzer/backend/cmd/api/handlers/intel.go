@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIntelFeeds reports the indicators loaded from each IOC feed.
+func (h *Handler) GetIntelFeeds(c *gin.Context) {
+	c.JSON(http.StatusOK, h.intel.Statuses())
+}
+
+// ReloadIntelFeeds reads every feed again without waiting for the next
+// refresh.
+func (h *Handler) ReloadIntelFeeds(c *gin.Context) {
+	c.JSON(http.StatusOK, h.intel.Reload(true))
+}
+
+// LookupIndicator checks a single value against the feeds.
+func (h *Handler) LookupIndicator(c *gin.Context) {
+	value := strings.TrimSpace(c.Query("value"))
+	if value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value is required"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"value": value, "hits": h.intel.Lookup(value)})
+}
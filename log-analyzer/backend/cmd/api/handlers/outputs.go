@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"log-analyzer/backend/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeadLetterRetryRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// GetOutputs reports the delivery counters of the enabled outputs.
+func (h *Handler) GetOutputs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notifier.Statuses())
+}
+
+// TestOutput sends a sample alert to an output right away, without retries,
+// and reports the result.
+func (h *Handler) TestOutput(c *gin.Context) {
+	output, ok := h.ruleManager.GetOutput(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "output not found"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	if err := notify.Test(ctx, output); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test alert sent"})
+}
+
+// FlushDigest sends an output's pending digest now instead of at its next
+// scheduled time.
+func (h *Handler) FlushDigest(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+	err := h.notifier.FlushDigest(ctx, c.Param("name"))
+	if errors.Is(err, notify.ErrNoDigest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Digest sent"})
+}
+
+func (h *Handler) GetDeadLetters(c *gin.Context) {
+	c.JSON(http.StatusOK, h.notifier.DeadLetters())
+}
+
+// RetryDeadLetters queues the given dead letters, or all of them, again.
+func (h *Handler) RetryDeadLetters(c *gin.Context) {
+	var req DeadLetterRetryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	retried := h.notifier.RetryDeadLetters(req.IDs...)
+	h.saveState(deadLettersFile, h.notifier.Marshal)
+	c.JSON(http.StatusOK, gin.H{"retried": retried})
+}
+
+func (h *Handler) ClearDeadLetters(c *gin.Context) {
+	removed := h.notifier.ClearDeadLetters()
+	h.saveState(deadLettersFile, h.notifier.Marshal)
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"log-analyzer/backend/cmd/api/handlers"
 	"log-analyzer/backend/internal/rules"
@@ -27,7 +33,7 @@ func main() {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -49,7 +55,7 @@ func main() {
 		if token == "" && c.Request.URL.Path == "/api/tail/ws" {
 			token = c.Query("token")
 		}
-		if token != handlers.AuthToken() {
+		if !handlers.Authenticate(c, token) {
 			c.AbortWithStatusJSON(401, gin.H{"error": "Yetkisiz"})
 			return
 		}
@@ -57,12 +63,50 @@ func main() {
 	})
 	api.POST("/login", handler.Login)
 	api.GET("/rules", handler.GetRules)
+	api.GET("/rules/stats", handler.GetRuleStats)
+	api.DELETE("/rules/stats", handler.ResetRuleStats)
+	api.POST("/rules/test", handler.TestRules)
+	api.POST("/rules/lint", handler.LintRules)
+	api.POST("/rules/backtest", handler.BacktestRule)
+	api.GET("/suppressions", handler.GetSuppressions)
+	api.POST("/suppressions", handler.AddSuppression)
+	api.DELETE("/suppressions", handler.PruneSuppressions)
+	api.DELETE("/suppressions/:id", handler.RemoveSuppression)
 	api.GET("/logfiles", handler.GetLogFiles)
 	api.POST("/analyze", handler.AnalyzeFiles)
+	api.POST("/export", handler.ExportReport)
 	api.POST("/tail/start", handler.StartTailing)
 	api.POST("/tail/stop", handler.StopTailing)
 	api.GET("/tail/alerts", handler.GetAlerts)
 	api.GET("/tail/ws", handler.WebSocketAlerts)
+	api.GET("/alerts", handler.GetAlerts)
+	api.GET("/alerts/:id", handler.GetAlert)
+	api.POST("/alerts/:id/status", handler.SetAlertStatus)
+	api.POST("/alerts/:id/assign", handler.AssignAlert)
+	api.POST("/alerts/:id/comments", handler.CommentAlert)
+	api.GET("/cases", handler.GetCases)
+	api.POST("/cases", handler.CreateCase)
+	api.GET("/cases/:id", handler.GetCase)
+	api.POST("/cases/:id/alerts", handler.AddCaseAlerts)
+	api.POST("/cases/:id/status", handler.SetCaseStatus)
+	api.POST("/cases/:id/severity", handler.SetCaseSeverity)
+	api.POST("/cases/:id/notes", handler.AddCaseNote)
+	api.GET("/cases/:id/export", handler.ExportCase)
+	api.GET("/outputs", handler.GetOutputs)
+	api.POST("/outputs/:name/test", handler.TestOutput)
+	api.POST("/outputs/:name/digest", handler.FlushDigest)
+	api.GET("/outputs/deadletters", handler.GetDeadLetters)
+	api.POST("/outputs/deadletters/retry", handler.RetryDeadLetters)
+	api.DELETE("/outputs/deadletters", handler.ClearDeadLetters)
+	api.GET("/actions", handler.GetActions)
+	api.GET("/actions/log", handler.GetActionLog)
+	api.POST("/actions/:name/block", handler.BlockTarget)
+	api.POST("/actions/:name/unblock", handler.UnblockTarget)
+	api.GET("/intel/feeds", handler.GetIntelFeeds)
+	api.POST("/intel/feeds/reload", handler.ReloadIntelFeeds)
+	api.GET("/intel/lookup", handler.LookupIndicator)
+	api.GET("/dns/stats", handler.GetDNSStats)
+	api.GET("/entities/top", handler.GetTopEntities)
 	api.GET("/stats", handler.GetStats)
 	r.Static("/assets", "./frontend/dist/assets")
 	r.StaticFile("/", "./frontend/dist/index.html")
@@ -75,8 +119,25 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	// Stop cleanly on SIGINT/SIGTERM so statistics and other state are
+	// saved instead of losing what changed since the last periodic save.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
 	}
+	handler.Close()
 }
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/intel"
+	"log-analyzer/backend/internal/rules"
+	"log-analyzer/backend/internal/tailer"
+)
+
+// sourceFlags are shared by analyze and tail. Sources are given as
+// positional arguments or --files; "-" means stdin.
+type sourceFlags struct {
+	configPath  string
+	files       string
+	logType     string
+	name        string
+	inputFormat string
+	severity    string
+	format      string
+	output      string
+}
+
+func (f *sourceFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.configPath, "config", defaultConfigPath, "kural yapılandırma dosyası")
+	fs.StringVar(&f.files, "files", "", "virgülle ayrılmış dosya listesi (boşsa etkin log dosyaları)")
+	fs.StringVar(&f.logType, "type", "", "kaynağın log türü (örn. nginx, auth)")
+	fs.StringVar(&f.name, "name", "stdin", "standart girdi için kaynak adı")
+	fs.StringVar(&f.inputFormat, "input-format", "", "girdi biçimi: plain, journal-json, journal-export")
+	fs.StringVar(&f.severity, "severity", "", "en düşük önem derecesi (low, medium, high, critical)")
+	fs.StringVar(&f.format, "format", "text", "çıktı biçimi: text, csv, json, ndjson, sarif, markdown, html")
+	fs.StringVar(&f.output, "output", "", "çıktı dosyası (varsayılan stdout)")
+}
+
+// sources returns the requested sources, or the enabled log files from the
+// config (fromConfig) when none were given.
+func (f *sourceFlags) sources(ruleManager *rules.Manager, positional []string) (sources []string, fromConfig bool) {
+	sources = positional
+	for _, file := range strings.Split(f.files, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			sources = append(sources, file)
+		}
+	}
+	if len(sources) == 0 {
+		for _, file := range ruleManager.GetEnabledLogFiles() {
+			sources = append(sources, file.Path)
+		}
+		return sources, true
+	}
+	return sources, false
+}
+
+func (f *sourceFlags) logFile(ruleManager *rules.Manager, source string) rules.LogFile {
+	if source == "-" {
+		return rules.LogFile{Path: f.name, Type: f.logType, Format: f.inputFormat, Enabled: true}
+	}
+	logFile, found := ruleManager.GetLogFile(source)
+	if abs, err := filepath.Abs(source); !found && err == nil {
+		if configured, ok := ruleManager.GetLogFile(abs); ok {
+			logFile = configured
+			logFile.Path = source
+		}
+	}
+	if f.logType != "" {
+		logFile.Type = f.logType
+	}
+	if f.inputFormat != "" {
+		logFile.Format = f.inputFormat
+	}
+	return logFile
+}
+
+func (f *sourceFlags) accepts(severity string) bool {
+	return f.severity == "" || analyzer.SeverityLevel(severity) >= analyzer.SeverityLevel(f.severity)
+}
+
+func (f *sourceFlags) validate() error {
+	if f.severity != "" && analyzer.SeverityLevel(f.severity) == 0 {
+		return fmt.Errorf("unknown severity %q", f.severity)
+	}
+	return checkOutputFormat(f.format)
+}
+
+// parseInterspersed lets flags follow positional arguments, so both
+// `analyze --type nginx -` and `analyze - --type nginx` work.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runAnalyze(args []string) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	var flags sourceFlags
+	flags.register(fs)
+	failOn := fs.String("fail-on", "", "bu önem derecesi veya üzerinde uyarı varsa 1 ile çık (örn. high)")
+	maxAlerts := &maxAlertsFlag{defaultBudget: -1}
+	fs.Var(maxAlerts, "max-alerts", "kural başına izin verilen uyarı sayısı: N veya 'Kural Adı=N' (tekrarlanabilir)")
+	summaryFormat := fs.String("summary", "text", "sonuç özeti biçimi (stderr): text, json, none")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitConfigError
+	}
+	if err := flags.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: %v\n", err)
+		return exitConfigError
+	}
+	if *failOn != "" && analyzer.SeverityLevel(*failOn) == 0 {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: unknown severity %q\n", *failOn)
+		return exitConfigError
+	}
+	if !isSummaryFormat(*summaryFormat) {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: unknown summary format %q (valid: %s)\n", *summaryFormat, strings.Join(summaryFormats, ", "))
+		return exitConfigError
+	}
+
+	ruleManager, err := rules.NewManager(flags.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return exitConfigError
+	}
+	sources, fromConfig := flags.sources(ruleManager, positional)
+	if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "Analiz edilecek dosya veya '-' (stdin) belirtin.")
+		return exitConfigError
+	}
+
+	writer, err := newEntryWriter(flags.format, flags.output, ruleManager.GetRules())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+		return exitIOError
+	}
+	// A single pass needs no periodic feed reloads.
+	_, feeds := ruleManager.GetThreatIntel()
+	analyzer := analyzer.NewAnalyzer(ruleManager)
+	analyzer.SetIntel(intel.NewMatcher(0, feeds))
+	summary := newAnalyzeSummary(*failOn)
+
+	for _, source := range sources {
+		logFile := flags.logFile(ruleManager, source)
+		entries, err := analyzeSource(analyzer, source, logFile)
+		if err != nil {
+			// Yapılandırmadan gelen ama bu makinede olmayan dosyalar hata sayılmaz.
+			if fromConfig && os.IsNotExist(err) {
+				continue
+			}
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", logFile.Path, err))
+			continue
+		}
+		summary.Files++
+		for _, entry := range entries {
+			if !flags.accepts(entry.Severity) {
+				continue
+			}
+			if err := writer.Write(entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+				writer.Close()
+				return exitIOError
+			}
+			summary.add(entry)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+		return exitIOError
+	}
+
+	code := summary.evaluate(maxAlerts)
+	summary.write(os.Stderr, *summaryFormat)
+	return code
+}
+
+func analyzeSource(a *analyzer.Analyzer, source string, logFile rules.LogFile) ([]analyzer.LogEntry, error) {
+	if source == "-" {
+		return a.AnalyzeReader(os.Stdin, logFile)
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return a.AnalyzeReader(file, logFile)
+}
+
+// reportRepeat limits how often a grouped alert is written again while it
+// keeps repeating: on the first occurrence and at 10, 100, 1000...
+func reportRepeat(count int) bool {
+	for count >= 10 && count%10 == 0 {
+		count /= 10
+	}
+	return count <= 1
+}
+
+func runTail(args []string) int {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	var flags sourceFlags
+	flags.register(fs)
+	withInputs := fs.Bool("inputs", false, "yapılandırmadaki etkin girdileri (syslog, journal) de başlat")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitConfigError
+	}
+	if err := flags.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: %v\n", err)
+		return exitConfigError
+	}
+
+	ruleManager, err := rules.NewManager(flags.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return exitConfigError
+	}
+	defer saveStats(ruleManager)
+	sources, _ := flags.sources(ruleManager, positional)
+	if len(sources) == 0 && !*withInputs {
+		fmt.Fprintln(os.Stderr, "İzlenecek dosya veya '-' (stdin) belirtin.")
+		return exitConfigError
+	}
+
+	writer, err := newEntryWriter(flags.format, flags.output, ruleManager.GetRules())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+		return exitIOError
+	}
+	tailer := tailer.NewTailer(ruleManager)
+	matcher := intel.NewMatcher(ruleManager.GetThreatIntel())
+	defer matcher.Close()
+	tailer.SetIntel(matcher)
+
+	var stdinDone <-chan struct{}
+	for _, source := range sources {
+		logFile := flags.logFile(ruleManager, source)
+		if source == "-" {
+			done, err := tailer.StartReader(logFile, os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Uyarı: stdin izlenemiyor: %v\n", err)
+				continue
+			}
+			stdinDone = done
+			continue
+		}
+		if err := tailer.StartLogFile(logFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Uyarı: %s dosyası izlenemiyor: %v\n", source, err)
+		}
+	}
+	if *withInputs {
+		for _, input := range ruleManager.GetEnabledInputs() {
+			if err := tailer.StartInput(input); err != nil {
+				fmt.Fprintf(os.Stderr, "Uyarı: %s girdisi başlatılamadı: %v\n", input.Name, err)
+			}
+		}
+	}
+	if len(tailer.GetWatchedFiles()) == 0 && len(tailer.GetInputs()) == 0 {
+		fmt.Fprintln(os.Stderr, "İzlenebilecek kaynak yok.")
+		writer.Close()
+		return exitIOError
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for alert := range tailer.Alerts() {
+			if !flags.accepts(alert.Severity) || !reportRepeat(alert.Count) {
+				continue
+			}
+			if err := writer.Write(entryFromAlert(alert)); err != nil {
+				fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+			}
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	if stdinDone != nil && len(sources) == 1 && !*withInputs {
+		select {
+		case <-signals:
+		case <-stdinDone:
+		}
+	} else {
+		<-signals
+	}
+	tailer.Stop()
+	<-done
+	if err := writer.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+		return exitIOError
+	}
+	return exitOK
+}
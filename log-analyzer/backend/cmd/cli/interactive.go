@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/intel"
+	"log-analyzer/backend/internal/rules"
+	"log-analyzer/backend/internal/tailer"
+)
+
+func runInteractive(args []string) int {
+	fs := flag.NewFlagSet("interactive", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath, "kural yapılandırma dosyası")
+	if err := fs.Parse(args); err != nil {
+		return exitConfigError
+	}
+	if fs.NArg() > 0 {
+		*configPath = fs.Arg(0)
+	}
+
+	ruleManager, err := rules.NewManager(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return exitConfigError
+	}
+	defer saveStats(ruleManager)
+
+	matcher := intel.NewMatcher(ruleManager.GetThreatIntel())
+	defer matcher.Close()
+	analyzer := analyzer.NewAnalyzer(ruleManager)
+	analyzer.SetIntel(matcher)
+	tailer := tailer.NewTailer(ruleManager)
+	tailer.SetIntel(matcher)
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for {
+		printMenu()
+		fmt.Print("Seçiminiz: ")
+		
+		if !scanner.Scan() {
+			break
+		}
+
+		choice := strings.TrimSpace(scanner.Text())
+
+		switch choice {
+		case "1":
+			analyzeFiles(analyzer, ruleManager)
+		case "2":
+			startTailing(tailer, ruleManager)
+		case "3":
+			viewRules(ruleManager)
+		case "4":
+			viewLogFiles(ruleManager)
+		case "5":
+			fmt.Println("Çıkılıyor...")
+			tailer.Stop()
+			return exitOK
+		default:
+			fmt.Println("Geçersiz seçim! Lütfen 1-5 arası bir sayı girin.")
+		}
+	}
+	return exitOK
+}
+
+func printMenu() {
+	fmt.Println("\n=== Log Analiz ve Uyarı Aracı ===")
+	fmt.Println("1. Dosya Bazlı Analiz")
+	fmt.Println("2. Gerçek Zamanlı İzleme (Tailing)")
+	fmt.Println("3. Kuralları Görüntüle")
+	fmt.Println("4. Log Dosyalarını Görüntüle")
+	fmt.Println("5. Çıkış")
+	fmt.Println()
+}
+
+func analyzeFiles(analyzer *analyzer.Analyzer, ruleManager *rules.Manager) {
+	fmt.Println("\n=== Dosya Bazlı Analiz ===")
+	
+	logFiles := ruleManager.GetEnabledLogFiles()
+	if len(logFiles) == 0 {
+		fmt.Println("Etkin log dosyası bulunamadı!")
+		return
+	}
+
+	fmt.Println("\nEtkin log dosyaları:")
+	for i, file := range logFiles {
+		fmt.Printf("%d. %s (%s)\n", i+1, file.Path, file.Type)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	fmt.Print("\nAnaliz edilecek dosyaları seçin (virgülle ayırın, 'all' için tümünü seçin): ")
+	scanner.Scan()
+	selection := strings.TrimSpace(scanner.Text())
+
+	var filesToAnalyze []string
+	if strings.ToLower(selection) == "all" {
+		for _, file := range logFiles {
+			filesToAnalyze = append(filesToAnalyze, file.Path)
+		}
+	} else {
+		indices := strings.Split(selection, ",")
+		for _, idxStr := range indices {
+			var idx int
+			if _, err := fmt.Sscanf(strings.TrimSpace(idxStr), "%d", &idx); err == nil && idx > 0 && idx <= len(logFiles) {
+				filesToAnalyze = append(filesToAnalyze, logFiles[idx-1].Path)
+			}
+		}
+	}
+
+	if len(filesToAnalyze) == 0 {
+		fmt.Println("Geçerli dosya seçilmedi!")
+		return
+	}
+
+	fmt.Println("\nAnaliz başlatılıyor...")
+	entries, err := analyzer.AnalyzeMultipleFiles(filesToAnalyze)
+	if err != nil {
+		fmt.Printf("Analiz hatası: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nToplam %d uyarı bulundu.\n", len(entries))
+	severityCount := make(map[string]int)
+	for _, entry := range entries {
+		severityCount[entry.Severity]++
+	}
+
+	fmt.Println("\nÖzet:")
+	for severity, count := range severityCount {
+		fmt.Printf("  %s: %d\n", severity, count)
+	}
+	fmt.Print("\nRapor olarak kaydetmek ister misiniz? (e/h): ")
+	scanner.Scan()
+	if strings.ToLower(strings.TrimSpace(scanner.Text())) == "e" {
+		fmt.Print("Biçim (csv, json, ndjson, sarif, markdown, html) [csv]: ")
+		scanner.Scan()
+		format := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if format == "" {
+			format = "csv"
+		}
+		fmt.Print("Dosya adı (örn: report.csv): ")
+		scanner.Scan()
+		outputPath := strings.TrimSpace(scanner.Text())
+		if outputPath == "" {
+			outputPath = "report." + format
+		}
+
+		if err := saveReport(entries, format, outputPath, ruleManager.GetRules()); err != nil {
+			fmt.Printf("Rapor kaydetme hatası: %v\n", err)
+		} else {
+			fmt.Printf("Rapor %s dosyasına kaydedildi.\n", outputPath)
+		}
+	}
+	fmt.Println("\nSon 10 uyarı:")
+	start := 0
+	if len(entries) > 10 {
+		start = len(entries) - 10
+	}
+	for i := start; i < len(entries); i++ {
+		printEntry(entries[i])
+	}
+}
+
+func saveReport(entries []analyzer.LogEntry, format, outputPath string, ruleSet []rules.Rule) error {
+	writer, err := newEntryWriter(format, outputPath, ruleSet)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := writer.Write(entry); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func startTailing(tailer *tailer.Tailer, ruleManager *rules.Manager) {
+	fmt.Println("\n=== Gerçek Zamanlı İzleme ===")
+	
+	logFiles := ruleManager.GetEnabledLogFiles()
+	if len(logFiles) == 0 {
+		fmt.Println("Etkin log dosyası bulunamadı!")
+		return
+	}
+
+	fmt.Println("\nEtkin log dosyaları:")
+	for i, file := range logFiles {
+		fmt.Printf("%d. %s (%s)\n", i+1, file.Path, file.Type)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	fmt.Print("\nİzlenecek dosyaları seçin (virgülle ayırın, 'all' için tümünü seçin): ")
+	scanner.Scan()
+	selection := strings.TrimSpace(scanner.Text())
+
+	var filesToWatch []string
+	if strings.ToLower(selection) == "all" {
+		for _, file := range logFiles {
+			filesToWatch = append(filesToWatch, file.Path)
+		}
+	} else {
+		indices := strings.Split(selection, ",")
+		for _, idxStr := range indices {
+			var idx int
+			if _, err := fmt.Sscanf(strings.TrimSpace(idxStr), "%d", &idx); err == nil && idx > 0 && idx <= len(logFiles) {
+				filesToWatch = append(filesToWatch, logFiles[idx-1].Path)
+			}
+		}
+	}
+
+	if len(filesToWatch) == 0 {
+		fmt.Println("Geçerli dosya seçilmedi!")
+		return
+	}
+
+	var inputsStarted []string
+	for _, input := range ruleManager.GetEnabledInputs() {
+		if err := tailer.StartInput(input); err != nil {
+			fmt.Printf("Uyarı: %s girdisi başlatılamadı: %v\n", input.Name, err)
+		} else {
+			inputsStarted = append(inputsStarted, input.Name)
+			fmt.Printf("✓ %s (%s) dinleniyor...\n", input.Name, input.Type)
+		}
+	}
+
+	for _, filePath := range filesToWatch {
+		if err := tailer.StartWatching(filePath); err != nil {
+			fmt.Printf("Uyarı: %s dosyası izlenemiyor: %v\n", filePath, err)
+		} else {
+			fmt.Printf("✓ %s izleniyor...\n", filePath)
+		}
+	}
+
+	fmt.Println("\nGerçek zamanlı izleme başlatıldı. Uyarılar aşağıda görüntülenecek.")
+	fmt.Print("Durdurmak için 'q' tuşuna basın.\n\n")
+	go func() {
+		for alert := range tailer.Alerts() {
+			printAlert(alert)
+		}
+	}()
+	scanner.Scan()
+	if strings.ToLower(strings.TrimSpace(scanner.Text())) == "q" {
+		for _, filePath := range filesToWatch {
+			tailer.StopWatching(filePath)
+		}
+		for _, name := range inputsStarted {
+			tailer.StopInput(name)
+		}
+		fmt.Println("İzleme durduruldu.")
+	}
+}
+
+func viewRules(ruleManager *rules.Manager) {
+	printRules(ruleManager.GetRules(), statsByRule(ruleManager))
+}
+
+func printRules(rules []rules.Rule, stats map[string]rules.RuleStats) {
+	fmt.Println("\n=== Kurallar ===")
+	
+	if len(rules) == 0 {
+		fmt.Println("Kural bulunamadı!")
+		return
+	}
+
+	for i, rule := range rules {
+		status := "Pasif"
+		if rule.Enabled {
+			status = "Aktif"
+		}
+		fmt.Printf("\n%d. %s [%s] - %s\n", i+1, rule.Name, status, rule.Severity)
+		fmt.Printf("   Desen: %s\n", rule.Pattern)
+		fmt.Printf("   Açıklama: %s\n", rule.Description)
+		if len(rule.LogTypes) > 0 || len(rule.Paths) > 0 {
+			fmt.Printf("   Kapsam: %s\n", strings.Join(append(append([]string{}, rule.LogTypes...), rule.Paths...), ", "))
+		}
+		if s, ok := stats[rule.Name]; ok && s.TotalHits > 0 {
+			fmt.Printf("   Eşleşme: %d (saatte %.1f, %d bastırıldı), son: %s\n", s.TotalHits, s.AvgHitsPerHour, s.SuppressedHits, s.LastSeen.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+func viewLogFiles(ruleManager *rules.Manager) {
+	fmt.Println("\n=== Log Dosyaları ===")
+	logFiles := ruleManager.GetLogFiles()
+	
+	if len(logFiles) == 0 {
+		fmt.Println("Log dosyası bulunamadı!")
+		return
+	}
+
+	for i, file := range logFiles {
+		status := "Pasif"
+		if file.Enabled {
+			status = "Aktif"
+		}
+		
+		exists := "✓"
+		if _, err := os.Stat(file.Path); os.IsNotExist(err) {
+			exists = "✗"
+		}
+		
+		fmt.Printf("\n%d. %s [%s] - %s\n", i+1, file.Path, status, file.Type)
+		fmt.Printf("   Durum: %s\n", exists)
+	}
+}
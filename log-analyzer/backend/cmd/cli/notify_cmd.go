@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"log-analyzer/backend/internal/notify"
+)
+
+func runNotify(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli notify <test|sink|smtp-sink> [seçenekler]")
+		return exitConfigError
+	}
+	switch args[0] {
+	case "test":
+		return runNotifyTest(args[1:])
+	case "sink":
+		return runNotifySink(args[1:])
+	case "smtp-sink":
+		return runSMTPSink(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Bilinmeyen notify komutu: %s\n", args[0])
+		return exitConfigError
+	}
+}
+
+// runNotifyTest sends a sample alert to the named outputs right away.
+func runNotifyTest(args []string) int {
+	fs := flag.NewFlagSet("notify test", flag.ContinueOnError)
+	ruleManager, names, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli notify test <çıkış adı>...")
+		return exitConfigError
+	}
+
+	code := exitOK
+	for _, name := range names {
+		output, found := ruleManager.GetOutput(name)
+		if !found {
+			fmt.Fprintf(os.Stderr, "Çıkış bulunamadı: %s\n", name)
+			code = exitConfigError
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := notify.Test(ctx, output)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: gönderilemedi: %v\n", name, err)
+			code = exitIOError
+			continue
+		}
+		fmt.Printf("%s: test uyarısı gönderildi\n", name)
+	}
+	return code
+}
+
+// runNotifySink is a local stand-in for a webhook receiver: it prints every
+// request, checks signatures and can fail the first requests to exercise
+// retries.
+func runNotifySink(args []string) int {
+	fs := flag.NewFlagSet("notify sink", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:9099", "dinlenecek adres")
+	secret := fs.String("secret", "", "imzaları doğrulamak için HMAC anahtarı")
+	failFirst := fs.Int("fail", 0, "ilk N isteğe 500 döndür")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return exitConfigError
+	}
+
+	var received int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&received, 1)
+		body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		signature := ""
+		if *secret != "" {
+			expected := "sha256=" + notify.Sign([]byte(*secret), body)
+			if got := r.Header.Get(notify.SignatureHeader); got == "" {
+				signature = " imza: yok"
+			} else if hmac.Equal([]byte(expected), []byte(got)) {
+				signature = " imza: geçerli"
+			} else {
+				signature = " imza: GEÇERSİZ"
+			}
+		}
+		status := http.StatusOK
+		if n <= int64(*failFirst) {
+			status = http.StatusInternalServerError
+		}
+		fmt.Printf("#%d %s %s %s -> %d%s\n%s\n\n", n, time.Now().Format("15:04:05"), r.Method, r.URL.Path, status, signature, strings.TrimSpace(string(body)))
+		w.WriteHeader(status)
+	})
+
+	fmt.Printf("Webhook alıcısı %s üzerinde dinliyor (Ctrl+C ile çıkış)\n", *addr)
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		fmt.Fprintf(os.Stderr, "Dinlenemedi: %v\n", err)
+		return exitIOError
+	}
+	return exitOK
+}
+
+// runSMTPSink is a local stand-in for a mail server: it accepts every
+// message without TLS or authentication and prints it decoded.
+func runSMTPSink(args []string) int {
+	fs := flag.NewFlagSet("notify smtp-sink", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:2525", "dinlenecek adres")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return exitConfigError
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Dinlenemedi: %v\n", err)
+		return exitIOError
+	}
+	fmt.Printf("SMTP alıcısı %s üzerinde dinliyor (Ctrl+C ile çıkış)\n", *addr)
+
+	var received int64
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Bağlantı kabul edilemedi: %v\n", err)
+			return exitIOError
+		}
+		go serveSMTP(conn, &received)
+	}
+}
+
+func serveSMTP(conn net.Conn, received *int64) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 log-analyzer smtp-sink")
+	var from string
+	var to []string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 log-analyzer")
+		case "MAIL":
+			from, to = smtpPath(arg), nil
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			to = append(to, smtpPath(arg))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			n := atomic.AddInt64(received, 1)
+			fmt.Printf("#%d %s %s -> %s\n%s\n", n, time.Now().Format("15:04:05"), from, strings.Join(to, ", "), decodeMail(data))
+			text.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// smtpPath returns the address of a "FROM:<a@b>" or "TO:<a@b>" argument.
+func smtpPath(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	return strings.Trim(strings.TrimSpace(path), "<>")
+}
+
+// decodeMail returns the subject and plain body of a message for printing.
+func decodeMail(data []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	var body io.Reader = msg.Body
+	if strings.EqualFold(msg.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(msg.Body)
+	}
+	content, _ := io.ReadAll(body)
+	return "Konu: " + subject + "\n\n" + strings.TrimSpace(strings.ReplaceAll(string(content), "\r\n", "\n")) + "\n"
+}
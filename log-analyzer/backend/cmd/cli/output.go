@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/rules"
+	"log-analyzer/backend/internal/tailer"
+)
+
+// entryWriter writes alerts one by one so analyze and tail share the same
+// output formats. Report formats (json, sarif, markdown, html) are buffered
+// and written on Close.
+type entryWriter interface {
+	Write(entry analyzer.LogEntry) error
+	Close() error
+}
+
+// checkOutputFormat rejects a format newEntryWriter does not know, before
+// the output file is truncated.
+func checkOutputFormat(format string) error {
+	switch format {
+	case "", "text", "csv", "ndjson", "jsonl":
+		return nil
+	}
+	if _, err := analyzer.NewExporter(format, nil); err != nil {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	return nil
+}
+
+func newEntryWriter(format, outputPath string, ruleSet []rules.Rule) (entryWriter, error) {
+	if err := checkOutputFormat(format); err != nil {
+		return nil, err
+	}
+	out := io.WriteCloser(nopCloser{os.Stdout})
+	if outputPath != "" && outputPath != "-" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		out = file
+	}
+
+	switch format {
+	case "", "text":
+		return &textWriter{out: out}, nil
+	case "csv":
+		w := &csvWriter{out: out, writer: csv.NewWriter(out)}
+		if err := w.writer.Write(analyzer.CSVHeader()); err != nil {
+			out.Close()
+			return nil, err
+		}
+		return w, nil
+	case "ndjson", "jsonl":
+		return &ndjsonWriter{out: out, encoder: json.NewEncoder(out)}, nil
+	default:
+		exporter, err := analyzer.NewExporter(format, ruleSet)
+		if err != nil {
+			out.Close()
+			return nil, fmt.Errorf("unsupported output format %q", format)
+		}
+		return &reportWriter{out: out, exporter: exporter}, nil
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+type textWriter struct {
+	out io.WriteCloser
+}
+
+func (w *textWriter) Write(entry analyzer.LogEntry) error {
+	fmt.Fprintf(w.out, "\n[%s] %s - %s\n", entry.Severity, entry.Timestamp, strings.Join(entry.MatchedRules, ", "))
+	if entry.Count > 1 {
+		fmt.Fprintf(w.out, "  Tekrar: %d kez, son: %s\n", entry.Count, entry.LastSeen)
+	}
+	fmt.Fprintf(w.out, "  Dosya: %s\n", entry.Source)
+	if entry.Host != "" {
+		fmt.Fprintf(w.out, "  Sunucu: %s\n", entry.Host)
+	}
+	_, err := fmt.Fprintf(w.out, "  Satır: %s\n", truncate(entry.Line, 150))
+	return err
+}
+
+func (w *textWriter) Close() error {
+	return w.out.Close()
+}
+
+type csvWriter struct {
+	out    io.WriteCloser
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(entry analyzer.LogEntry) error {
+	err := w.writer.Write(analyzer.CSVRecord(entry))
+	w.writer.Flush()
+	return err
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.out.Close()
+		return err
+	}
+	return w.out.Close()
+}
+
+type ndjsonWriter struct {
+	out     io.WriteCloser
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(entry analyzer.LogEntry) error {
+	return w.encoder.Encode(entry)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.out.Close()
+}
+
+type reportWriter struct {
+	out      io.WriteCloser
+	exporter analyzer.Exporter
+	entries  []analyzer.LogEntry
+}
+
+func (w *reportWriter) Write(entry analyzer.LogEntry) error {
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func (w *reportWriter) Close() error {
+	if err := w.exporter.Export(w.out, w.entries); err != nil {
+		w.out.Close()
+		return err
+	}
+	return w.out.Close()
+}
+
+func entryFromAlert(alert tailer.Alert) analyzer.LogEntry {
+	return analyzer.LogEntry{
+		Timestamp:    alert.Timestamp.Format("2006-01-02 15:04:05"),
+		Source:       alert.Source,
+		LogFile:      alert.LogFile,
+		Line:         alert.Line,
+		MatchedRules: alert.MatchedRules,
+		Severity:     alert.Severity,
+		Host:         alert.Host,
+		Fields:       alert.Fields,
+		Count:        alert.Count,
+		LastSeen:     alert.LastSeen.Format("2006-01-02 15:04:05"),
+		GroupKey:     alert.GroupKey,
+	}
+}
+
+func printEntry(entry analyzer.LogEntry) {
+	fmt.Printf("\n[%s] %s - %s\n", entry.Severity, entry.Timestamp, strings.Join(entry.MatchedRules, ", "))
+	if entry.Count > 1 {
+		fmt.Printf("  Tekrar: %d kez, son: %s\n", entry.Count, entry.LastSeen)
+	}
+	fmt.Printf("  Dosya: %s\n", entry.Source)
+	if entry.Host != "" {
+		fmt.Printf("  Sunucu: %s\n", entry.Host)
+	}
+	fmt.Printf("  Satır: %s\n", truncate(entry.Line, 100))
+}
+
+func printAlert(alert tailer.Alert) {
+	fmt.Printf("\n[%s] %s - %s\n", alert.Severity, alert.Timestamp.Format("2006-01-02 15:04:05"), strings.Join(alert.MatchedRules, ", "))
+	fmt.Printf("  Dosya: %s\n", alert.Source)
+	if alert.Host != "" {
+		fmt.Printf("  Sunucu: %s\n", alert.Host)
+	}
+	fmt.Printf("  Satır: %s\n", truncate(alert.Line, 150))
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
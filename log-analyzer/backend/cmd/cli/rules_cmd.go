@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/rules"
+
+	"gopkg.in/yaml.v3"
+)
+
+func runRules(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli rules <list|stats|test|match|lint|backtest|validate> [seçenekler]")
+		return exitConfigError
+	}
+	switch args[0] {
+	case "list":
+		return runRulesList(args[1:])
+	case "test":
+		return runRulesTest(args[1:])
+	case "match":
+		return runRulesMatch(args[1:])
+	case "stats":
+		return runRulesStats(args[1:])
+	case "lint":
+		return runRulesLint(args[1:])
+	case "backtest":
+		return runRulesBacktest(args[1:])
+	case "validate":
+		return runRulesValidate(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Bilinmeyen rules komutu: %s\n", args[0])
+		return exitConfigError
+	}
+}
+
+func loadManager(fs *flag.FlagSet, args []string) (*rules.Manager, []string, bool) {
+	configPath := fs.String("config", defaultConfigPath, "kural yapılandırma dosyası")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return nil, nil, false
+	}
+	ruleManager, err := rules.NewManager(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return nil, nil, false
+	}
+	return ruleManager, positional, true
+}
+
+func runRulesList(args []string) int {
+	fs := flag.NewFlagSet("rules list", flag.ContinueOnError)
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	enabledOnly := fs.Bool("enabled", false, "yalnızca etkin kuralları listele")
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+
+	ruleList := ruleManager.GetRules()
+	if *enabledOnly {
+		ruleList = ruleManager.GetEnabledRules()
+	}
+	if *format == "json" {
+		return writeJSON(ruleList)
+	}
+	printRules(ruleList, statsByRule(ruleManager))
+	return exitOK
+}
+
+// runRulesStats lists per-rule hit counters, noisiest first.
+func runRulesStats(args []string) int {
+	fs := flag.NewFlagSet("rules stats", flag.ContinueOnError)
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	reset := fs.Bool("reset", false, "sayaçları sıfırla")
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if *reset {
+		ruleManager.ResetStats()
+		if err := ruleManager.SaveStats(); err != nil {
+			fmt.Fprintf(os.Stderr, "İstatistikler kaydedilemedi: %v\n", err)
+			return exitIOError
+		}
+		fmt.Println("Kural istatistikleri sıfırlandı.")
+		return exitOK
+	}
+
+	stats := ruleManager.RuleStats()
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].TotalHits > stats[j].TotalHits })
+	if *format == "json" {
+		return writeJSON(stats)
+	}
+	fmt.Printf("%-32s %8s %10s %9s %-19s %-19s %10s\n", "Kural", "Eşleşme", "Bastırılan", "Saatlik", "İlk", "Son", "Süre")
+	for _, s := range stats {
+		first, last := "-", "-"
+		if s.FirstSeen != nil {
+			first = s.FirstSeen.Format("2006-01-02 15:04:05")
+			last = s.LastSeen.Format("2006-01-02 15:04:05")
+		}
+		name := s.Rule
+		if !s.Enabled {
+			name += " (pasif)"
+		}
+		fmt.Printf("%-32s %8d %10d %9.1f %-19s %-19s %10s\n", truncate(name, 32), s.TotalHits, s.SuppressedHits, s.AvgHitsPerHour, first, last, s.MatchTime.Round(time.Microsecond))
+		for path, hits := range s.HitsPerFile {
+			fmt.Printf("    %s: %d\n", path, hits)
+		}
+	}
+	return exitOK
+}
+
+func statsByRule(ruleManager *rules.Manager) map[string]rules.RuleStats {
+	stats := make(map[string]rules.RuleStats)
+	for _, s := range ruleManager.RuleStats() {
+		stats[s.Rule] = s
+	}
+	return stats
+}
+
+func saveStats(ruleManager *rules.Manager) {
+	if err := ruleManager.SaveStats(); err != nil {
+		fmt.Fprintf(os.Stderr, "Uyarı: kural istatistikleri kaydedilemedi: %v\n", err)
+	}
+}
+
+// runRulesTest runs the should_match/should_not_match examples embedded in
+// the config. Given lines, it behaves like `rules match` for compatibility.
+func runRulesTest(args []string) int {
+	fs := flag.NewFlagSet("rules test", flag.ContinueOnError)
+	ruleName := fs.String("rule", "", "yalnızca bu kuralı test et")
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	ruleManager, lines, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if len(lines) > 0 {
+		return matchLines(ruleManager, rules.LogFile{}, lines, *ruleName)
+	}
+
+	var results []rules.TestResult
+	for _, result := range ruleManager.RunTests() {
+		if *ruleName == "" || result.Rule == *ruleName {
+			results = append(results, result)
+		}
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Failed() {
+			failed++
+		}
+	}
+
+	if *format == "json" {
+		if code := writeJSON(results); code != exitOK {
+			return code
+		}
+	} else {
+		for _, result := range results {
+			if !result.Failed() {
+				fmt.Printf("✓ %s (%d örnek)\n", result.Rule, result.Passed)
+				continue
+			}
+			fmt.Printf("✗ %s\n", result.Rule)
+			if result.Error != "" {
+				fmt.Printf("   hata: %s\n", result.Error)
+			}
+			for _, failure := range result.Failures {
+				expectation := "eşleşmemeliydi"
+				if failure.ExpectedMatch {
+					expectation = "eşleşmeliydi"
+				}
+				fmt.Printf("   %s: %s\n", expectation, truncate(failure.Line, 100))
+			}
+		}
+		fmt.Printf("\n%d kural test edildi, %d başarısız.\n", len(results), failed)
+	}
+	if failed > 0 {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// runRulesMatch shows which rules match each given line. Lines come from
+// the arguments, or from stdin when none (or "-") are given.
+func runRulesMatch(args []string) int {
+	fs := flag.NewFlagSet("rules match", flag.ContinueOnError)
+	ruleName := fs.String("rule", "", "yalnızca bu kuralı dene")
+	var logFile rules.LogFile
+	fs.StringVar(&logFile.Type, "type", "", "satırların geldiği log türü (kural kapsamı için)")
+	fs.StringVar(&logFile.Path, "path", "", "satırların geldiği dosya yolu (kural kapsamı için)")
+	ruleManager, lines, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if logFile.Path != "" {
+		if configured, found := ruleManager.GetLogFile(logFile.Path); found && logFile.Type == "" {
+			logFile.Type = configured.Type
+		}
+	}
+	return matchLines(ruleManager, logFile, lines, *ruleName)
+}
+
+// matchLines shows the rules matching each line. Without a type or path,
+// rule scopes are not applied.
+func matchLines(ruleManager *rules.Manager, logFile rules.LogFile, lines []string, ruleName string) int {
+	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "-") {
+		lines = nil
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	matchedLines := 0
+	for _, line := range lines {
+		var names []string
+		for _, rule := range ruleManager.MatchRules(logFile, line) {
+			if ruleName == "" || rule.Name == ruleName {
+				names = append(names, fmt.Sprintf("%s (%s)", rule.Name, rule.Severity))
+			}
+		}
+		if len(names) == 0 {
+			fmt.Printf("✗ %s\n", truncate(line, 100))
+			continue
+		}
+		matchedLines++
+		fmt.Printf("✓ %s\n   -> %s\n", truncate(line, 100), strings.Join(names, ", "))
+	}
+	fmt.Printf("\n%d/%d satır eşleşti.\n", matchedLines, len(lines))
+	return exitOK
+}
+
+// runRulesLint reports rule problems. The corpus for overlap and
+// performance checks defaults to the enabled log files that exist here.
+func runRulesLint(args []string) int {
+	fs := flag.NewFlagSet("rules lint", flag.ContinueOnError)
+	corpusFiles := fs.String("corpus", "", "virgülle ayrılmış örnek log dosyaları (boşsa etkin log dosyaları)")
+	noCorpus := fs.Bool("no-corpus", false, "yalnızca statik kontrolleri çalıştır")
+	maxLines := fs.Int("max-lines", 10000, "dosya başına okunacak en fazla satır")
+	minSeverity := fs.String("severity", rules.LintInfo, "gösterilecek en düşük bulgu derecesi: info, warning, error")
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if rules.LintSeverityLevel(*minSeverity) == 0 {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: unknown severity %q\n", *minSeverity)
+		return exitConfigError
+	}
+
+	var corpus []string
+	if !*noCorpus {
+		var paths []string
+		for _, path := range strings.Split(*corpusFiles, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			for _, file := range ruleManager.GetEnabledLogFiles() {
+				if info, err := os.Stat(file.Path); err == nil && info.Mode().IsRegular() {
+					paths = append(paths, file.Path)
+				}
+			}
+		}
+		var err error
+		if corpus, err = rules.ReadCorpus(paths, *maxLines); err != nil {
+			fmt.Fprintf(os.Stderr, "Örnek dosyalar okunamadı: %v\n", err)
+			return exitIOError
+		}
+	}
+
+	var findings []rules.LintFinding
+	errors := 0
+	for _, finding := range ruleManager.Lint(corpus) {
+		if finding.Severity == rules.LintError {
+			errors++
+		}
+		if rules.LintSeverityLevel(finding.Severity) >= rules.LintSeverityLevel(*minSeverity) {
+			findings = append(findings, finding)
+		}
+	}
+
+	if *format == "json" {
+		if code := writeJSON(findings); code != exitOK {
+			return code
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Printf("[%s] %s: %s (%s)\n", finding.Severity, finding.Rule, finding.Message, finding.Check)
+			if finding.Related != "" {
+				fmt.Printf("   ilgili kural: %s\n", finding.Related)
+			}
+		}
+		fmt.Printf("\n%d bulgu (%d örnek satır).\n", len(findings), len(corpus))
+	}
+	if errors > 0 {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// runRulesBacktest runs an unsaved rule, given by flags or a YAML file, over
+// historical logs and compares it with the saved rule of the same name.
+func runRulesBacktest(args []string) int {
+	fs := flag.NewFlagSet("rules backtest", flag.ContinueOnError)
+	var candidate rules.Rule
+	fs.StringVar(&candidate.Name, "name", "", "kural adı (kayıtlı kuralla karşılaştırmak için aynı ad)")
+	fs.StringVar(&candidate.Pattern, "pattern", "", "aday desen")
+	fs.StringVar(&candidate.ExcludePattern, "exclude", "", "aday hariç tutma deseni")
+	fs.StringVar(&candidate.Field, "field", "", "desenin uygulanacağı alan")
+	fs.StringVar(&candidate.Severity, "rule-severity", "orta", "aday kuralın önem derecesi")
+	ruleFile := fs.String("rule-file", "", "aday kuralı içeren YAML dosyası")
+	files := fs.String("files", "", "virgülle ayrılmış dosya listesi (boşsa etkin log dosyaları)")
+	from := fs.String("from", "", "başlangıç zamanı (RFC3339 veya 2006-01-02 15:04:05)")
+	to := fs.String("to", "", "bitiş zamanı")
+	bucket := fs.Duration("bucket", 0, "zaman aralığı boyutu (örn. 1h; boşsa otomatik)")
+	samples := fs.Int("samples", analyzer.DefaultBacktestSamples, "gösterilecek örnek sayısı")
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	ruleManager, positional, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+
+	if *ruleFile != "" {
+		data, err := os.ReadFile(*ruleFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Kural dosyası okunamadı: %v\n", err)
+			return exitIOError
+		}
+		if err := yaml.Unmarshal(data, &candidate); err != nil {
+			fmt.Fprintf(os.Stderr, "Kural dosyası geçersiz: %v\n", err)
+			return exitConfigError
+		}
+	}
+	if candidate.Pattern == "" {
+		fmt.Fprintln(os.Stderr, "Aday kural için --pattern veya --rule-file belirtin.")
+		return exitConfigError
+	}
+
+	opts := analyzer.BacktestOptions{Bucket: *bucket, Samples: *samples, Files: positional}
+	for _, file := range strings.Split(*files, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			opts.Files = append(opts.Files, file)
+		}
+	}
+	if len(opts.Files) == 0 {
+		for _, file := range ruleManager.GetEnabledLogFiles() {
+			opts.Files = append(opts.Files, file.Path)
+		}
+	}
+	var err error
+	if opts.From, err = parseTimeFlag(*from); err != nil {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: %v\n", err)
+		return exitConfigError
+	}
+	if opts.To, err = parseTimeFlag(*to); err != nil {
+		fmt.Fprintf(os.Stderr, "Geçersiz seçenek: %v\n", err)
+		return exitConfigError
+	}
+
+	result, err := analyzer.NewAnalyzer(ruleManager).Backtest(candidate, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Geriye dönük test hatası: %v\n", err)
+		return exitConfigError
+	}
+	if *format == "json" {
+		return writeJSON(result)
+	}
+	printBacktest(result)
+	return exitOK
+}
+
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+func printBacktest(result *analyzer.BacktestResult) {
+	fmt.Printf("Kural: %s\n", result.Rule)
+	fmt.Printf("Aday: %d eşleşme", result.CandidateHits)
+	if result.HasCurrent {
+		fmt.Printf(" | Mevcut: %d eşleşme | +%d yeni, -%d kaybolan, %d ortak", result.CurrentHits, result.Added, result.Removed, result.Unchanged)
+	}
+	fmt.Println()
+	if result.Undated > 0 {
+		fmt.Printf("Zamanı belirlenemeyen: %d\n", result.Undated)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Uyarı: %s\n", e)
+	}
+
+	if len(result.Buckets) > 0 {
+		peak := 1
+		for _, b := range result.Buckets {
+			if b.Candidate > peak {
+				peak = b.Candidate
+			}
+			if b.Current > peak {
+				peak = b.Current
+			}
+		}
+		fmt.Printf("\nZaman dağılımı (%s):\n", result.Bucket)
+		for _, b := range result.Buckets {
+			bar := strings.Repeat("█", (b.Candidate*40+peak-1)/peak)
+			if result.HasCurrent {
+				fmt.Printf("  %s %5d (mevcut %5d) %s\n", b.Start.Format("2006-01-02 15:04"), b.Candidate, b.Current, bar)
+			} else {
+				fmt.Printf("  %s %5d %s\n", b.Start.Format("2006-01-02 15:04"), b.Candidate, bar)
+			}
+		}
+	}
+
+	printSamples := func(title string, entries []analyzer.LogEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		for _, entry := range entries {
+			fmt.Printf("  %s:%d %s\n", entry.Source, entry.LineNumber, truncate(entry.Line, 120))
+		}
+	}
+	printSamples("Örnek eşleşmeler", result.Samples)
+	printSamples("Yalnızca aday kuralın yakaladıkları", result.AddedSamples)
+	printSamples("Aday kuralın artık yakalamadıkları", result.RemovedSamples)
+}
+
+func runRulesValidate(args []string) int {
+	fs := flag.NewFlagSet("rules validate", flag.ContinueOnError)
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	fmt.Printf("Yapılandırma geçerli: %d kural (%d etkin), %d log dosyası, %d girdi.\n",
+		len(ruleManager.GetRules()), len(ruleManager.GetEnabledRules()),
+		len(ruleManager.GetLogFiles()), len(ruleManager.GetInputs()))
+	return exitOK
+}
+
+func runLogFiles(args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli logfiles list [seçenekler]")
+		return exitConfigError
+	}
+	fs := flag.NewFlagSet("logfiles list", flag.ContinueOnError)
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	ruleManager, _, ok := loadManager(fs, args[1:])
+	if !ok {
+		return exitConfigError
+	}
+	if *format == "json" {
+		return writeJSON(ruleManager.GetLogFiles())
+	}
+	viewLogFiles(ruleManager)
+	return exitOK
+}
+
+func writeJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Çıktı hatası: %v\n", err)
+		return exitIOError
+	}
+	return exitOK
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"log-analyzer/backend/internal/analyzer"
+)
+
+// maxAlertsFlag collects --max-alerts values: "N" sets the budget for every
+// rule, "Rule Name=N" for a single rule. The flag may be repeated.
+type maxAlertsFlag struct {
+	defaultBudget int
+	perRule       map[string]int
+}
+
+func (f *maxAlertsFlag) String() string {
+	var parts []string
+	if f.defaultBudget >= 0 {
+		parts = append(parts, strconv.Itoa(f.defaultBudget))
+	}
+	for rule, budget := range f.perRule {
+		parts = append(parts, fmt.Sprintf("%s=%d", rule, budget))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *maxAlertsFlag) Set(value string) error {
+	rule := ""
+	if idx := strings.LastIndex(value, "="); idx >= 0 {
+		rule = strings.TrimSpace(value[:idx])
+		value = value[idx+1:]
+	}
+	budget, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || budget < 0 {
+		return fmt.Errorf("invalid alert budget %q", value)
+	}
+	if rule == "" {
+		f.defaultBudget = budget
+		return nil
+	}
+	if f.perRule == nil {
+		f.perRule = make(map[string]int)
+	}
+	f.perRule[rule] = budget
+	return nil
+}
+
+func (f *maxAlertsFlag) budget(rule string) (int, bool) {
+	if budget, ok := f.perRule[rule]; ok {
+		return budget, true
+	}
+	return f.defaultBudget, f.defaultBudget >= 0
+}
+
+type gateViolation struct {
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Count    int    `json:"count"`
+	Limit    int    `json:"limit"`
+	Reason   string `json:"reason"`
+}
+
+type analyzeSummary struct {
+	Files         int             `json:"files"`
+	TotalAlerts   int             `json:"totalAlerts"`
+	SeverityCount map[string]int  `json:"severityCount"`
+	RuleCount     map[string]int  `json:"ruleCount"`
+	FailOn        string          `json:"failOn,omitempty"`
+	Violations    []gateViolation `json:"violations"`
+	Errors        []string        `json:"errors"`
+	ExitCode      int             `json:"exitCode"`
+
+	failOnCount int
+}
+
+func newAnalyzeSummary(failOn string) *analyzeSummary {
+	return &analyzeSummary{
+		SeverityCount: make(map[string]int),
+		RuleCount:     make(map[string]int),
+		FailOn:        failOn,
+		Violations:    []gateViolation{},
+		Errors:        []string{},
+	}
+}
+
+// add counts a grouped entry once per line it stands for, so grouping does
+// not change the gates.
+func (s *analyzeSummary) add(entry analyzer.LogEntry) {
+	n := entry.Occurrences()
+	s.TotalAlerts += n
+	s.SeverityCount[entry.Severity] += n
+	for _, rule := range entry.MatchedRules {
+		s.RuleCount[rule] += n
+	}
+	if s.FailOn != "" && analyzer.SeverityLevel(entry.Severity) >= analyzer.SeverityLevel(s.FailOn) {
+		s.failOnCount += n
+	}
+}
+
+// evaluate applies the gates and sets the exit code. I/O errors win over
+// findings because the results are incomplete.
+func (s *analyzeSummary) evaluate(maxAlerts *maxAlertsFlag) int {
+	if s.failOnCount > 0 {
+		s.Violations = append(s.Violations, gateViolation{
+			Severity: s.FailOn,
+			Count:    s.failOnCount,
+			Reason:   "fail-on",
+		})
+	}
+	rules := make([]string, 0, len(s.RuleCount))
+	for rule := range s.RuleCount {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		if budget, ok := maxAlerts.budget(rule); ok && s.RuleCount[rule] > budget {
+			s.Violations = append(s.Violations, gateViolation{
+				Rule:   rule,
+				Count:  s.RuleCount[rule],
+				Limit:  budget,
+				Reason: "max-alerts",
+			})
+		}
+	}
+
+	switch {
+	case len(s.Errors) > 0:
+		s.ExitCode = exitIOError
+	case len(s.Violations) > 0:
+		s.ExitCode = exitFindings
+	default:
+		s.ExitCode = exitOK
+	}
+	return s.ExitCode
+}
+
+// summaryFormats are the values --summary accepts.
+var summaryFormats = []string{"text", "json", "none"}
+
+func isSummaryFormat(format string) bool {
+	for _, known := range summaryFormats {
+		if known == format {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *analyzeSummary) write(w io.Writer, format string) {
+	switch format {
+	case "none":
+	case "json":
+		data, _ := json.Marshal(s)
+		fmt.Fprintln(w, string(data))
+	default:
+		fmt.Fprintf(w, "\nToplam %d uyarı bulundu (%d dosya).\n", s.TotalAlerts, s.Files)
+		severities := make([]string, 0, len(s.SeverityCount))
+		for severity := range s.SeverityCount {
+			severities = append(severities, severity)
+		}
+		sort.Slice(severities, func(i, j int) bool {
+			li, lj := analyzer.SeverityLevel(severities[i]), analyzer.SeverityLevel(severities[j])
+			if li != lj {
+				return li > lj
+			}
+			return severities[i] < severities[j]
+		})
+		for _, severity := range severities {
+			fmt.Fprintf(w, "  %s: %d\n", severity, s.SeverityCount[severity])
+		}
+		for _, v := range s.Violations {
+			if v.Reason == "fail-on" {
+				fmt.Fprintf(w, "✗ %d uyarı '%s' veya üzeri önem derecesinde\n", v.Count, v.Severity)
+			} else {
+				fmt.Fprintf(w, "✗ %s: %d uyarı (sınır %d)\n", v.Rule, v.Count, v.Limit)
+			}
+		}
+		for _, e := range s.Errors {
+			fmt.Fprintf(w, "✗ %s\n", e)
+		}
+	}
+}
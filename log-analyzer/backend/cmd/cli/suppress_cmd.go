@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"log-analyzer/backend/internal/rules"
+)
+
+func runSuppress(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli suppress <list|add|remove|prune> [seçenekler]")
+		return exitConfigError
+	}
+	switch args[0] {
+	case "list":
+		return runSuppressList(args[1:])
+	case "add":
+		return runSuppressAdd(args[1:])
+	case "remove":
+		return runSuppressRemove(args[1:])
+	case "prune":
+		return runSuppressPrune(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Bilinmeyen suppress komutu: %s\n", args[0])
+		return exitConfigError
+	}
+}
+
+func runSuppressList(args []string) int {
+	fs := flag.NewFlagSet("suppress list", flag.ContinueOnError)
+	format := fs.String("format", "text", "çıktı biçimi: text, json")
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+
+	suppressions := ruleManager.Suppressions()
+	if *format == "json" {
+		return writeJSON(suppressions)
+	}
+	if len(suppressions) == 0 {
+		fmt.Println("Tanımlı bastırma kuralı yok.")
+		return exitOK
+	}
+	for _, s := range suppressions {
+		status := "etkin"
+		if s.Expired {
+			status = "süresi doldu"
+		} else if s.ExpiresAt != nil {
+			status = "bitiş " + s.ExpiresAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%s  [%s] %d bastırma\n", s.ID, status, s.Hits)
+		if s.Rule != "" {
+			fmt.Printf("   Kural : %s\n", s.Rule)
+		}
+		if s.Field != "" {
+			fmt.Printf("   Alan  : %s = %s\n", s.Field, s.Value)
+		}
+		if s.Pattern != "" {
+			fmt.Printf("   Desen : %s\n", s.Pattern)
+		}
+		if s.Reason != "" {
+			fmt.Printf("   Neden : %s\n", s.Reason)
+		}
+		fmt.Printf("   Ekleyen: %s, %s\n", s.AddedBy, s.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return exitOK
+}
+
+func runSuppressAdd(args []string) int {
+	fs := flag.NewFlagSet("suppress add", flag.ContinueOnError)
+	var s rules.Suppression
+	fs.StringVar(&s.Rule, "rule", "", "yalnızca bu kuralın eşleşmelerini bastır")
+	fs.StringVar(&s.Field, "field", "", "karşılaştırılacak alan: ip, user, host, program veya başka bir alan")
+	fs.StringVar(&s.Value, "value", "", "alan değeri (ip için CIDR de olabilir)")
+	fs.StringVar(&s.Pattern, "pattern", "", "satırın eşleşmesi gereken düzenli ifade")
+	fs.StringVar(&s.Reason, "reason", "", "bastırma nedeni")
+	fs.StringVar(&s.AddedBy, "by", currentUser(), "ekleyen kişi")
+	expires := fs.String("expires", "", "geçerlilik süresi (örn. 72h) veya bitiş zamanı (2006-01-02[T15:04:05])")
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if *expires != "" {
+		var at time.Time
+		if duration, err := time.ParseDuration(*expires); err == nil {
+			at = time.Now().Add(duration)
+		} else if at, err = parseTimeFlag(*expires); err != nil {
+			fmt.Fprintf(os.Stderr, "Geçersiz seçenek: %v\n", err)
+			return exitConfigError
+		}
+		s.ExpiresAt = &at
+	}
+
+	added, err := ruleManager.AddSuppression(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Bastırma eklenemedi: %v\n", err)
+		return exitConfigError
+	}
+	fmt.Printf("Bastırma eklendi: %s\n", added.ID)
+	return exitOK
+}
+
+func runSuppressRemove(args []string) int {
+	fs := flag.NewFlagSet("suppress remove", flag.ContinueOnError)
+	ruleManager, ids, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "Kullanım: cli suppress remove <id>...")
+		return exitConfigError
+	}
+	code := exitOK
+	for _, id := range ids {
+		if err := ruleManager.RemoveSuppression(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Bastırma silinemedi: %v\n", err)
+			code = exitConfigError
+			continue
+		}
+		fmt.Printf("Bastırma silindi: %s\n", id)
+	}
+	return code
+}
+
+func runSuppressPrune(args []string) int {
+	fs := flag.NewFlagSet("suppress prune", flag.ContinueOnError)
+	ruleManager, _, ok := loadManager(fs, args)
+	if !ok {
+		return exitConfigError
+	}
+	removed, err := ruleManager.RemoveExpiredSuppressions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Bastırmalar kaydedilemedi: %v\n", err)
+		return exitIOError
+	}
+	fmt.Printf("%d süresi dolmuş bastırma silindi.\n", removed)
+	return exitOK
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,314 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/ids"
+	"log-analyzer/backend/internal/parser"
+)
+
+type Status string
+
+const (
+	StatusNew           Status = "new"
+	StatusAcknowledged  Status = "acknowledged"
+	StatusResolved      Status = "resolved"
+	StatusFalsePositive Status = "false_positive"
+)
+
+// transitions lists the allowed status changes. Closed alerts can only be
+// reopened, which puts them back to new.
+var transitions = map[Status][]Status{
+	StatusNew:           {StatusAcknowledged, StatusResolved, StatusFalsePositive},
+	StatusAcknowledged:  {StatusResolved, StatusFalsePositive},
+	StatusResolved:      {StatusNew},
+	StatusFalsePositive: {StatusNew},
+}
+
+func ParseStatus(value string) (Status, error) {
+	status := Status(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("unknown alert status %q", value)
+	}
+	return status, nil
+}
+
+// Open reports whether the alert still needs attention.
+func (s Status) Open() bool {
+	return s == StatusNew || s == StatusAcknowledged
+}
+
+type Comment struct {
+	Time   time.Time `json:"time"`
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+}
+
+// Event is one entry of an alert's audit trail.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Action string    `json:"action"`
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	Note   string    `json:"note,omitempty"`
+}
+
+// Alert is an alert as shown on the dashboard. Alerts from batch analysis
+// carry no ID or lifecycle; those are assigned when a live alert is stored.
+type Alert struct {
+	ID           string            `json:"id,omitempty"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Source       string            `json:"source"`
+	LogFile      string            `json:"logFile"`
+	Line         string            `json:"line"`
+	Summary      string            `json:"summary"`
+	MatchedRules []string          `json:"matchedRules"`
+	Severity     string            `json:"severity"`
+	Host         string            `json:"host,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	Entities     *parser.Entities  `json:"entities,omitempty"`
+	Geo          []geoip.Location  `json:"geo,omitempty"`
+	Count        int               `json:"count"`
+	LastSeen     time.Time         `json:"lastSeen"`
+	GroupKey     string            `json:"groupKey,omitempty"`
+	Status       Status            `json:"status,omitempty"`
+	Assignee     string            `json:"assignee,omitempty"`
+	Comments     []Comment         `json:"comments,omitempty"`
+	History      []Event           `json:"history,omitempty"`
+	UpdatedAt    *time.Time        `json:"updatedAt,omitempty"`
+}
+
+func (a *Alert) copy() Alert {
+	copied := *a
+	copied.Comments = append([]Comment(nil), a.Comments...)
+	copied.History = append([]Event(nil), a.History...)
+	return copied
+}
+
+func (a *Alert) record(actor, action, from, to, note string) {
+	now := time.Now()
+	a.History = append(a.History, Event{Time: now, Actor: actor, Action: action, From: from, To: to, Note: note})
+	a.UpdatedAt = &now
+}
+
+// Store keeps the most recent live alerts in arrival order.
+type Store struct {
+	mu    sync.RWMutex
+	items []*Alert
+	byID  map[string]*Alert
+	limit int
+	dirty bool
+}
+
+func NewStore(limit int) *Store {
+	return &Store{byID: make(map[string]*Alert), limit: limit}
+}
+
+// Add stores a new alert, assigning its ID and initial status.
+func (s *Store) Add(alert Alert) Alert {
+	alert.ID = ids.New()
+	alert.Status = StatusNew
+	alert.Comments, alert.History, alert.UpdatedAt = nil, nil, nil
+	alert.History = []Event{{Time: time.Now(), Actor: "system", Action: "created", To: string(StatusNew)}}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := &alert
+	s.items = append(s.items, stored)
+	s.byID[alert.ID] = stored
+	if len(s.items) > s.limit {
+		for _, dropped := range s.items[:len(s.items)-s.limit] {
+			delete(s.byID, dropped.ID)
+		}
+		s.items = s.items[len(s.items)-s.limit:]
+	}
+	s.dirty = true
+	return stored.copy()
+}
+
+// UpdateGroup counts a repeat on the newest open alert of its group. It
+// fails if there is none, in which case the repeat should be added as a new
+// alert.
+func (s *Store) UpdateGroup(repeat Alert) (Alert, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.items) - 1; i >= 0; i-- {
+		alert := s.items[i]
+		if alert.GroupKey == repeat.GroupKey && alert.Status.Open() {
+			alert.Count = repeat.Count
+			alert.LastSeen = repeat.LastSeen
+			s.dirty = true
+			return alert.copy(), true
+		}
+	}
+	return Alert{}, false
+}
+
+// Filter selects alerts for List. Zero values match everything.
+type Filter struct {
+	Status   Status
+	Assignee string
+	Limit    int
+}
+
+// List returns matching alerts, oldest first, keeping the newest Limit.
+func (s *Store) List(filter Filter) []Alert {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []Alert
+	for _, alert := range s.items {
+		if filter.Status != "" && alert.Status != filter.Status {
+			continue
+		}
+		if filter.Assignee != "" && alert.Assignee != filter.Assignee {
+			continue
+		}
+		result = append(result, alert.copy())
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result
+}
+
+func (s *Store) Get(id string) (Alert, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alert, ok := s.byID[id]
+	if !ok {
+		return Alert{}, false
+	}
+	return alert.copy(), true
+}
+
+// ErrNotFound is returned for unknown alert IDs.
+var ErrNotFound = errors.New("alert not found")
+
+func (s *Store) update(id string, change func(alert *Alert) error) (Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alert, ok := s.byID[id]
+	if !ok {
+		return Alert{}, ErrNotFound
+	}
+	if err := change(alert); err != nil {
+		return Alert{}, err
+	}
+	s.dirty = true
+	return alert.copy(), nil
+}
+
+// SetStatus moves an alert along the workflow; note is kept in the audit
+// trail.
+func (s *Store) SetStatus(id string, status Status, actor, note string) (Alert, error) {
+	return s.update(id, func(alert *Alert) error {
+		allowed := false
+		for _, next := range transitions[alert.Status] {
+			allowed = allowed || next == status
+		}
+		if !allowed {
+			return fmt.Errorf("cannot change alert status from %s to %s", alert.Status, status)
+		}
+		action := "status"
+		if status == StatusNew {
+			action = "reopen"
+		}
+		alert.record(actor, action, string(alert.Status), string(status), note)
+		alert.Status = status
+		return nil
+	})
+}
+
+// Assign sets or, with an empty assignee, clears the alert's owner.
+func (s *Store) Assign(id, assignee, actor string) (Alert, error) {
+	return s.update(id, func(alert *Alert) error {
+		if alert.Assignee == assignee {
+			return nil
+		}
+		alert.record(actor, "assign", alert.Assignee, assignee, "")
+		alert.Assignee = assignee
+		return nil
+	})
+}
+
+func (s *Store) AddComment(id, author, text string) (Alert, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Alert{}, fmt.Errorf("comment is empty")
+	}
+	return s.update(id, func(alert *Alert) error {
+		now := time.Now()
+		alert.Comments = append(alert.Comments, Comment{Time: now, Author: author, Text: text})
+		alert.record(author, "comment", "", "", "")
+		return nil
+	})
+}
+
+// SetFields adds fields found after the alert was stored, such as DNS
+// names, replacing values with the same key.
+func (s *Store) SetFields(id string, fields map[string]string) (Alert, error) {
+	return s.update(id, func(alert *Alert) error {
+		merged := make(map[string]string, len(alert.Fields)+len(fields))
+		for key, value := range alert.Fields {
+			merged[key] = value
+		}
+		for key, value := range fields {
+			merged[key] = value
+		}
+		alert.Fields = merged
+		return nil
+	})
+}
+
+// CountByStatus counts the stored alerts per status.
+func (s *Store) CountByStatus() map[Status]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[Status]int)
+	for _, alert := range s.items {
+		counts[alert.Status]++
+	}
+	return counts
+}
+
+// Marshal encodes the stored alerts if they changed since the last call.
+func (s *Store) Marshal() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil, false, nil
+	}
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode alerts: %w", err)
+	}
+	s.dirty = false
+	return data, true, nil
+}
+
+// Load replaces the stored alerts with previously marshalled ones.
+func (s *Store) Load(data []byte) error {
+	var loaded []*Alert
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse alerts: %w", err)
+	}
+	if len(loaded) > s.limit {
+		loaded = loaded[len(loaded)-s.limit:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = loaded
+	s.byID = make(map[string]*Alert, len(loaded))
+	for _, alert := range loaded {
+		s.byID[alert.ID] = alert
+	}
+	return nil
+}
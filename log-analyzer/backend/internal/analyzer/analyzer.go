@@ -3,28 +3,55 @@ package analyzer
 import (
 	"bufio"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"log-analyzer/backend/internal/intel"
+	"log-analyzer/backend/internal/journal"
+	"log-analyzer/backend/internal/multiline"
 	"log-analyzer/backend/internal/parser"
 	"log-analyzer/backend/internal/rules"
 )
 
 type LogEntry struct {
-	Timestamp    string
-	Source       string
-	LogFile      string
-	Line         string
-	Summary      string
-	MatchedRules []string
-	Severity     string
+	Timestamp    string            `json:"timestamp"`
+	Source       string            `json:"source"`
+	LogFile      string            `json:"logFile"`
+	LineNumber   int               `json:"lineNumber,omitempty"`
+	Line         string            `json:"line"`
+	Summary      string            `json:"summary"`
+	MatchedRules []string          `json:"matchedRules"`
+	Severity     string            `json:"severity"`
+	Host         string            `json:"host,omitempty"`
+	Fields       map[string]string `json:"fields,omitempty"`
+	Entities     *parser.Entities  `json:"entities,omitempty"`
+	Count        int               `json:"count,omitempty"`
+	LastSeen     string            `json:"lastSeen,omitempty"`
+	GroupKey     string            `json:"groupKey,omitempty"`
+
+	firstAt     time.Time
+	lastAt      time.Time
+	groupWindow time.Duration
+}
+
+// Occurrences is the number of matched lines an entry stands for.
+func (e LogEntry) Occurrences() int {
+	if e.Count > 1 {
+		return e.Count
+	}
+	return 1
 }
 
 type Analyzer struct {
 	ruleManager *rules.Manager
+	// ruleSet, when set, is matched instead of the manager's enabled rules.
+	ruleSet []rules.Rule
+	intel   *intel.Matcher
 }
 
 func NewAnalyzer(ruleManager *rules.Manager) *Analyzer {
@@ -33,6 +60,39 @@ func NewAnalyzer(ruleManager *rules.Manager) *Analyzer {
 	}
 }
 
+// SetIntel makes the analyzer report lines containing indicators of the
+// IOC feeds, as the tailer does. Call it before analysing.
+func (a *Analyzer) SetIntel(matcher *intel.Matcher) {
+	a.intel = matcher
+}
+
+// WithRules returns an analyzer that matches only the given rules, enabled or
+// not, while still using the manager's log file settings.
+func (a *Analyzer) WithRules(ruleSet []rules.Rule) (*Analyzer, error) {
+	compiled := make([]rules.Rule, len(ruleSet))
+	copy(compiled, ruleSet)
+	for i := range compiled {
+		if err := compiled[i].Compile(); err != nil {
+			return nil, err
+		}
+	}
+	a.ruleManager.UseGeoIP(compiled)
+	return &Analyzer{ruleManager: a.ruleManager, ruleSet: compiled}, nil
+}
+
+func (a *Analyzer) match(logFile rules.LogFile, entry *parser.Entry) []rules.Rule {
+	if a.ruleSet == nil {
+		return a.ruleManager.MatchBatchEntry(logFile, entry)
+	}
+	var matches []rules.Rule
+	for i := range a.ruleSet {
+		if a.ruleSet[i].AppliesTo(logFile) && a.ruleSet[i].Match(entry) {
+			matches = append(matches, a.ruleSet[i])
+		}
+	}
+	return matches
+}
+
 func (a *Analyzer) AnalyzeFile(filePath string) ([]LogEntry, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -40,51 +100,163 @@ func (a *Analyzer) AnalyzeFile(filePath string) ([]LogEntry, error) {
 	}
 	defer file.Close()
 	
+	logFile, _ := a.ruleManager.GetLogFile(filePath)
+	return a.AnalyzeReader(file, logFile)
+}
+
+// AnalyzeReader analyses a stream such as stdin or a named pipe. The LogFile
+// supplies the source name (Path), log type and format.
+func (a *Analyzer) AnalyzeReader(r io.Reader, logFile rules.LogFile) ([]LogEntry, error) {
 	var entries []LogEntry
-	scanner := bufio.NewScanner(file)
+	var err error
+	if journal.IsFormat(logFile.Format) {
+		entries, err = a.analyzeJournal(r, logFile)
+	} else {
+		entries, err = a.analyzeLines(r, logFile)
+	}
+	return GroupEntries(entries), err
+}
+
+func (a *Analyzer) analyzeLines(r io.Reader, logFile rules.LogFile) ([]LogEntry, error) {
+	assembler := multiline.NewAssembler(logFile.Multiline)
+	var entries []LogEntry
+	var starts []int
+	lineNumber := 0
+	scanner := bufio.NewScanner(r)
 	
 	for scanner.Scan() {
+		lineNumber++
 		line := scanner.Text()
 		if line == "" {
 			continue
 		}
 		
-		matchedRules := a.ruleManager.MatchRules(line)
-		if len(matchedRules) > 0 {
-			var ruleNames []string
-			maxSeverity := "low"
-			
-			for _, rule := range matchedRules {
-				ruleNames = append(ruleNames, rule.Name)
-				if severityLevel(rule.Severity) > severityLevel(maxSeverity) {
-					maxSeverity = rule.Severity
-				}
-			}
-			
-			timestamp := extractTimestamp(line)
-			summary := parser.ParseLogLineToSummary(line)
-			if summary == "" {
-				summary = line
+		if assembler.StartsEvent(line) {
+			starts = append(starts, lineNumber)
+		}
+		for _, event := range assembler.Add(line) {
+			if entry, ok := a.analyzeEntry(logFile, parser.NewEntry(event)); ok {
+				entry.LineNumber = starts[0]
+				entries = append(entries, entry)
 			}
-			entries = append(entries, LogEntry{
-				Timestamp:    timestamp,
-				Source:       filepath.Base(filePath),
-				LogFile:      filePath,
-				Line:         line,
-				Summary:      summary,
-				MatchedRules: ruleNames,
-				Severity:     maxSeverity,
-			})
+			starts = starts[1:]
 		}
 	}
 	
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
+	if event, ok := assembler.Flush(); ok {
+		if entry, ok := a.analyzeEntry(logFile, parser.NewEntry(event)); ok {
+			entry.LineNumber = starts[0]
+			entries = append(entries, entry)
+		}
+	}
+	
+	return entries, nil
+}
+
+func (a *Analyzer) analyzeJournal(r io.Reader, logFile rules.LogFile) ([]LogEntry, error) {
+	reader, err := journal.NewReader(r, logFile.Format)
+	if err != nil {
+		return nil, err
+	}
+	
+	var entries []LogEntry
+	recordNumber := 0
+	for {
+		record, err := reader.Next()
+		recordNumber++
+		if errors.Is(err, journal.ErrInvalidEntry) {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading journal: %w", err)
+		}
+		if entry, ok := a.analyzeEntry(logFile, record); ok {
+			entry.LineNumber = recordNumber
+			entries = append(entries, entry)
+		}
+	}
 	
 	return entries, nil
 }
 
+func (a *Analyzer) analyzeEntry(logFile rules.LogFile, record *parser.Entry) (LogEntry, bool) {
+	filePath := logFile.Path
+	matchedRules := a.match(logFile, record)
+	// Custom rule sets compare rules only, so feeds stay out of them.
+	var hits []intel.Hit
+	if a.ruleSet == nil {
+		hits = a.intel.Match(record)
+		matchedRules = intel.Rules(matchedRules, hits)
+	}
+	if len(matchedRules) == 0 {
+		return LogEntry{}, false
+	}
+	
+	var ruleNames []string
+	maxSeverity := "low"
+	
+	for _, rule := range matchedRules {
+		ruleNames = append(ruleNames, rule.Name)
+		if SeverityLevel(rule.Severity) > SeverityLevel(maxSeverity) {
+			maxSeverity = rule.Severity
+		}
+	}
+	
+	line := record.Line
+	timestamp := extractTimestamp(line)
+	if !record.Timestamp.IsZero() {
+		timestamp = record.Timestamp.Format(time.RFC3339)
+	}
+	summary := record.Message
+	if summary == "" {
+		summary = parser.ParseLogLineToSummary(line)
+	}
+	if summary == "" {
+		summary = line
+	}
+	entry := LogEntry{
+		Timestamp:    timestamp,
+		Source:       filepath.Base(filePath),
+		LogFile:      filePath,
+		Line:         line,
+		Summary:      summary,
+		MatchedRules: ruleNames,
+		Severity:     maxSeverity,
+		Host:         record.Host,
+		Fields:       rules.WithCaptures(matchedRules, record, record.Attributes()),
+		Count:        1,
+	}
+	if len(hits) > 0 {
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]string)
+		}
+		for key, value := range intel.Annotate(hits) {
+			entry.Fields[key] = value
+		}
+	}
+	if entities := record.Entities(); !entities.Empty() {
+		entry.Entities = &entities
+	}
+	// Custom rule sets are used for comparisons, which need every hit.
+	if a.ruleSet == nil {
+		entry.GroupKey, entry.groupWindow = a.ruleManager.DedupKey(matchedRules, record)
+	}
+	if entry.GroupKey != "" {
+		entry.firstAt = record.Timestamp
+		if entry.firstAt.IsZero() {
+			entry.firstAt, _ = ParseTimestamp(line)
+		}
+		entry.lastAt = entry.firstAt
+	}
+	return entry, true
+}
+
 func (a *Analyzer) AnalyzeMultipleFiles(filePaths []string) ([]LogEntry, error) {
 	var allEntries []LogEntry
 	
@@ -96,7 +268,7 @@ func (a *Analyzer) AnalyzeMultipleFiles(filePaths []string) ([]LogEntry, error)
 		allEntries = append(allEntries, entries...)
 	}
 	
-	return allEntries, nil
+	return GroupEntries(allEntries), nil
 }
 
 func (a *Analyzer) ExportToCSV(entries []LogEntry, outputPath string) error {
@@ -106,27 +278,17 @@ func (a *Analyzer) ExportToCSV(entries []LogEntry, outputPath string) error {
 	}
 	defer file.Close()
 	
-	writer := csv.NewWriter(file)
+	return WriteCSV(file, entries)
+}
+
+func WriteCSV(w io.Writer, entries []LogEntry) error {
+	writer := csv.NewWriter(w)
 	defer writer.Flush()
-	header := []string{"Timestamp", "Source", "LogFile", "Severity", "MatchedRules", "Summary", "LogLine"}
-	if err := writer.Write(header); err != nil {
+	if err := writer.Write(CSVHeader()); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 	for _, entry := range entries {
-		summary := entry.Summary
-		if summary == "" {
-			summary = entry.Line
-		}
-		record := []string{
-			entry.Timestamp,
-			entry.Source,
-			entry.LogFile,
-			entry.Severity,
-			strings.Join(entry.MatchedRules, "; "),
-			summary,
-			entry.Line,
-		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(CSVRecord(entry)); err != nil {
 			return fmt.Errorf("failed to write record: %w", err)
 		}
 	}
@@ -134,6 +296,26 @@ func (a *Analyzer) ExportToCSV(entries []LogEntry, outputPath string) error {
 	return nil
 }
 
+func CSVHeader() []string {
+	return []string{"Timestamp", "Source", "LogFile", "Severity", "MatchedRules", "Summary", "LogLine"}
+}
+
+func CSVRecord(entry LogEntry) []string {
+	summary := entry.Summary
+	if summary == "" {
+		summary = entry.Line
+	}
+	return []string{
+		entry.Timestamp,
+		entry.Source,
+		entry.LogFile,
+		entry.Severity,
+		strings.Join(entry.MatchedRules, "; "),
+		summary,
+		entry.Line,
+	}
+}
+
 func extractTimestamp(line string) string {
 	parts := strings.Fields(line)
 	if len(parts) >= 3 {
@@ -147,7 +329,7 @@ func extractTimestamp(line string) string {
 	return time.Now().Format(time.RFC3339)
 }
 
-func severityLevel(severity string) int {
+func SeverityLevel(severity string) int {
 	s := strings.ToLower(severity)
 	switch s {
 	case "critical", "kritik":
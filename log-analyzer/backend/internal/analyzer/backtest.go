@@ -0,0 +1,244 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"log-analyzer/backend/internal/rules"
+)
+
+const (
+	backtestCandidate = "\x00candidate"
+	backtestCurrent   = "\x00current"
+
+	DefaultBacktestSamples = 10
+)
+
+// BacktestOptions limits a backtest to files and an optional time range.
+// A zero Bucket picks a bucket size from the span of the matched entries.
+type BacktestOptions struct {
+	Files   []string
+	From    time.Time
+	To      time.Time
+	Bucket  time.Duration
+	Samples int
+}
+
+type BacktestBucket struct {
+	Start     time.Time `json:"start"`
+	Candidate int       `json:"candidate"`
+	Current   int       `json:"current"`
+}
+
+// BacktestResult compares a candidate rule with the saved rule of the same
+// name. Added and Removed count entries only the candidate or only the
+// current rule matches.
+type BacktestResult struct {
+	Rule           string           `json:"rule"`
+	Files          []string         `json:"files"`
+	Bucket         string           `json:"bucket"`
+	CandidateHits  int              `json:"candidateHits"`
+	CurrentHits    int              `json:"currentHits"`
+	HasCurrent     bool             `json:"hasCurrent"`
+	Added          int              `json:"added"`
+	Removed        int              `json:"removed"`
+	Unchanged      int              `json:"unchanged"`
+	Undated        int              `json:"undated"`
+	Buckets        []BacktestBucket `json:"buckets"`
+	Samples        []LogEntry       `json:"samples"`
+	AddedSamples   []LogEntry       `json:"addedSamples"`
+	RemovedSamples []LogEntry       `json:"removedSamples"`
+	Errors         []string         `json:"errors,omitempty"`
+}
+
+// Backtest runs an unsaved candidate rule over historical logs and reports
+// how often it would have fired, next to the current version of the rule.
+func (a *Analyzer) Backtest(candidate rules.Rule, opts BacktestOptions) (*BacktestResult, error) {
+	if candidate.Pattern == "" {
+		return nil, fmt.Errorf("candidate rule has no pattern")
+	}
+	if opts.Samples <= 0 {
+		opts.Samples = DefaultBacktestSamples
+	}
+
+	result := &BacktestResult{
+		Rule:           candidate.Name,
+		Files:          opts.Files,
+		Buckets:        []BacktestBucket{},
+		Samples:        []LogEntry{},
+		AddedSamples:   []LogEntry{},
+		RemovedSamples: []LogEntry{},
+	}
+	ruleSet := []rules.Rule{candidate}
+	ruleSet[0].Name = backtestCandidate
+	for _, rule := range a.ruleManager.GetRules() {
+		if rule.Name == candidate.Name {
+			rule.Name = backtestCurrent
+			ruleSet = append(ruleSet, rule)
+			result.HasCurrent = true
+			break
+		}
+	}
+	backtester, err := a.WithRules(ruleSet)
+	if err != nil {
+		return nil, err
+	}
+
+	type hit struct {
+		entry     LogEntry
+		at        time.Time
+		candidate bool
+		current   bool
+	}
+	var hits []hit
+	for _, path := range opts.Files {
+		entries, err := backtester.AnalyzeFile(path)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		for _, entry := range entries {
+			h := hit{entry: entry}
+			for _, name := range entry.MatchedRules {
+				h.candidate = h.candidate || name == backtestCandidate
+				h.current = h.current || name == backtestCurrent
+			}
+			var dated bool
+			h.at, dated = ParseTimestamp(entry.Line)
+			if !dated {
+				if !opts.From.IsZero() || !opts.To.IsZero() {
+					continue
+				}
+				result.Undated++
+			} else if (!opts.From.IsZero() && h.at.Before(opts.From)) || (!opts.To.IsZero() && h.at.After(opts.To)) {
+				continue
+			}
+			h.entry.MatchedRules = []string{candidate.Name}
+			hits = append(hits, h)
+		}
+	}
+
+	var first, last time.Time
+	for _, h := range hits {
+		if h.at.IsZero() {
+			continue
+		}
+		if first.IsZero() || h.at.Before(first) {
+			first = h.at
+		}
+		if h.at.After(last) {
+			last = h.at
+		}
+	}
+	bucket := opts.Bucket
+	if bucket <= 0 {
+		bucket = pickBucket(last.Sub(first))
+	}
+	result.Bucket = bucket.String()
+
+	buckets := make(map[int64]*BacktestBucket)
+	for _, h := range hits {
+		if h.candidate {
+			result.CandidateHits++
+		}
+		if h.current {
+			result.CurrentHits++
+		}
+		switch {
+		case h.candidate && h.current:
+			result.Unchanged++
+		case h.candidate:
+			result.Added++
+			if result.HasCurrent && len(result.AddedSamples) < opts.Samples {
+				result.AddedSamples = append(result.AddedSamples, h.entry)
+			}
+		case h.current:
+			result.Removed++
+			if len(result.RemovedSamples) < opts.Samples {
+				result.RemovedSamples = append(result.RemovedSamples, h.entry)
+			}
+		}
+		if h.candidate && len(result.Samples) < opts.Samples {
+			result.Samples = append(result.Samples, h.entry)
+		}
+
+		if h.at.IsZero() {
+			continue
+		}
+		start := h.at.Truncate(bucket)
+		b, ok := buckets[start.Unix()]
+		if !ok {
+			b = &BacktestBucket{Start: start}
+			buckets[start.Unix()] = b
+		}
+		if h.candidate {
+			b.Candidate++
+		}
+		if h.current {
+			b.Current++
+		}
+	}
+	for _, b := range buckets {
+		result.Buckets = append(result.Buckets, *b)
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Start.Before(result.Buckets[j].Start)
+	})
+	return result, nil
+}
+
+// pickBucket aims for at most ~50 buckets over the span.
+func pickBucket(span time.Duration) time.Duration {
+	for _, size := range []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour} {
+		if span/size <= 50 {
+			return size
+		}
+	}
+	return 7 * 24 * time.Hour
+}
+
+var (
+	isoTimestamp    = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})[T ](\d{2}:\d{2}:\d{2})(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	accessTimestamp = regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`)
+	syslogTimestamp = regexp.MustCompile(`^([A-Z][a-z]{2}\s+\d{1,2} \d{2}:\d{2}:\d{2})`)
+)
+
+// ParseTimestamp finds the event time in a log line: ISO 8601 (application
+// and PostgreSQL logs), nginx/apache access logs or BSD syslog, which has no
+// year and is assumed to be within the last year.
+func ParseTimestamp(line string) (time.Time, bool) {
+	if m := syslogTimestamp.FindStringSubmatch(line); m != nil {
+		t, err := time.ParseInLocation(time.Stamp, m[1], time.Local)
+		if err == nil {
+			now := time.Now()
+			t = t.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			return t, true
+		}
+	}
+	if m := accessTimestamp.FindStringSubmatch(line); m != nil {
+		if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1]); err == nil {
+			return t, true
+		}
+	}
+	if m := isoTimestamp.FindStringSubmatch(line); m != nil {
+		value := m[1] + "T" + m[2] + m[3]
+		layout := "2006-01-02T15:04:05.999999999"
+		if zone := m[4]; zone != "" {
+			if zone != "Z" && len(zone) == 5 {
+				zone = zone[:3] + ":" + zone[3:]
+			}
+			if t, err := time.Parse(layout+"Z07:00", value+zone); err == nil {
+				return t, true
+			}
+		}
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
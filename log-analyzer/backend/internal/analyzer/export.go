@@ -0,0 +1,482 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"log-analyzer/backend/internal/export"
+	"log-analyzer/backend/internal/rules"
+)
+
+// Exporter writes analysis results in a report format.
+type Exporter interface {
+	Export(w io.Writer, entries []LogEntry) error
+	ContentType() string
+	Extension() string
+}
+
+var exportFormats = []string{"csv", "json", "ndjson", "sarif", "markdown", "html"}
+
+func ExportFormats() []string {
+	formats := make([]string, len(exportFormats))
+	copy(formats, exportFormats)
+	return formats
+}
+
+// NewExporter returns the exporter for format. The rule set is optional and
+// only used for rule descriptions in SARIF, Markdown and HTML reports.
+func NewExporter(format string, ruleSet []rules.Rule) (Exporter, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return csvExporter{}, nil
+	case "json":
+		return jsonExporter{}, nil
+	case "ndjson", "jsonl":
+		return ndjsonExporter{}, nil
+	case "sarif":
+		return sarifExporter{rules: ruleSet}, nil
+	case "markdown", "md":
+		return markdownExporter{rules: ruleSet}, nil
+	case "html":
+		return htmlExporter{rules: ruleSet}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+type csvExporter struct{}
+
+func (csvExporter) Export(w io.Writer, entries []LogEntry) error {
+	return WriteCSV(w, entries)
+}
+
+func (csvExporter) ContentType() string { return "text/csv; charset=utf-8" }
+func (csvExporter) Extension() string   { return ".csv" }
+
+type jsonExporter struct{}
+
+func (jsonExporter) Export(w io.Writer, entries []LogEntry) error {
+	report := buildReport(entries, nil)
+	if entries == nil {
+		entries = []LogEntry{}
+	}
+	severityCount := make(map[string]int)
+	for _, s := range report.Severities {
+		severityCount[s.Severity] = s.Count
+	}
+	ruleCount := make(map[string]int)
+	for _, r := range report.Rules {
+		ruleCount[r.Name] = r.Count
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{
+		"generatedAt":   report.GeneratedAt,
+		"totalAlerts":   report.Total,
+		"severityCount": severityCount,
+		"ruleCount":     ruleCount,
+		"entries":       entries,
+	})
+}
+
+func (jsonExporter) ContentType() string { return "application/json" }
+func (jsonExporter) Extension() string   { return ".json" }
+
+type ndjsonExporter struct{}
+
+func (ndjsonExporter) Export(w io.Writer, entries []LogEntry) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+	return nil
+}
+
+func (ndjsonExporter) ContentType() string { return "application/x-ndjson" }
+func (ndjsonExporter) Extension() string   { return ".ndjson" }
+
+// severityCount and ruleReport back the Markdown and HTML reports.
+type severityCount struct {
+	Severity string
+	Count    int
+	Percent  float64
+}
+
+type ruleReport struct {
+	Name        string
+	Severity    string
+	Description string
+	Count       int
+	Entries     []LogEntry
+}
+
+type report struct {
+	GeneratedAt time.Time
+	Total       int
+	Severities  []severityCount
+	Rules       []ruleReport
+}
+
+func buildReport(entries []LogEntry, ruleSet []rules.Rule) report {
+	r := report{GeneratedAt: time.Now()}
+
+	ruleInfo := make(map[string]rules.Rule)
+	for _, rule := range ruleSet {
+		ruleInfo[rule.Name] = rule
+	}
+
+	severities := make(map[string]int)
+	byRule := make(map[string]*ruleReport)
+	var ruleOrder []string
+	for _, entry := range entries {
+		r.Total += entry.Occurrences()
+		severities[entry.Severity] += entry.Occurrences()
+		for _, name := range entry.MatchedRules {
+			rr, ok := byRule[name]
+			if !ok {
+				rr = &ruleReport{Name: name, Severity: ruleInfo[name].Severity, Description: ruleInfo[name].Description}
+				byRule[name] = rr
+				ruleOrder = append(ruleOrder, name)
+			}
+			if rr.Severity == "" || (ruleInfo[name].Name == "" && SeverityLevel(entry.Severity) > SeverityLevel(rr.Severity)) {
+				rr.Severity = entry.Severity
+			}
+			rr.Count += entry.Occurrences()
+			rr.Entries = append(rr.Entries, entry)
+		}
+	}
+
+	for severity, count := range severities {
+		sc := severityCount{Severity: severity, Count: count}
+		if r.Total > 0 {
+			sc.Percent = float64(count) * 100 / float64(r.Total)
+		}
+		r.Severities = append(r.Severities, sc)
+	}
+	sort.Slice(r.Severities, func(i, j int) bool {
+		return SeverityLevel(r.Severities[i].Severity) > SeverityLevel(r.Severities[j].Severity)
+	})
+
+	for _, name := range ruleOrder {
+		r.Rules = append(r.Rules, *byRule[name])
+	}
+	sort.SliceStable(r.Rules, func(i, j int) bool {
+		return r.Rules[i].Count > r.Rules[j].Count
+	})
+	return r
+}
+
+type sarifExporter struct {
+	rules []rules.Rule
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfig   `json:"defaultConfiguration"`
+	Properties           map[string]string `json:"properties,omitempty"`
+}
+
+type sarifRuleConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string            `json:"ruleId"`
+	RuleIndex int               `json:"ruleIndex"`
+	Level     string            `json:"level"`
+	Message   sarifMessage      `json:"message"`
+	Locations []sarifLocation   `json:"locations"`
+	Props     map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int          `json:"startLine"`
+	Snippet   sarifMessage `json:"snippet"`
+}
+
+// Export writes one SARIF result per matched rule so each finding shows up
+// under its rule in code-scanning UIs.
+func (e sarifExporter) Export(w io.Writer, entries []LogEntry) error {
+	ruleInfo := make(map[string]rules.Rule)
+	for _, rule := range e.rules {
+		ruleInfo[rule.Name] = rule
+	}
+
+	driver := sarifDriver{Name: "log-analyzer", Rules: []sarifRule{}}
+	ruleIndex := make(map[string]int)
+	results := []sarifResult{}
+	for _, entry := range entries {
+		for _, name := range entry.MatchedRules {
+			idx, ok := ruleIndex[name]
+			if !ok {
+				info := ruleInfo[name]
+				severity := info.Severity
+				if severity == "" {
+					severity = entry.Severity
+				}
+				description := info.Description
+				if description == "" {
+					description = name
+				}
+				idx = len(driver.Rules)
+				ruleIndex[name] = idx
+				driver.Rules = append(driver.Rules, sarifRule{
+					ID:                   sarifRuleID(name),
+					Name:                 name,
+					ShortDescription:     sarifMessage{Text: strings.TrimSpace(description)},
+					DefaultConfiguration: sarifRuleConfig{Level: sarifLevel(severity)},
+					Properties:           map[string]string{"severity": severity, "pattern": info.Pattern},
+				})
+			}
+
+			region := (*sarifRegion)(nil)
+			if entry.LineNumber > 0 {
+				region = &sarifRegion{StartLine: entry.LineNumber, Snippet: sarifMessage{Text: entry.Line}}
+			}
+			message := entry.Summary
+			if message == "" {
+				message = entry.Line
+			}
+			result := sarifResult{
+				RuleID:    driver.Rules[idx].ID,
+				RuleIndex: idx,
+				Level:     driver.Rules[idx].DefaultConfiguration.Level,
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifact{URI: sarifURI(entry.LogFile)},
+						Region:           region,
+					},
+				}},
+			}
+			if entry.Host != "" {
+				result.Props = map[string]string{"host": entry.Host}
+			}
+			results = append(results, result)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+func (sarifExporter) ContentType() string { return "application/sarif+json" }
+func (sarifExporter) Extension() string   { return ".sarif" }
+
+func sarifLevel(severity string) string {
+	switch SeverityLevel(severity) {
+	case 4, 3:
+		return "error"
+	case 2:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func sarifRuleID(name string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash && b.Len() > 0 {
+			b.WriteByte('-')
+			lastDash = true
+		}
+	}
+	id := strings.TrimSuffix(b.String(), "-")
+	if id == "" {
+		id = "rule"
+	}
+	return "LA-" + id
+}
+
+func sarifURI(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return "file://" + path
+	}
+	return path
+}
+
+type markdownExporter struct {
+	rules []rules.Rule
+}
+
+func (e markdownExporter) Export(w io.Writer, entries []LogEntry) error {
+	r := buildReport(entries, e.rules)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Log Analiz Raporu\n\n")
+	fmt.Fprintf(&b, "Oluşturulma: %s  \nToplam uyarı: **%d**\n\n", export.FormatTime(r.GeneratedAt), r.Total)
+
+	b.WriteString("## Önem Derecesi Dağılımı\n\n| Önem | Sayı | Oran |\n|---|---:|---:|\n")
+	for _, s := range r.Severities {
+		fmt.Fprintf(&b, "| %s | %d | %%%.1f |\n", export.MarkdownEscape(s.Severity), s.Count, s.Percent)
+	}
+
+	b.WriteString("\n## Kurallar\n\n| Kural | Önem | Sayı | Açıklama |\n|---|---|---:|---|\n")
+	for _, rule := range r.Rules {
+		fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", export.MarkdownEscape(rule.Name), export.MarkdownEscape(rule.Severity), rule.Count, export.MarkdownEscape(rule.Description))
+	}
+
+	for _, rule := range r.Rules {
+		fmt.Fprintf(&b, "\n### %s (%d)\n\n| Zaman | Kaynak | Satır | Özet |\n|---|---|---:|---|\n", export.MarkdownEscape(rule.Name), rule.Count)
+		for _, entry := range rule.Entries {
+			fmt.Fprintf(&b, "| %s | %s | %d | %s |\n",
+				export.MarkdownEscape(entry.Timestamp), export.MarkdownEscape(entry.Source), entry.LineNumber, export.MarkdownEscape(entry.Summary))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (markdownExporter) ContentType() string { return "text/markdown; charset=utf-8" }
+func (markdownExporter) Extension() string   { return ".md" }
+
+type htmlExporter struct {
+	rules []rules.Rule
+}
+
+func (e htmlExporter) Export(w io.Writer, entries []LogEntry) error {
+	return htmlReport.Execute(w, buildReport(entries, e.rules))
+}
+
+func (htmlExporter) ContentType() string { return "text/html; charset=utf-8" }
+func (htmlExporter) Extension() string   { return ".html" }
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"severityClass": func(severity string) string {
+		switch SeverityLevel(severity) {
+		case 4:
+			return "critical"
+		case 3:
+			return "high"
+		case 2:
+			return "medium"
+		default:
+			return "low"
+		}
+	},
+	"percent": func(p float64) string { return fmt.Sprintf("%.1f", p) },
+	"date":    export.FormatTime,
+}).Parse(`<!DOCTYPE html>
+<html lang="tr">
+<head>
+<meta charset="utf-8">
+<title>Log Analiz Raporu</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem; color: #1f2933; background: #f5f7fa; }
+h1 { margin-bottom: .25rem; }
+section { background: #fff; border-radius: 8px; padding: 1rem 1.5rem; margin: 1rem 0; box-shadow: 0 1px 3px rgba(0,0,0,.1); }
+table { border-collapse: collapse; width: 100%; font-size: .9rem; }
+th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #e4e7eb; vertical-align: top; }
+th { background: #f0f4f8; }
+td.num { text-align: right; }
+.bar { height: .8rem; border-radius: 4px; min-width: 2px; }
+.badge { display: inline-block; padding: .1rem .5rem; border-radius: 10px; color: #fff; font-size: .8rem; }
+.critical { background: #c81e1e; } .high { background: #e3790c; } .medium { background: #d4a300; } .low { background: #3f83f8; }
+pre { white-space: pre-wrap; word-break: break-all; margin: 0; font-size: .8rem; }
+.muted { color: #7b8794; }
+</style>
+</head>
+<body>
+<h1>Log Analiz Raporu</h1>
+<p class="muted">Oluşturulma: {{date .GeneratedAt}} · Toplam uyarı: <strong>{{.Total}}</strong></p>
+
+<section>
+<h2>Önem Derecesi Dağılımı</h2>
+<table>
+<tr><th>Önem</th><th>Sayı</th><th>Oran</th><th style="width:50%"></th></tr>
+{{range .Severities}}<tr>
+<td><span class="badge {{severityClass .Severity}}">{{.Severity}}</span></td>
+<td class="num">{{.Count}}</td>
+<td class="num">%{{percent .Percent}}</td>
+<td><div class="bar {{severityClass .Severity}}" style="width: {{percent .Percent}}%"></div></td>
+</tr>{{end}}
+</table>
+</section>
+
+<section>
+<h2>Kurallar</h2>
+<table>
+<tr><th>Kural</th><th>Önem</th><th>Sayı</th><th>Açıklama</th></tr>
+{{range .Rules}}<tr>
+<td><a href="#{{.Name}}">{{.Name}}</a></td>
+<td><span class="badge {{severityClass .Severity}}">{{.Severity}}</span></td>
+<td class="num">{{.Count}}</td>
+<td>{{.Description}}</td>
+</tr>{{end}}
+</table>
+</section>
+
+{{range .Rules}}<section id="{{.Name}}">
+<h2>{{.Name}} <span class="muted">({{.Count}})</span></h2>
+<table>
+<tr><th>Zaman</th><th>Kaynak</th><th>Satır</th><th>Log</th></tr>
+{{range .Entries}}<tr>
+<td>{{.Timestamp}}</td>
+<td>{{.Source}}{{if .Host}}<br><span class="muted">{{.Host}}</span>{{end}}</td>
+<td class="num">{{if .LineNumber}}{{.LineNumber}}{{end}}</td>
+<td><pre>{{.Line}}</pre></td>
+</tr>{{end}}
+</table>
+</section>
+{{end}}
+</body>
+</html>
+`))
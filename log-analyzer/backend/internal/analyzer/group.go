@@ -0,0 +1,43 @@
+package analyzer
+
+// GroupEntries folds entries that share a group key into the first entry of
+// their group, adding up Count and moving LastSeen forward. An entry joins a
+// group if it is within the group's window of it; entries without a
+// timestamp always join. Entries from earlier grouping can be grouped again.
+func GroupEntries(entries []LogEntry) []LogEntry {
+	var grouped []LogEntry
+	open := make(map[string]int)
+	for _, entry := range entries {
+		if entry.GroupKey == "" {
+			grouped = append(grouped, entry)
+			continue
+		}
+		if i, ok := open[entry.GroupKey]; ok && overlaps(grouped[i], entry) {
+			group := &grouped[i]
+			group.Count += entry.Occurrences()
+			if entry.firstAt.Before(group.firstAt) {
+				group.firstAt = entry.firstAt
+			}
+			if !entry.lastAt.Before(group.lastAt) {
+				group.lastAt = entry.lastAt
+				group.LastSeen = entry.LastSeen
+				if group.LastSeen == "" {
+					group.LastSeen = entry.Timestamp
+				}
+			}
+			continue
+		}
+		entry.Count = entry.Occurrences()
+		open[entry.GroupKey] = len(grouped)
+		grouped = append(grouped, entry)
+	}
+	return grouped
+}
+
+func overlaps(group, entry LogEntry) bool {
+	if group.lastAt.IsZero() || entry.firstAt.IsZero() {
+		return true
+	}
+	window := group.groupWindow
+	return entry.firstAt.Sub(group.lastAt) <= window && group.firstAt.Sub(entry.lastAt) <= window
+}
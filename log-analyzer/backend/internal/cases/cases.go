@@ -0,0 +1,390 @@
+package cases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/ids"
+)
+
+type Status string
+
+const (
+	StatusOpen          Status = "open"
+	StatusInvestigating Status = "investigating"
+	StatusClosed        Status = "closed"
+)
+
+func ParseStatus(value string) (Status, error) {
+	switch status := Status(strings.ToLower(strings.TrimSpace(value))); status {
+	case StatusOpen, StatusInvestigating, StatusClosed:
+		return status, nil
+	}
+	return "", fmt.Errorf("unknown case status %q", value)
+}
+
+// TimelineEntry is one event of a case: an alert joining it, a note or a
+// change made by an analyst.
+type TimelineEntry struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Actor   string    `json:"actor,omitempty"`
+	AlertID string    `json:"alertId,omitempty"`
+	Text    string    `json:"text,omitempty"`
+}
+
+// Case groups related alerts. Severity follows the most severe alert until
+// an analyst sets it.
+type Case struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Severity    string          `json:"severity"`
+	Status      Status          `json:"status"`
+	Auto        bool            `json:"auto"`
+	Entities    []string        `json:"entities"`
+	AlertIDs    []string        `json:"alertIds"`
+	Timeline    []TimelineEntry `json:"timeline"`
+	FirstSeen   time.Time       `json:"firstSeen"`
+	LastSeen    time.Time       `json:"lastSeen"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	SeveritySet bool            `json:"severitySet,omitempty"`
+}
+
+func (c *Case) copy() Case {
+	copied := *c
+	copied.Entities = append([]string(nil), c.Entities...)
+	copied.AlertIDs = append([]string(nil), c.AlertIDs...)
+	copied.Timeline = append([]TimelineEntry(nil), c.Timeline...)
+	return copied
+}
+
+func (c *Case) hasAlert(id string) bool {
+	for _, existing := range c.AlertIDs {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Case) hasEntity(entity string) bool {
+	for _, existing := range c.Entities {
+		if existing == entity {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Case) log(entry TimelineEntry) {
+	c.Timeline = append(c.Timeline, entry)
+	sort.SliceStable(c.Timeline, func(i, j int) bool { return c.Timeline[i].Time.Before(c.Timeline[j].Time) })
+	c.UpdatedAt = time.Now()
+}
+
+// addAlert attaches an alert and its entities, raising the severity unless
+// an analyst has set it.
+func (c *Case) addAlert(alert alerts.Alert, entities []string, actor string) bool {
+	if c.hasAlert(alert.ID) {
+		return false
+	}
+	c.AlertIDs = append(c.AlertIDs, alert.ID)
+	for _, entity := range entities {
+		if !c.hasEntity(entity) {
+			c.Entities = append(c.Entities, entity)
+		}
+	}
+	if c.FirstSeen.IsZero() || alert.Timestamp.Before(c.FirstSeen) {
+		c.FirstSeen = alert.Timestamp
+	}
+	if alert.Timestamp.After(c.LastSeen) {
+		c.LastSeen = alert.Timestamp
+	}
+	if !c.SeveritySet && analyzer.SeverityLevel(alert.Severity) > analyzer.SeverityLevel(c.Severity) {
+		c.Severity = alert.Severity
+	}
+	text := alert.Summary
+	if len(alert.MatchedRules) > 0 {
+		text = strings.Join(alert.MatchedRules, ", ") + ": " + text
+	}
+	c.log(TimelineEntry{Time: alert.Timestamp, Kind: "alert", Actor: actor, AlertID: alert.ID, Text: text})
+	return true
+}
+
+// Member is an alert with the correlation entities found in it.
+type Member struct {
+	Alert    alerts.Alert
+	Entities []string
+}
+
+// Store keeps cases and correlates incoming alerts into them.
+type Store struct {
+	mu     sync.RWMutex
+	items  []*Case
+	byID   map[string]*Case
+	recent map[string]Member
+	limit  int
+	dirty  bool
+}
+
+func NewStore(limit int) *Store {
+	return &Store{byID: make(map[string]*Case), recent: make(map[string]Member), limit: limit}
+}
+
+// ErrNotFound is returned for unknown case IDs.
+var ErrNotFound = errors.New("case not found")
+
+func (s *Store) insert(c *Case) {
+	s.items = append(s.items, c)
+	s.byID[c.ID] = c
+	if len(s.items) > s.limit {
+		for _, dropped := range s.items[:len(s.items)-s.limit] {
+			delete(s.byID, dropped.ID)
+		}
+		s.items = s.items[len(s.items)-s.limit:]
+	}
+	s.dirty = true
+}
+
+func newCase(title, severity, actor string, auto bool) *Case {
+	now := time.Now()
+	c := &Case{
+		ID:        ids.New(),
+		Title:     title,
+		Severity:  severity,
+		Status:    StatusOpen,
+		Auto:      auto,
+		CreatedAt: now,
+	}
+	c.SeveritySet = severity != ""
+	c.log(TimelineEntry{Time: now, Kind: "created", Actor: actor})
+	return c
+}
+
+// Create opens a case by hand from the given alerts.
+func (s *Store) Create(title, severity, actor, note string, members []Member) Case {
+	if strings.TrimSpace(title) == "" {
+		title = "Yeni vaka"
+	}
+	c := newCase(title, severity, actor, false)
+	for _, member := range members {
+		c.addAlert(member.Alert, member.Entities, actor)
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		c.log(TimelineEntry{Time: time.Now(), Kind: "note", Actor: actor, Text: note})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insert(c)
+	return c.copy()
+}
+
+// Correlate files a new alert under the open case that shares one of its
+// entities and was active within window. Without such a case, an earlier
+// alert on a shared entity within window starts a new case with both. It
+// reports whether the alert joined a case.
+func (s *Store) Correlate(member Member, window time.Duration) (Case, bool) {
+	alert, entities := member.Alert, member.Entities
+	if window <= 0 || len(entities) == 0 {
+		return Case{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.items) - 1; i >= 0; i-- {
+		c := s.items[i]
+		if c.Status == StatusClosed || alert.Timestamp.Sub(c.LastSeen) > window {
+			continue
+		}
+		for _, entity := range entities {
+			if c.hasEntity(entity) {
+				c.addAlert(alert, entities, "system")
+				s.dirty = true
+				return c.copy(), true
+			}
+		}
+	}
+
+	s.pruneRecent(alert.Timestamp, window)
+	for _, entity := range entities {
+		previous, ok := s.recent[entity]
+		if !ok || previous.Alert.ID == alert.ID || alert.Timestamp.Sub(previous.Alert.Timestamp) > window {
+			continue
+		}
+		c := newCase(caseTitle(entity), "", "system", true)
+		c.addAlert(previous.Alert, previous.Entities, "system")
+		c.addAlert(alert, entities, "system")
+		for _, e := range previous.Entities {
+			delete(s.recent, e)
+		}
+		s.insert(c)
+		return c.copy(), true
+	}
+
+	for _, entity := range entities {
+		s.recent[entity] = member
+	}
+	return Case{}, false
+}
+
+func caseTitle(entity string) string {
+	field, value, _ := strings.Cut(entity, ":")
+	return fmt.Sprintf("%s %s ile ilişkili uyarılar", field, value)
+}
+
+// pruneRecent forgets uncorrelated alerts older than window once the map
+// grows large.
+func (s *Store) pruneRecent(now time.Time, window time.Duration) {
+	if len(s.recent) < 10000 {
+		return
+	}
+	for entity, previous := range s.recent {
+		if now.Sub(previous.Alert.Timestamp) > window {
+			delete(s.recent, entity)
+		}
+	}
+}
+
+// Filter selects cases for List. Zero values match everything.
+type Filter struct {
+	Status Status
+	Limit  int
+}
+
+// List returns matching cases, newest first.
+func (s *Store) List(filter Filter) []Case {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []Case{}
+	for i := len(s.items) - 1; i >= 0; i-- {
+		if filter.Status != "" && s.items[i].Status != filter.Status {
+			continue
+		}
+		result = append(result, s.items[i].copy())
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+func (s *Store) Get(id string) (Case, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.byID[id]
+	if !ok {
+		return Case{}, false
+	}
+	return c.copy(), true
+}
+
+func (s *Store) update(id string, change func(c *Case) error) (Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.byID[id]
+	if !ok {
+		return Case{}, ErrNotFound
+	}
+	if err := change(c); err != nil {
+		return Case{}, err
+	}
+	s.dirty = true
+	return c.copy(), nil
+}
+
+// AddAlerts attaches alerts to a case by hand.
+func (s *Store) AddAlerts(id string, members []Member, actor string) (Case, error) {
+	return s.update(id, func(c *Case) error {
+		for _, member := range members {
+			c.addAlert(member.Alert, member.Entities, actor)
+		}
+		return nil
+	})
+}
+
+func (s *Store) SetStatus(id string, status Status, actor, note string) (Case, error) {
+	return s.update(id, func(c *Case) error {
+		if c.Status == status {
+			return nil
+		}
+		text := string(c.Status) + " → " + string(status)
+		if note = strings.TrimSpace(note); note != "" {
+			text += ": " + note
+		}
+		c.Status = status
+		c.log(TimelineEntry{Time: time.Now(), Kind: "status", Actor: actor, Text: text})
+		return nil
+	})
+}
+
+// SetSeverity overrides the severity; it no longer follows the alerts.
+func (s *Store) SetSeverity(id, severity, actor string) (Case, error) {
+	if analyzer.SeverityLevel(severity) == 0 {
+		return Case{}, fmt.Errorf("unknown severity %q", severity)
+	}
+	return s.update(id, func(c *Case) error {
+		c.SeveritySet = true
+		if c.Severity == severity {
+			return nil
+		}
+		text := c.Severity + " → " + severity
+		c.Severity = severity
+		c.log(TimelineEntry{Time: time.Now(), Kind: "severity", Actor: actor, Text: text})
+		return nil
+	})
+}
+
+func (s *Store) AddNote(id, author, text string) (Case, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Case{}, fmt.Errorf("note is empty")
+	}
+	return s.update(id, func(c *Case) error {
+		c.log(TimelineEntry{Time: time.Now(), Kind: "note", Actor: author, Text: text})
+		return nil
+	})
+}
+
+// Marshal encodes the cases if they changed since the last call.
+func (s *Store) Marshal() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil, false, nil
+	}
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode cases: %w", err)
+	}
+	s.dirty = false
+	return data, true, nil
+}
+
+// Load replaces the cases with previously marshalled ones.
+func (s *Store) Load(data []byte) error {
+	var loaded []*Case
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse cases: %w", err)
+	}
+	if len(loaded) > s.limit {
+		loaded = loaded[len(loaded)-s.limit:]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = loaded
+	s.byID = make(map[string]*Case, len(loaded))
+	for _, c := range loaded {
+		s.byID[c.ID] = c
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cases
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/export"
+)
+
+var timelineLabels = map[string]string{
+	"created":  "Vaka açıldı",
+	"alert":    "Uyarı",
+	"note":     "Not",
+	"status":   "Durum",
+	"severity": "Önem",
+}
+
+// WriteMarkdown writes a report of the case with its timeline and the
+// alerts that are still stored.
+func WriteMarkdown(w io.Writer, c Case, members []alerts.Alert) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Vaka: %s\n\n", export.MarkdownEscape(c.Title))
+	fmt.Fprintf(&b, "Kimlik: `%s`  \nDurum: **%s**  \nÖnem: **%s**  \n", c.ID, c.Status, export.MarkdownEscape(c.Severity))
+	fmt.Fprintf(&b, "İlk görülme: %s  \nSon görülme: %s  \nUyarı sayısı: %d\n", export.FormatTime(c.FirstSeen), export.FormatTime(c.LastSeen), len(c.AlertIDs))
+	if len(c.Entities) > 0 {
+		fmt.Fprintf(&b, "\n## Varlıklar\n\n")
+		for _, entity := range c.Entities {
+			fmt.Fprintf(&b, "- `%s`\n", entity)
+		}
+	}
+
+	b.WriteString("\n## Zaman Çizelgesi\n\n| Zaman | Olay | Kişi | Ayrıntı |\n|---|---|---|---|\n")
+	for _, entry := range c.Timeline {
+		label := timelineLabels[entry.Kind]
+		if label == "" {
+			label = entry.Kind
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", export.FormatTime(entry.Time), label, export.MarkdownEscape(entry.Actor), export.MarkdownEscape(entry.Text))
+	}
+
+	if len(members) > 0 {
+		b.WriteString("\n## Uyarılar\n\n| Zaman | Önem | Kurallar | Kaynak | Satır |\n|---|---|---|---|---|\n")
+		for _, alert := range members {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", export.FormatTime(alert.Timestamp), export.MarkdownEscape(alert.Severity),
+				export.MarkdownEscape(strings.Join(alert.MatchedRules, ", ")), export.MarkdownEscape(alert.Source), export.MarkdownEscape(alert.Line))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,80 @@
+package dedup
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	leadingTimestamp = regexp.MustCompile(`^(?:[A-Z][a-z]{2}\s+\d{1,2} \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\s*`)
+	accessTimestamp  = regexp.MustCompile(`\[\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\]`)
+	ipv4             = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`)
+	ipv6             = regexp.MustCompile(`\b[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{0,4}){2,7}\b`)
+	uuid             = regexp.MustCompile(`\b[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\b`)
+	hexNumber        = regexp.MustCompile(`\b(?:0x)?[0-9A-Fa-f]*\d[0-9A-Fa-f]*\b`)
+	spaces           = regexp.MustCompile(`\s+`)
+)
+
+// Normalize masks the parts of a message that differ between repeats of the
+// same event: timestamps, addresses, IDs and numbers.
+func Normalize(message string) string {
+	message = leadingTimestamp.ReplaceAllString(message, "")
+	message = accessTimestamp.ReplaceAllString(message, "[<time>]")
+	message = uuid.ReplaceAllString(message, "<id>")
+	message = ipv4.ReplaceAllString(message, "<ip>")
+	message = ipv6.ReplaceAllString(message, "<ip>")
+	message = hexNumber.ReplaceAllString(message, "<n>")
+	return strings.TrimSpace(spaces.ReplaceAllString(message, " "))
+}
+
+type group struct {
+	first  time.Time
+	last   time.Time
+	count  int
+	window time.Duration
+}
+
+// pruneEvery is how many observations pass between sweeps of closed groups.
+const pruneEvery = 1024
+
+// Grouper counts occurrences of keys. An occurrence within the window of
+// the previous one joins its group; a later one starts a new group.
+type Grouper struct {
+	mu     sync.Mutex
+	groups map[string]*group
+	seen   int
+}
+
+func NewGrouper() *Grouper {
+	return &Grouper{groups: make(map[string]*group)}
+}
+
+// Observe records an occurrence of key at time at and returns the size of
+// its group and when the group started. A count of 1 is a new group.
+func (g *Grouper) Observe(key string, window time.Duration, at time.Time) (count int, first time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.seen++
+	if g.seen%pruneEvery == 0 {
+		for k, existing := range g.groups {
+			if at.Sub(existing.last) > existing.window {
+				delete(g.groups, k)
+			}
+		}
+	}
+
+	existing, ok := g.groups[key]
+	if !ok || at.Sub(existing.last) > window {
+		existing = &group{first: at}
+		g.groups[key] = existing
+	}
+	existing.count++
+	existing.window = window
+	if at.After(existing.last) {
+		existing.last = at
+	}
+	return existing.count, existing.first
+}
@@ -0,0 +1,27 @@
+// Package export holds the formatting shared by the alert and case exports.
+package export
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeLayout is how times are written in reports.
+const TimeLayout = "2006-01-02 15:04:05"
+
+// FormatTime formats t with TimeLayout.
+func FormatTime(t time.Time) string {
+	return t.Format(TimeLayout)
+}
+
+// MarkdownEscape makes s safe to put in a Markdown table cell. Markdown
+// renderers pass HTML through, so log lines must not bring their own tags.
+func MarkdownEscape(s string) string {
+	s = markdownHTML.Replace(s)
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return strings.TrimSpace(s)
+}
+
+var markdownHTML = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
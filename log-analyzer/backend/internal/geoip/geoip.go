@@ -0,0 +1,151 @@
+// Package geoip looks up the location and network owner of IP addresses in
+// MaxMind-format (MMDB) databases such as GeoLite2 City, Country and ASN.
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Location is what the databases know about an address. Fields the
+// databases lack are left empty.
+type Location struct {
+	IP          string  `json:"ip"`
+	Country     string  `json:"country,omitempty"`
+	CountryName string  `json:"countryName,omitempty"`
+	Continent   string  `json:"continent,omitempty"`
+	City        string  `json:"city,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	ASN         uint64  `json:"asn,omitempty"`
+	ASOrg       string  `json:"asOrg,omitempty"`
+}
+
+// Fields are the names Field accepts.
+var Fields = []string{"country", "country_name", "continent", "city", "asn", "as_org"}
+
+// Field returns a location attribute by name, "" when unknown or missing.
+func (l Location) Field(name string) string {
+	switch name {
+	case "country":
+		return l.Country
+	case "country_name":
+		return l.CountryName
+	case "continent":
+		return l.Continent
+	case "city":
+		return l.City
+	case "asn":
+		if l.ASN == 0 {
+			return ""
+		}
+		return strconv.FormatUint(l.ASN, 10)
+	case "as_org":
+		return l.ASOrg
+	}
+	return ""
+}
+
+// IsField reports whether name is a location attribute.
+func IsField(name string) bool {
+	for _, field := range Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DB combines several databases, for example a City and an ASN database;
+// each address is looked up in all of them.
+type DB struct {
+	readers []*reader
+}
+
+// Open reads the given MMDB files into memory.
+func Open(paths ...string) (*DB, error) {
+	db := &DB{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r, err := newReader(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		db.readers = append(db.readers, r)
+	}
+	return db, nil
+}
+
+// Lookup returns the location of ip. Private, loopback and other
+// non-routable addresses are not looked up.
+func (db *DB) Lookup(ip string) (Location, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if db == nil || err != nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap().WithZone("")
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return Location{}, false
+	}
+	location := Location{IP: addr.String()}
+	found := false
+	for _, r := range db.readers {
+		value, err := r.lookup(addr)
+		if err != nil || value == nil {
+			continue
+		}
+		if record, ok := value.(map[string]interface{}); ok {
+			location.merge(record)
+			found = true
+		}
+	}
+	return location, found
+}
+
+// merge fills the fields still empty from a City, Country or ASN record.
+func (l *Location) merge(record map[string]interface{}) {
+	country := lookupPath(record, "country")
+	if country == nil {
+		country = lookupPath(record, "registered_country")
+	}
+	if l.Country == "" {
+		l.Country, _ = lookupPath(country, "iso_code").(string)
+	}
+	if l.CountryName == "" {
+		l.CountryName, _ = lookupPath(country, "names", "en").(string)
+	}
+	if l.Continent == "" {
+		l.Continent, _ = lookupPath(record, "continent", "code").(string)
+	}
+	if l.City == "" {
+		l.City, _ = lookupPath(record, "city", "names", "en").(string)
+	}
+	if l.Latitude == 0 && l.Longitude == 0 {
+		l.Latitude, _ = lookupPath(record, "location", "latitude").(float64)
+		l.Longitude, _ = lookupPath(record, "location", "longitude").(float64)
+	}
+	if l.ASN == 0 {
+		l.ASN = toUint(record["autonomous_system_number"])
+	}
+	if l.ASOrg == "" {
+		l.ASOrg, _ = record["autonomous_system_organization"].(string)
+	}
+	l.Country = strings.ToUpper(l.Country)
+}
+
+func lookupPath(value interface{}, path ...string) interface{} {
+	for _, key := range path {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = fields[key]
+	}
+	return value
+}
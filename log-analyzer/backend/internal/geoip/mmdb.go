@@ -0,0 +1,326 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// metadataMarker starts the metadata section at the end of an MMDB file.
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSeparator is the run of zero bytes between the search tree and the
+// data section.
+const dataSeparator = 16
+
+// metadata is the part of an MMDB file's metadata the reader uses.
+type metadata struct {
+	DatabaseType string
+	IPVersion    int
+	NodeCount    int
+	RecordSize   int
+}
+
+// reader looks up addresses in a MaxMind DB file held in memory. See
+// https://maxmind.github.io/MaxMind-DB/ for the format.
+type reader struct {
+	buf      []byte
+	tree     []byte
+	data     []byte
+	metadata metadata
+	// ipv4Start is the node reached after the 96 zero bits that prefix
+	// IPv4 addresses in an IPv6 tree.
+	ipv4Start int
+}
+
+func newReader(buf []byte) (*reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("not a MaxMind DB file")
+	}
+	meta := &decoder{data: buf[start+len(metadataMarker):]}
+	value, _, err := meta.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid metadata")
+	}
+	r := &reader{buf: buf}
+	r.metadata.DatabaseType, _ = fields["database_type"].(string)
+	r.metadata.IPVersion = int(toUint(fields["ip_version"]))
+	r.metadata.NodeCount = int(toUint(fields["node_count"]))
+	r.metadata.RecordSize = int(toUint(fields["record_size"]))
+
+	switch r.metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("unsupported record size %d", r.metadata.RecordSize)
+	}
+	treeSize := r.metadata.NodeCount * r.metadata.RecordSize / 4
+	if r.metadata.NodeCount <= 0 || treeSize+dataSeparator > start {
+		return nil, errors.New("invalid search tree size")
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+dataSeparator : start]
+
+	if r.metadata.IPVersion == 6 {
+		node := 0
+		for i := 0; i < 96 && node < r.metadata.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node.
+func (r *reader) record(node, bit int) int {
+	switch r.metadata.RecordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return int(b[3]&0xF0)<<20 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+		}
+		return int(b[3]&0x0F)<<24 | int(b[4])<<16 | int(b[5])<<8 | int(b[6])
+	default:
+		return int(binary.BigEndian.Uint32(r.tree[node*8+bit*4:]))
+	}
+}
+
+// lookup returns the decoded record of addr, or nil when the database has
+// none.
+func (r *reader) lookup(addr netip.Addr) (interface{}, error) {
+	addr = addr.Unmap()
+	node := 0
+	if addr.Is4() {
+		if r.metadata.IPVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if r.metadata.IPVersion == 4 {
+		return nil, nil
+	}
+	bytes := addr.AsSlice()
+	for i := 0; i < len(bytes)*8 && node < r.metadata.NodeCount; i++ {
+		node = r.record(node, int(bytes[i/8]>>(7-i%8)&1))
+	}
+	if node <= r.metadata.NodeCount {
+		return nil, nil
+	}
+	offset := node - r.metadata.NodeCount - dataSeparator
+	if offset < 0 || offset >= len(r.data) {
+		return nil, errors.New("invalid data pointer in search tree")
+	}
+	value, _, err := (&decoder{data: r.data}).decode(offset, 0)
+	return value, err
+}
+
+// Data section types.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth bounds the nesting of maps, arrays and pointers so a corrupt
+// file cannot recurse forever.
+const maxDepth = 32
+
+type decoder struct {
+	data []byte
+}
+
+var errTruncated = errors.New("truncated data section")
+
+// decode reads the value at offset and returns it with the offset after it.
+func (d *decoder) decode(offset, depth int) (interface{}, int, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.New("data section nested too deeply")
+	}
+	if offset >= len(d.data) {
+		return nil, 0, errTruncated
+	}
+	ctrl := d.data[offset]
+	offset++
+	typ := int(ctrl >> 5)
+	if typ == typePointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+	if typ == typeExtended {
+		if offset >= len(d.data) {
+			return nil, 0, errTruncated
+		}
+		typ = 7 + int(d.data[offset])
+		offset++
+	}
+	size := int(ctrl & 0x1F)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > len(d.data) {
+			return nil, 0, errTruncated
+		}
+		extra := 0
+		for _, b := range d.data[offset : offset+n] {
+			extra = extra<<8 | int(b)
+		}
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + extra
+		case 2:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+
+	// Every map entry takes at least two bytes and every array element one,
+	// so a size the rest of the data cannot hold is corrupt. Checking it
+	// first keeps a bad size from allocating gigabytes.
+	remaining := len(d.data) - offset
+	switch typ {
+	case typeMap:
+		if size > remaining/2 {
+			return nil, 0, errTruncated
+		}
+		result := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+			value, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			result[name] = value
+			offset = next
+		}
+		return result, offset, nil
+	case typeArray:
+		if size > remaining {
+			return nil, 0, errTruncated
+		}
+		result := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+			offset = next
+		}
+		return result, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, offset, nil
+	}
+
+	if offset+size > len(d.data) {
+		return nil, 0, errTruncated
+	}
+	raw := d.data[offset : offset+size]
+	offset += size
+	switch typ {
+	case typeString:
+		return string(raw), offset, nil
+	case typeBytes:
+		return append([]byte(nil), raw...), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), offset, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		var value uint64
+		for _, b := range raw {
+			value = value<<8 | uint64(b)
+		}
+		return value, offset, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+		var value uint32
+		for _, b := range raw {
+			value = value<<8 | uint32(b)
+		}
+		return int64(int32(value)), offset, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(raw), offset, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type %d", typ)
+}
+
+// pointer decodes a pointer whose control byte is ctrl; pointers are
+// offsets from the start of the data section.
+func (d *decoder) pointer(ctrl byte, offset int) (int, int, error) {
+	n := int(ctrl>>3&3) + 1
+	if offset+n > len(d.data) {
+		return 0, 0, errTruncated
+	}
+	b := d.data[offset : offset+n]
+	var pointer int
+	switch n {
+	case 1:
+		pointer = int(ctrl&7)<<8 | int(b[0])
+	case 2:
+		pointer = (int(ctrl&7)<<16 | int(b[0])<<8 | int(b[1])) + 2048
+	case 3:
+		pointer = (int(ctrl&7)<<24 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])) + 526336
+	default:
+		pointer = int(binary.BigEndian.Uint32(b))
+	}
+	return pointer, offset + n, nil
+}
+
+func toUint(value interface{}) uint64 {
+	switch v := value.(type) {
+	case uint64:
+		return v
+	case int64:
+		if v > 0 {
+			return uint64(v)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,352 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the testdata databases")
+
+// mmdbWriter builds small MaxMind DB files for the tests. Values are nil,
+// string, uint64, float64, map[string]interface{} or pointer, which refers
+// to a value written earlier.
+type mmdbWriter struct {
+	data bytes.Buffer
+}
+
+type pointer int
+
+func (w *mmdbWriter) ctrl(typ, size int) {
+	var extra []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		extra = []byte{byte(size - 29)}
+		size = 29
+	case size < 65821:
+		extra = []byte{byte((size - 285) >> 8), byte(size - 285)}
+		size = 30
+	default:
+		n := size - 65821
+		extra = []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+		size = 31
+	}
+	if typ > 7 {
+		w.data.Write([]byte{byte(size), byte(typ - 7)})
+	} else {
+		w.data.WriteByte(byte(typ<<5 | size))
+	}
+	w.data.Write(extra)
+}
+
+// write appends value and returns its offset in the data section.
+func (w *mmdbWriter) write(value interface{}) pointer {
+	offset := pointer(w.data.Len())
+	switch v := value.(type) {
+	case string:
+		w.ctrl(typeString, len(v))
+		w.data.WriteString(v)
+	case uint64:
+		var raw []byte
+		for ; v > 0; v >>= 8 {
+			raw = append([]byte{byte(v)}, raw...)
+		}
+		w.ctrl(typeUint32, len(raw))
+		w.data.Write(raw)
+	case float64:
+		w.ctrl(typeDouble, 8)
+		binary.Write(&w.data, binary.BigEndian, math.Float64bits(v))
+	case []interface{}:
+		w.ctrl(typeArray, len(v))
+		for _, item := range v {
+			w.write(item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.ctrl(typeMap, len(v))
+		for _, key := range keys {
+			w.write(key)
+			w.write(v[key])
+		}
+	case pointer:
+		switch {
+		case v < 2048:
+			w.data.Write([]byte{byte(typePointer<<5 | int(v)>>8), byte(v)})
+		case v < 526336:
+			p := int(v) - 2048
+			w.data.Write([]byte{byte(typePointer<<5 | 1<<3 | p>>16), byte(p >> 8), byte(p)})
+		default:
+			panic("pointer too large for the test writer")
+		}
+	default:
+		panic("unsupported value")
+	}
+	return offset
+}
+
+type network struct {
+	prefix string
+	record pointer
+}
+
+type treeNode struct {
+	// child holds for each bit a node index, or -1 for no data, or
+	// -2-offset for a record at offset in the data section.
+	child [2]int
+}
+
+// build returns the file for the networks, whose records were written to
+// w before. IPv4 networks in an IPv6 tree go below ::/96, as in the
+// MaxMind databases.
+func (w *mmdbWriter) build(t *testing.T, recordSize, ipVersion int, networks []network) []byte {
+	t.Helper()
+	nodes := []treeNode{{child: [2]int{-1, -1}}}
+	for _, n := range networks {
+		prefix := netip.MustParsePrefix(n.prefix)
+		addr, bits := prefix.Addr(), prefix.Bits()
+		if addr.Is4() && ipVersion == 6 {
+			var raw [16]byte
+			v4 := addr.As4()
+			copy(raw[12:], v4[:])
+			addr, bits = netip.AddrFrom16(raw), bits+96
+		}
+		raw := addr.AsSlice()
+		node := 0
+		for i := 0; i < bits; i++ {
+			bit := int(raw[i/8] >> (7 - i%8) & 1)
+			if i == bits-1 {
+				nodes[node].child[bit] = -2 - int(n.record)
+				break
+			}
+			if nodes[node].child[bit] < 0 {
+				nodes = append(nodes, treeNode{child: [2]int{-1, -1}})
+				nodes[node].child[bit] = len(nodes) - 1
+			}
+			node = nodes[node].child[bit]
+		}
+	}
+
+	count := len(nodes)
+	var file bytes.Buffer
+	for _, node := range nodes {
+		var records [2]uint32
+		for bit, child := range node.child {
+			switch {
+			case child == -1:
+				records[bit] = uint32(count)
+			case child < -1:
+				records[bit] = uint32(count + dataSeparator + (-2 - child))
+			default:
+				records[bit] = uint32(child)
+			}
+		}
+		left, right := records[0], records[1]
+		switch recordSize {
+		case 24:
+			file.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 28:
+			file.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(left>>20&0xF0 | right>>24&0x0F), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 32:
+			binary.Write(&file, binary.BigEndian, [2]uint32{left, right})
+		}
+	}
+	file.Write(make([]byte, dataSeparator))
+	file.Write(w.data.Bytes())
+	file.Write(metadataMarker)
+	meta := &mmdbWriter{}
+	meta.write(map[string]interface{}{
+		"database_type": "Test-City",
+		"ip_version":    uint64(ipVersion),
+		"node_count":    uint64(count),
+		"record_size":   uint64(recordSize),
+	})
+	file.Write(meta.data.Bytes())
+	return file.Bytes()
+}
+
+// testDB writes a City-like database: two networks share the country of
+// their records through pointers, and the IPv6 tree also has an IPv6
+// network.
+func testDB(t *testing.T, recordSize, ipVersion int) []byte {
+	w := &mmdbWriter{}
+	turkey := w.write(map[string]interface{}{
+		"iso_code": "tr",
+		"names":    map[string]interface{}{"en": "Turkey"},
+	})
+	istanbul := w.write(map[string]interface{}{
+		"city":      map[string]interface{}{"names": map[string]interface{}{"en": "Istanbul"}},
+		"continent": map[string]interface{}{"code": "EU"},
+		"country":   turkey,
+		"location":  map[string]interface{}{"latitude": 41.01, "longitude": 28.97},
+	})
+	ankara := w.write(map[string]interface{}{
+		"city":                     map[string]interface{}{"names": map[string]interface{}{"en": "Ankara"}},
+		"registered_country":       turkey,
+		"autonomous_system_number": uint64(64500),
+	})
+	networks := []network{
+		{prefix: "203.0.113.0/24", record: istanbul},
+		{prefix: "198.51.100.128/25", record: ankara},
+	}
+	if ipVersion == 6 {
+		v6 := w.write(map[string]interface{}{
+			"country":                        turkey,
+			"autonomous_system_organization": "Example Net",
+		})
+		networks = append(networks, network{prefix: "2001:db8::/32", record: v6})
+	}
+	return w.build(t, recordSize, ipVersion, networks)
+}
+
+func TestLookup(t *testing.T) {
+	istanbul := Location{Country: "TR", CountryName: "Turkey", Continent: "EU", City: "Istanbul", Latitude: 41.01, Longitude: 28.97}
+	ankara := Location{Country: "TR", CountryName: "Turkey", City: "Ankara", ASN: 64500}
+	v6 := Location{Country: "TR", CountryName: "Turkey", ASOrg: "Example Net"}
+
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, ipVersion := range []int{4, 6} {
+			r, err := newReader(testDB(t, recordSize, ipVersion))
+			if err != nil {
+				t.Fatalf("record size %d, IPv%d: %v", recordSize, ipVersion, err)
+			}
+			db := &DB{readers: []*reader{r}}
+			tests := []struct {
+				ip    string
+				want  Location
+				found bool
+			}{
+				{ip: "203.0.113.9", want: istanbul, found: true},
+				{ip: "::ffff:203.0.113.9", want: istanbul, found: true},
+				{ip: "198.51.100.200", want: ankara, found: true},
+				{ip: "198.51.100.20"},
+				{ip: "192.0.2.1"},
+				{ip: "10.0.0.1"},
+				{ip: "2001:db8::1", want: v6, found: ipVersion == 6},
+				{ip: "2001:db9::1"},
+			}
+			for _, tt := range tests {
+				got, found := db.Lookup(tt.ip)
+				if found != tt.found {
+					t.Errorf("record size %d, IPv%d, %s: found = %v", recordSize, ipVersion, tt.ip, found)
+					continue
+				}
+				if !found {
+					continue
+				}
+				tt.want.IP = netip.MustParseAddr(tt.ip).Unmap().String()
+				if got != tt.want {
+					t.Errorf("record size %d, IPv%d, %s:\n got %+v\nwant %+v", recordSize, ipVersion, tt.ip, got, tt.want)
+				}
+			}
+		}
+	}
+}
+
+// TestOpenFixture reads the database in testdata, which go test -update
+// writes again.
+func TestOpenFixture(t *testing.T) {
+	path := filepath.Join("testdata", "test-city.mmdb")
+	if *update {
+		if err := os.WriteFile(path, testDB(t, 28, 6), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, ok := db.Lookup("203.0.113.9")
+	if !ok || location.City != "Istanbul" || location.Field("country") != "TR" {
+		t.Errorf("Lookup = %+v, %v", location, ok)
+	}
+	if _, err := Open(filepath.Join("testdata", "missing.mmdb")); err == nil {
+		t.Error("opening a missing file succeeded")
+	}
+}
+
+func TestLongPointers(t *testing.T) {
+	w := &mmdbWriter{}
+	w.write(strings.Repeat("x", 3000))
+	name := w.write("far away")
+	record := w.write(map[string]interface{}{"autonomous_system_organization": name})
+	r, err := newReader(w.build(t, 24, 4, []network{{prefix: "203.0.113.0/24", record: record}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	location, ok := (&DB{readers: []*reader{r}}).Lookup("203.0.113.1")
+	if !ok || location.ASOrg != "far away" {
+		t.Errorf("Lookup = %+v, %v", location, ok)
+	}
+}
+
+func TestDecodeCorrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "huge map", data: []byte{typeMap<<5 | 31, 0xFF, 0xFF, 0xFF}},
+		{name: "map larger than the data", data: []byte{typeMap<<5 | 3, typeString<<5 | 1, 'a'}},
+		{name: "huge array", data: []byte{31, typeArray - 7, 0xFF, 0xFF, 0xFF}},
+		{name: "string past the end", data: []byte{typeString<<5 | 10, 'a', 'b'}},
+		{name: "size bytes missing", data: []byte{typeString<<5 | 30, 0x01}},
+		{name: "pointer bytes missing", data: []byte{typePointer<<5 | 1<<3, 0x00}},
+		{name: "pointer past the end", data: []byte{typePointer<<5 | 1, 0x00}},
+		{name: "pointer to itself", data: []byte{typePointer << 5, 0x00}},
+		{name: "extended type missing", data: []byte{0x01}},
+		{name: "non-string key", data: []byte{typeMap<<5 | 1, typeDouble<<5 | 8, 0, 0, 0, 0, 0, 0, 0, 0, typeString << 5}},
+		{name: "bad double", data: []byte{typeDouble<<5 | 4, 0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if value, _, err := (&decoder{data: tt.data}).decode(0, 0); err == nil {
+			t.Errorf("%s: decoded %v", tt.name, value)
+		}
+	}
+
+	_, _, err := (&decoder{data: []byte{typeMap<<5 | 31, 0xFF, 0xFF, 0xFF}}).decode(0, 0)
+	if !errors.Is(err, errTruncated) {
+		t.Errorf("huge map: error = %v, want %v", err, errTruncated)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	file := testDB(t, 24, 6)
+	marker := bytes.LastIndex(file, metadataMarker)
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "no metadata", data: file[:marker]},
+		{name: "metadata cut", data: file[:len(file)-3]},
+		{name: "tree cut", data: append(append([]byte(nil), file[:20]...), file[marker:]...)},
+	} {
+		if _, err := newReader(tt.data); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+
+	// A tree pointing past the data section is reported at lookup.
+	w := &mmdbWriter{}
+	record := w.write(map[string]interface{}{"autonomous_system_organization": "x"})
+	broken := w.build(t, 32, 4, []network{{prefix: "203.0.113.0/24", record: record + 1000}})
+	r, err := newReader(broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.lookup(netip.MustParseAddr("203.0.113.1")); err == nil {
+		t.Error("lookup through a bad data pointer succeeded")
+	}
+}
@@ -0,0 +1,21 @@
+// Package ids generates the identifiers of stored alerts, cases, action log
+// entries and other records.
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// New returns a random 16 character hex ID. Should the system random source
+// fail, the ID is derived from the clock instead, which is still unique
+// within one process.
+func New() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,347 @@
+package intel
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/rules"
+)
+
+// Indicator types.
+const (
+	TypeIP        = "ip"
+	TypeDomain    = "domain"
+	TypeHash      = "hash"
+	TypeURL       = "url"
+	TypeUserAgent = "user_agent"
+)
+
+// Indicator is one known-bad value of a feed. IP indicators may be CIDR
+// ranges and domain indicators also cover their subdomains.
+type Indicator struct {
+	Type        string `json:"type"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+var (
+	hashValue   = regexp.MustCompile(`^(?:[0-9a-f]{32}|[0-9a-f]{40}|[0-9a-f]{64}|[0-9a-f]{128})$`)
+	domainValue = regexp.MustCompile(`^(?:[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{0,62}$`)
+)
+
+var typeAliases = map[string]string{
+	"ip": TypeIP, "ipv4": TypeIP, "ipv6": TypeIP, "ip-src": TypeIP, "ip-dst": TypeIP,
+	"ipv4-addr": TypeIP, "ipv6-addr": TypeIP, "cidr": TypeIP, "ip-address": TypeIP,
+	"domain": TypeDomain, "hostname": TypeDomain, "domain-name": TypeDomain, "fqdn": TypeDomain,
+	"hash": TypeHash, "md5": TypeHash, "sha1": TypeHash, "sha256": TypeHash, "sha512": TypeHash, "file-hash": TypeHash,
+	"url": TypeURL, "uri": TypeURL, "link": TypeURL,
+	"user_agent": TypeUserAgent, "user-agent": TypeUserAgent, "useragent": TypeUserAgent, "ua": TypeUserAgent,
+}
+
+// normalizeType maps the type names used by feeds onto ours; unknown names
+// give "".
+func normalizeType(name string) string {
+	return typeAliases[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// detectType guesses the type of a value from its shape.
+func detectType(value string) string {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.Contains(lower, "://"):
+		return TypeURL
+	case isIP(value):
+		return TypeIP
+	case hashValue.MatchString(lower):
+		return TypeHash
+	case domainValue.MatchString(strings.TrimPrefix(strings.TrimSuffix(lower, "."), "*.")):
+		return TypeDomain
+	case strings.ContainsAny(value, " /"):
+		return TypeUserAgent
+	}
+	return ""
+}
+
+func isIP(value string) bool {
+	if _, err := netip.ParseAddr(value); err == nil {
+		return true
+	}
+	_, err := netip.ParsePrefix(value)
+	return err == nil
+}
+
+// normalize checks a value against its type and brings it into the form
+// the index compares: lower case, masked prefixes, no wildcard labels.
+func normalize(typ, value string) (Indicator, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Indicator{}, false
+	}
+	switch typ {
+	case TypeIP:
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return Indicator{Type: typ, Value: addr.Unmap().String()}, true
+		}
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			return Indicator{Type: typ, Value: prefix.Masked().String()}, true
+		}
+		return Indicator{}, false
+	case TypeDomain:
+		value = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(value), "."), "*.")
+		if !domainValue.MatchString(value) {
+			return Indicator{}, false
+		}
+		return Indicator{Type: typ, Value: value}, true
+	case TypeHash:
+		value = strings.ToLower(value)
+		if !hashValue.MatchString(value) {
+			return Indicator{}, false
+		}
+		return Indicator{Type: typ, Value: value}, true
+	case TypeURL, TypeUserAgent:
+		return Indicator{Type: typ, Value: strings.ToLower(value)}, true
+	}
+	return Indicator{}, false
+}
+
+// Parse reads the indicators of a feed. Values that do not fit their type
+// are skipped rather than failing the whole feed.
+func Parse(feed rules.IntelFeed, r io.Reader) ([]Indicator, error) {
+	switch feed.Format {
+	case "plain":
+		return parsePlain(feed, r)
+	case "csv":
+		return parseCSV(feed, r)
+	case "stix":
+		return parseSTIX(r, time.Now())
+	case "misp":
+		return parseMISP(r)
+	default:
+		return nil, fmt.Errorf("unsupported feed format %q", feed.Format)
+	}
+}
+
+func feedType(feed rules.IntelFeed, value string) string {
+	if typ := normalizeType(feed.Type); typ != "" {
+		return typ
+	}
+	return detectType(value)
+}
+
+// parsePlain reads one indicator per line. Blank lines and lines starting
+// with # or ; are skipped, and so is anything after " #". Except in user
+// agent feeds only the first word of a line counts.
+func parsePlain(feed rules.IntelFeed, r io.Reader) ([]Indicator, error) {
+	var result []Indicator
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if normalizeType(feed.Type) != TypeUserAgent {
+			line = strings.Fields(line)[0]
+		}
+		if indicator, ok := normalize(feedType(feed, line), line); ok {
+			result = append(result, indicator)
+		}
+	}
+	return result, scanner.Err()
+}
+
+// parseCSV reads the indicator from Column (or the first column) and its
+// type from TypeColumn. With named columns the first row is the header;
+// otherwise a first row without a valid indicator is taken as one.
+func parseCSV(feed rules.IntelFeed, r io.Reader) ([]Indicator, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.LazyQuotes = true
+	if feed.Delimiter != "" {
+		reader.Comma = rune(feed.Delimiter[0])
+	}
+
+	valueColumn, typeColumn := 0, -1
+	first := true
+	var result []Indicator
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		if first {
+			first = false
+			if feed.Column != "" || feed.TypeColumn != "" {
+				valueColumn, typeColumn = -1, -1
+				for i, name := range record {
+					switch strings.TrimSpace(name) {
+					case feed.Column:
+						valueColumn = i
+					case feed.TypeColumn:
+						typeColumn = i
+					}
+				}
+				if feed.Column == "" {
+					valueColumn = 0
+				}
+				if valueColumn < 0 || (feed.TypeColumn != "" && typeColumn < 0) {
+					return nil, fmt.Errorf("csv header has no column %q or %q", feed.Column, feed.TypeColumn)
+				}
+				continue
+			}
+			if len(record) > 0 && detectType(strings.TrimSpace(record[0])) == "" {
+				continue
+			}
+		}
+		if valueColumn >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[valueColumn])
+		typ := feedType(feed, value)
+		if typeColumn >= 0 && typeColumn < len(record) {
+			typ = normalizeType(record[typeColumn])
+		}
+		if indicator, ok := normalize(typ, value); ok {
+			result = append(result, indicator)
+		}
+	}
+}
+
+// stixComparison matches the equality comparisons of a STIX pattern such
+// as [ipv4-addr:value = '198.51.100.1'] or [file:hashes.'SHA-256' = '...'].
+var stixComparison = regexp.MustCompile(`([a-z0-9-]+):([A-Za-z0-9_.'-]+)\s*=\s*'((?:[^'\\]|\\.)*)'`)
+
+type stixObject struct {
+	Type       string     `json:"type"`
+	Name       string     `json:"name"`
+	Pattern    string     `json:"pattern"`
+	Revoked    bool       `json:"revoked"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// parseSTIX reads the indicator objects of a STIX 2.1 bundle. Revoked and
+// expired indicators are skipped.
+func parseSTIX(r io.Reader, now time.Time) ([]Indicator, error) {
+	var bundle struct {
+		Type    string       `json:"type"`
+		Objects []stixObject `json:"objects"`
+	}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("invalid STIX bundle: %w", err)
+	}
+	if bundle.Type != "bundle" {
+		return nil, fmt.Errorf("not a STIX bundle")
+	}
+	var result []Indicator
+	for _, object := range bundle.Objects {
+		if object.Type != "indicator" || object.Revoked || (object.ValidUntil != nil && object.ValidUntil.Before(now)) {
+			continue
+		}
+		for _, m := range stixComparison.FindAllStringSubmatch(object.Pattern, -1) {
+			value := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(m[3])
+			if indicator, ok := normalize(stixType(m[1], m[2]), value); ok {
+				indicator.Description = object.Name
+				result = append(result, indicator)
+			}
+		}
+	}
+	return result, nil
+}
+
+func stixType(object, path string) string {
+	switch {
+	case object == "ipv4-addr" || object == "ipv6-addr":
+		return TypeIP
+	case object == "domain-name":
+		return TypeDomain
+	case object == "url":
+		return TypeURL
+	case object == "file" && strings.HasPrefix(path, "hashes."):
+		return TypeHash
+	case strings.Contains(strings.ToLower(path), "user-agent"):
+		return TypeUserAgent
+	}
+	return ""
+}
+
+// parseMISP reads the attributes of a MISP JSON export: a single event, a
+// list of events or a restSearch response. As in MISP, only attributes
+// flagged for IDS are used.
+func parseMISP(r io.Reader) ([]Indicator, error) {
+	var data interface{}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid MISP export: %w", err)
+	}
+	var result []Indicator
+	collectMISP(data, "", &result)
+	return result, nil
+}
+
+func collectMISP(data interface{}, info string, result *[]Indicator) {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			collectMISP(item, info, result)
+		}
+	case map[string]interface{}:
+		if text, ok := v["info"].(string); ok {
+			info = text
+		}
+		for _, key := range []string{"response", "Event", "Object"} {
+			if nested, ok := v[key]; ok {
+				collectMISP(nested, info, result)
+			}
+		}
+		if attributes, ok := v["Attribute"].([]interface{}); ok {
+			for _, attribute := range attributes {
+				if fields, ok := attribute.(map[string]interface{}); ok {
+					*result = append(*result, mispAttribute(fields, info)...)
+				}
+			}
+		}
+	}
+}
+
+// mispAttribute converts one attribute; composite types such as
+// "ip-dst|port" or "filename|sha256" yield their indicator parts.
+func mispAttribute(fields map[string]interface{}, info string) []Indicator {
+	if toIDS, ok := fields["to_ids"].(bool); ok && !toIDS {
+		return nil
+	}
+	if deleted, ok := fields["deleted"].(bool); ok && deleted {
+		return nil
+	}
+	typ, _ := fields["type"].(string)
+	value, _ := fields["value"].(string)
+	description, _ := fields["comment"].(string)
+	if description == "" {
+		description = info
+	}
+	types := strings.Split(typ, "|")
+	values := strings.Split(value, "|")
+	var result []Indicator
+	for i, part := range types {
+		if i >= len(values) {
+			break
+		}
+		if indicator, ok := normalize(normalizeType(part), values[i]); ok {
+			indicator.Description = description
+			result = append(result, indicator)
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package intel
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// entry is an indicator as stored in the index, with the feed it came from.
+type entry struct {
+	Indicator
+	feed     string
+	severity string
+}
+
+// prefixNode is a binary trie over address bits. Every IP indicator is a
+// prefix; a single address is a full-length prefix.
+type prefixNode struct {
+	children [2]*prefixNode
+	entries  []*entry
+}
+
+func (n *prefixNode) insert(prefix netip.Prefix, e *entry) {
+	addr := prefix.Addr().AsSlice()
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := addr[i/8] >> (7 - i%8) & 1
+		if n.children[bit] == nil {
+			n.children[bit] = &prefixNode{}
+		}
+		n = n.children[bit]
+	}
+	n.entries = append(n.entries, e)
+}
+
+// lookup returns the entries of every prefix containing addr.
+func (n *prefixNode) lookup(addr netip.Addr) []*entry {
+	bytes := addr.AsSlice()
+	found := append([]*entry(nil), n.entries...)
+	for i := 0; i < len(bytes)*8 && n != nil; i++ {
+		n = n.children[bytes[i/8]>>(7-i%8)&1]
+		if n != nil {
+			found = append(found, n.entries...)
+		}
+	}
+	return found
+}
+
+// domainNode is a trie over domain labels from the top-level domain down,
+// so an indicator also covers every subdomain.
+type domainNode struct {
+	children map[string]*domainNode
+	entries  []*entry
+}
+
+func (n *domainNode) insert(domain string, e *entry) {
+	labels := strings.Split(domain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		if n.children == nil {
+			n.children = make(map[string]*domainNode)
+		}
+		child, ok := n.children[labels[i]]
+		if !ok {
+			child = &domainNode{}
+			n.children[labels[i]] = child
+		}
+		n = child
+	}
+	n.entries = append(n.entries, e)
+}
+
+func (n *domainNode) lookup(domain string) []*entry {
+	labels := strings.Split(domain, ".")
+	var found []*entry
+	for i := len(labels) - 1; i >= 0 && n != nil; i-- {
+		n = n.children[labels[i]]
+		if n != nil {
+			found = append(found, n.entries...)
+		}
+	}
+	return found
+}
+
+// index answers indicator lookups: IPs and ranges through bit tries,
+// domains through a label trie and everything else through hash sets.
+type index struct {
+	v4, v6  prefixNode
+	domains domainNode
+	exact   map[string][]*entry
+	counts  map[string]int
+}
+
+func newIndex() *index {
+	return &index{exact: make(map[string][]*entry), counts: make(map[string]int)}
+}
+
+func exactKey(typ, value string) string {
+	return typ + "\x00" + value
+}
+
+func (ix *index) add(feed, severity string, indicators []Indicator) {
+	for _, indicator := range indicators {
+		e := &entry{Indicator: indicator, feed: feed, severity: severity}
+		switch indicator.Type {
+		case TypeIP:
+			prefix, err := netip.ParsePrefix(indicator.Value)
+			if err != nil {
+				addr, err := netip.ParseAddr(indicator.Value)
+				if err != nil {
+					continue
+				}
+				prefix = netip.PrefixFrom(addr, addr.BitLen())
+			}
+			if prefix.Addr().Is4() {
+				ix.v4.insert(prefix, e)
+			} else {
+				ix.v6.insert(prefix, e)
+			}
+		case TypeDomain:
+			// A bare top-level domain would flag every name under it.
+			if !strings.Contains(indicator.Value, ".") {
+				continue
+			}
+			ix.domains.insert(indicator.Value, e)
+		default:
+			key := exactKey(indicator.Type, indicator.Value)
+			ix.exact[key] = append(ix.exact[key], e)
+		}
+		ix.counts[indicator.Type]++
+	}
+}
+
+func (ix *index) has(typ string) bool {
+	return ix.counts[typ] > 0
+}
+
+func (ix *index) lookupIP(value string) []*entry {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return ix.v4.lookup(addr)
+	}
+	return ix.v6.lookup(addr.WithZone(""))
+}
+
+func (ix *index) lookupDomain(value string) []*entry {
+	return ix.domains.lookup(strings.TrimSuffix(strings.ToLower(value), "."))
+}
+
+func (ix *index) lookupExact(typ, value string) []*entry {
+	return ix.exact[exactKey(typ, strings.ToLower(value))]
+}
@@ -0,0 +1,345 @@
+package intel
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+const maxHits = 20
+
+var lineQuoted = regexp.MustCompile(`"([^"]+)"`)
+
+// Hit is an indicator found in a log entry.
+type Hit struct {
+	Feed        string `json:"feed"`
+	Severity    string `json:"severity"`
+	Type        string `json:"type"`
+	Indicator   string `json:"indicator"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// FeedStatus reports the last load of a feed.
+type FeedStatus struct {
+	Name       string         `json:"name"`
+	Path       string         `json:"path"`
+	Format     string         `json:"format"`
+	Indicators int            `json:"indicators"`
+	Types      map[string]int `json:"types,omitempty"`
+	LoadedAt   *time.Time     `json:"loadedAt,omitempty"`
+	ModTime    *time.Time     `json:"modTime,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type feedState struct {
+	config     rules.IntelFeed
+	indicators []Indicator
+	size       int64
+	status     FeedStatus
+}
+
+// Matcher checks log entries against the IOC feeds. Feeds are reloaded
+// every refresh interval when their file changed; a feed that fails to load
+// keeps its previous indicators.
+type Matcher struct {
+	mu      sync.RWMutex
+	feeds   []*feedState
+	index   *index
+	loadMu  sync.Mutex
+	refresh time.Duration
+	stop    chan struct{}
+}
+
+func NewMatcher(refresh time.Duration, feeds []rules.IntelFeed) *Matcher {
+	m := &Matcher{index: newIndex(), refresh: refresh, stop: make(chan struct{})}
+	for _, feed := range feeds {
+		m.feeds = append(m.feeds, &feedState{
+			config: feed,
+			status: FeedStatus{Name: feed.Name, Path: feed.Path, Format: feed.Format},
+		})
+	}
+	m.Reload(true)
+	if len(m.feeds) > 0 && refresh > 0 {
+		go m.run()
+	}
+	return m
+}
+
+func (m *Matcher) run() {
+	ticker := time.NewTicker(m.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.Reload(false)
+		}
+	}
+}
+
+// Reload reads the feeds whose file changed, or all of them when forced,
+// and rebuilds the index if any changed.
+func (m *Matcher) Reload(force bool) []FeedStatus {
+	m.loadMu.Lock()
+	defer m.loadMu.Unlock()
+
+	changed := false
+	for _, feed := range m.feeds {
+		if m.load(feed, force) {
+			changed = true
+		}
+	}
+	if changed {
+		ix := newIndex()
+		for _, feed := range m.feeds {
+			severity := feed.config.Severity
+			if severity == "" {
+				severity = "high"
+			}
+			ix.add(feed.config.Name, severity, feed.indicators)
+		}
+		m.mu.Lock()
+		m.index = ix
+		m.mu.Unlock()
+	}
+	return m.Statuses()
+}
+
+// load reads one feed if needed and reports whether its indicators changed.
+func (m *Matcher) load(feed *feedState, force bool) bool {
+	info, err := os.Stat(feed.config.Path)
+	if err == nil && !force && feed.status.ModTime != nil &&
+		info.ModTime().Equal(*feed.status.ModTime) && info.Size() == feed.size {
+		return false
+	}
+	var indicators []Indicator
+	if err == nil {
+		var file *os.File
+		if file, err = os.Open(feed.config.Path); err == nil {
+			indicators, err = Parse(feed.config, file)
+			file.Close()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		message := fmt.Sprintf("failed to load feed: %v", err)
+		if feed.status.Error != message {
+			log.Printf("Threat intel feed %s: %v", feed.config.Name, err)
+		}
+		feed.status.Error = message
+		return false
+	}
+	now, modTime := time.Now(), info.ModTime()
+	feed.indicators = indicators
+	feed.size = info.Size()
+	feed.status.Error = ""
+	feed.status.LoadedAt = &now
+	feed.status.ModTime = &modTime
+	feed.status.Indicators = len(indicators)
+	feed.status.Types = make(map[string]int)
+	for _, indicator := range indicators {
+		feed.status.Types[indicator.Type]++
+	}
+	return true
+}
+
+func (m *Matcher) Statuses() []FeedStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]FeedStatus, 0, len(m.feeds))
+	for _, feed := range m.feeds {
+		result = append(result, feed.status)
+	}
+	return result
+}
+
+// Close stops the periodic reloads.
+func (m *Matcher) Close() {
+	close(m.stop)
+}
+
+// hits collects the matches of one value, once per feed and indicator.
+type hits struct {
+	list []Hit
+	seen map[string]bool
+}
+
+func (h *hits) add(found []*entry, value string) {
+	for _, e := range found {
+		key := e.feed + "\x00" + e.Type + "\x00" + e.Value
+		if h.seen[key] || len(h.list) >= maxHits {
+			continue
+		}
+		if h.seen == nil {
+			h.seen = make(map[string]bool)
+		}
+		h.seen[key] = true
+		h.list = append(h.list, Hit{
+			Feed:        e.feed,
+			Severity:    e.severity,
+			Type:        e.Type,
+			Indicator:   e.Value,
+			Value:       value,
+			Description: e.Description,
+		})
+	}
+}
+
+// Match checks the line and the fields of entry for known indicators:
+// addresses, domains, URLs and hashes found in the text, quoted strings and
+// whole field values as user agents.
+func (m *Matcher) Match(entry *parser.Entry) []Hit {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	ix := m.index
+	m.mu.RUnlock()
+
+	var found hits
+	texts := []string{entry.Line}
+	if entry.Message != "" && !strings.Contains(entry.Line, entry.Message) {
+		texts = append(texts, entry.Message)
+	}
+	for _, value := range entry.Fields {
+		texts = append(texts, value)
+	}
+	for i, text := range texts {
+		matchText(ix, text, &found)
+		if i > 0 {
+			matchValue(ix, text, &found)
+		}
+	}
+	return found.list
+}
+
+func matchText(ix *index, text string, found *hits) {
+	entities := parser.ExtractEntities(text)
+	if ix.has(TypeIP) {
+		for _, ip := range entities.IPs {
+			found.add(ix.lookupIP(ip), ip)
+		}
+	}
+	if ix.has(TypeURL) {
+		for _, url := range entities.URLs {
+			found.add(ix.lookupExact(TypeURL, url), url)
+		}
+	}
+	if ix.has(TypeDomain) {
+		for _, domain := range entities.Domains {
+			found.add(ix.lookupDomain(domain), domain)
+		}
+	}
+	if ix.has(TypeHash) {
+		for _, hash := range entities.Hashes {
+			found.add(ix.lookupExact(TypeHash, hash), hash)
+		}
+	}
+	if ix.has(TypeUserAgent) {
+		for _, quoted := range lineQuoted.FindAllStringSubmatch(text, -1) {
+			found.add(ix.lookupExact(TypeUserAgent, quoted[1]), quoted[1])
+		}
+	}
+}
+
+// matchValue checks a whole field value, which may be a user agent or a
+// URL that the text patterns would cut short.
+func matchValue(ix *index, value string, found *hits) {
+	value = strings.TrimSpace(value)
+	for _, typ := range []string{TypeUserAgent, TypeURL, TypeHash} {
+		if ix.has(typ) {
+			found.add(ix.lookupExact(typ, value), value)
+		}
+	}
+}
+
+// Lookup checks a single value of any type, for example from the API.
+func (m *Matcher) Lookup(value string) []Hit {
+	m.mu.RLock()
+	ix := m.index
+	m.mu.RUnlock()
+
+	var found hits
+	value = strings.TrimSpace(value)
+	found.add(ix.lookupIP(value), value)
+	found.add(ix.lookupDomain(value), value)
+	for _, typ := range []string{TypeURL, TypeHash, TypeUserAgent} {
+		found.add(ix.lookupExact(typ, value), value)
+	}
+	return found.list
+}
+
+// Rules returns matched with a rule for every feed that has a hit, so
+// dedup, outputs and actions treat IOC matches like any other.
+func Rules(matched []rules.Rule, hits []Hit) []rules.Rule {
+	for _, hit := range hits {
+		name := rules.IntelRuleName(hit.Feed)
+		found := false
+		for _, rule := range matched {
+			if rule.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			matched = append(matched, rules.Rule{Name: name, Severity: hit.Severity})
+		}
+	}
+	return matched
+}
+
+// Annotate returns the alert fields describing hits: the feeds, types,
+// indicators and the values they matched, each comma separated. The values
+// are also listed per type under rules.IntelField, where actions look for
+// their target.
+func Annotate(list []Hit) map[string]string {
+	var feeds, types, indicators, values []string
+	byType := make(map[string][]string)
+	add := func(list []string, value string) []string {
+		for _, existing := range list {
+			if existing == value {
+				return list
+			}
+		}
+		return append(list, value)
+	}
+	for _, hit := range list {
+		feeds = add(feeds, hit.Feed)
+		types = add(types, hit.Type)
+		indicators = add(indicators, hit.Indicator)
+		values = add(values, hit.Value)
+		byType[hit.Type] = add(byType[hit.Type], hit.Value)
+	}
+	fields := map[string]string{
+		"ioc_feed":      strings.Join(feeds, ", "),
+		"ioc_type":      strings.Join(types, ", "),
+		"ioc_indicator": strings.Join(indicators, ", "),
+		"ioc_value":     strings.Join(values, ", "),
+	}
+	for typ, list := range byType {
+		fields[rules.IntelField(typ)] = strings.Join(list, ", ")
+	}
+	var descriptions []string
+	for _, hit := range list {
+		if hit.Description != "" {
+			descriptions = add(descriptions, hit.Description)
+		}
+	}
+	if len(descriptions) > 0 {
+		sort.Strings(descriptions)
+		fields["ioc_description"] = strings.Join(descriptions, "; ")
+	}
+	return fields
+}
@@ -0,0 +1,259 @@
+package intel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+const sha256Value = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		feed  rules.IntelFeed
+		input string
+		want  []Indicator
+	}{
+		{
+			name: "plain",
+			feed: rules.IntelFeed{Format: "plain"},
+			input: `# blocklist
+203.0.113.7
+198.51.100.77/24 # scanners
+; note
+Evil.Example.
+*.bad.example
+D41D8CD98F00B204E9800998ECF8427E
+not-an-indicator
+::ffff:203.0.113.9 extra words
+`,
+			want: []Indicator{
+				{Type: TypeIP, Value: "203.0.113.7"},
+				{Type: TypeIP, Value: "198.51.100.0/24"},
+				{Type: TypeDomain, Value: "evil.example"},
+				{Type: TypeDomain, Value: "bad.example"},
+				{Type: TypeHash, Value: "d41d8cd98f00b204e9800998ecf8427e"},
+				{Type: TypeIP, Value: "203.0.113.9"},
+			},
+		},
+		{
+			name:  "plain typed",
+			feed:  rules.IntelFeed{Format: "plain", Type: "ip"},
+			input: "203.0.113.7\nevil.example\n",
+			want:  []Indicator{{Type: TypeIP, Value: "203.0.113.7"}},
+		},
+		{
+			name:  "plain user agents",
+			feed:  rules.IntelFeed{Format: "plain", Type: "user_agent"},
+			input: "sqlmap/1.7 (http://sqlmap.org)\n",
+			want:  []Indicator{{Type: TypeUserAgent, Value: "sqlmap/1.7 (http://sqlmap.org)"}},
+		},
+		{
+			name: "csv with named columns",
+			feed: rules.IntelFeed{Format: "csv", Column: "indicator", TypeColumn: "type"},
+			input: `comment,indicator,type
+c2,203.0.113.7,ip-dst
+phishing,evil.example,hostname
+unknown,foo,mutex
+`,
+			want: []Indicator{
+				{Type: TypeIP, Value: "203.0.113.7"},
+				{Type: TypeDomain, Value: "evil.example"},
+			},
+		},
+		{
+			name:  "csv header guessed",
+			feed:  rules.IntelFeed{Format: "csv", Delimiter: ";"},
+			input: "value;note\n203.0.113.7;scanner\nhttp://evil.example/x;dropper\n",
+			want: []Indicator{
+				{Type: TypeIP, Value: "203.0.113.7"},
+				{Type: TypeURL, Value: "http://evil.example/x"},
+			},
+		},
+		{
+			name: "stix",
+			feed: rules.IntelFeed{Format: "stix"},
+			input: `{"type": "bundle", "objects": [
+  {"type": "indicator", "name": "C2", "pattern": "[ipv4-addr:value = '203.0.113.7'] OR [domain-name:value = 'evil.example']"},
+  {"type": "indicator", "name": "Dropper", "pattern": "[file:hashes.'SHA-256' = '` + sha256Value + `']", "valid_until": "2999-01-01T00:00:00Z"},
+  {"type": "indicator", "name": "Revoked", "revoked": true, "pattern": "[ipv4-addr:value = '203.0.113.8']"},
+  {"type": "indicator", "name": "Expired", "valid_until": "2000-01-01T00:00:00Z", "pattern": "[ipv4-addr:value = '203.0.113.9']"},
+  {"type": "malware", "name": "Not an indicator", "pattern": "[ipv4-addr:value = '203.0.113.10']"}
+]}`,
+			want: []Indicator{
+				{Type: TypeIP, Value: "203.0.113.7", Description: "C2"},
+				{Type: TypeDomain, Value: "evil.example", Description: "C2"},
+				{Type: TypeHash, Value: sha256Value, Description: "Dropper"},
+			},
+		},
+		{
+			name: "misp",
+			feed: rules.IntelFeed{Format: "misp"},
+			input: `{"response": [{"Event": {"info": "Campaign", "Attribute": [
+  {"type": "ip-dst", "value": "203.0.113.7", "to_ids": true},
+  {"type": "ip-src", "value": "203.0.113.8", "to_ids": false},
+  {"type": "ip-dst|port", "value": "203.0.113.9|443", "to_ids": true, "comment": "C2 port"},
+  {"type": "filename|sha256", "value": "a.exe|` + sha256Value + `", "to_ids": true},
+  {"type": "domain", "value": "deleted.example", "to_ids": true, "deleted": true}
+], "Object": [{"Attribute": [{"type": "hostname", "value": "evil.example", "to_ids": true}]}]}}]}`,
+			want: []Indicator{
+				{Type: TypeDomain, Value: "evil.example", Description: "Campaign"},
+				{Type: TypeIP, Value: "203.0.113.7", Description: "Campaign"},
+				{Type: TypeIP, Value: "203.0.113.9", Description: "C2 port"},
+				{Type: TypeHash, Value: sha256Value, Description: "Campaign"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.feed, strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		feed  rules.IntelFeed
+		input string
+	}{
+		{name: "unknown format", feed: rules.IntelFeed{Format: "xml"}},
+		{name: "csv without the column", feed: rules.IntelFeed{Format: "csv", Column: "ioc"}, input: "value,type\n203.0.113.7,ip\n"},
+		{name: "stix without a bundle", feed: rules.IntelFeed{Format: "stix"}, input: `{"type": "indicator"}`},
+		{name: "broken misp", feed: rules.IntelFeed{Format: "misp"}, input: `{"Event":`},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.feed, strings.NewReader(tt.input)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func newTestMatcher(indicators []Indicator) *Matcher {
+	ix := newIndex()
+	ix.add("blocklist", "high", indicators)
+	return &Matcher{index: ix}
+}
+
+func TestMatch(t *testing.T) {
+	m := newTestMatcher([]Indicator{
+		{Type: TypeIP, Value: "203.0.113.7"},
+		{Type: TypeIP, Value: "198.51.100.0/24"},
+		{Type: TypeDomain, Value: "evil.example", Description: "C2"},
+		{Type: TypeHash, Value: sha256Value},
+		{Type: TypeURL, Value: "http://drop.example/payload.sh"},
+		{Type: TypeUserAgent, Value: "sqlmap/1.7"},
+	})
+
+	tests := []struct {
+		name   string
+		line   string
+		fields map[string]string
+		want   []Hit
+	}{
+		{
+			name: "exact address",
+			line: "Failed password for root from 203.0.113.7 port 22 ssh2",
+			want: []Hit{{Type: TypeIP, Indicator: "203.0.113.7", Value: "203.0.113.7"}},
+		},
+		{
+			name: "address in a range",
+			line: "Connection from 198.51.100.23 port 4711",
+			want: []Hit{{Type: TypeIP, Indicator: "198.51.100.0/24", Value: "198.51.100.23"}},
+		},
+		{
+			name: "address outside the range",
+			line: "Connection from 198.51.101.23 port 4711",
+		},
+		{
+			name: "subdomain",
+			line: "query[A] api.Evil.example from 10.0.0.5",
+			want: []Hit{{Type: TypeDomain, Indicator: "evil.example", Value: "api.evil.example", Description: "C2"}},
+		},
+		{
+			name: "lookalike domain",
+			line: "query[A] notevil.example from 10.0.0.5",
+		},
+		{
+			name: "hash",
+			line: "clamd: /tmp/x sha256=" + strings.ToUpper(sha256Value),
+			want: []Hit{{Type: TypeHash, Indicator: sha256Value, Value: sha256Value}},
+		},
+		{
+			name:   "url and user agent fields",
+			line:   "GET request blocked",
+			fields: map[string]string{"url": "http://drop.example/payload.sh", "agent": "sqlmap/1.7"},
+			want: []Hit{
+				{Type: TypeURL, Indicator: "http://drop.example/payload.sh", Value: "http://drop.example/payload.sh"},
+				{Type: TypeUserAgent, Indicator: "sqlmap/1.7", Value: "sqlmap/1.7"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		entry := parser.NewEntry(tt.line)
+		for key, value := range tt.fields {
+			if entry.Fields == nil {
+				entry.Fields = make(map[string]string)
+			}
+			entry.Fields[key] = value
+		}
+		got := m.Match(entry)
+		for i := range tt.want {
+			tt.want[i].Feed, tt.want[i].Severity = "blocklist", "high"
+		}
+		if !sameHits(got, tt.want) {
+			t.Errorf("%s:\n got %v\nwant %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sameHits compares hits regardless of order, as fields are matched in map
+// order.
+func sameHits(got, want []Hit) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if g == w {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAnnotateTarget(t *testing.T) {
+	hits := []Hit{
+		{Feed: "a", Type: TypeIP, Indicator: "198.51.100.0/24", Value: "198.51.100.23"},
+		{Feed: "b", Type: TypeDomain, Indicator: "evil.example", Value: "api.evil.example"},
+		{Feed: "b", Type: TypeIP, Indicator: "198.51.100.23", Value: "198.51.100.23"},
+	}
+	fields := Annotate(hits)
+	if got := fields[rules.IntelField(TypeIP)]; got != "198.51.100.23" {
+		t.Errorf("ip target = %q", got)
+	}
+	if got := fields[rules.IntelField(TypeDomain)]; got != "api.evil.example" {
+		t.Errorf("domain target = %q", got)
+	}
+	if got := fields["ioc_feed"]; got != "a, b" {
+		t.Errorf("feeds = %q", got)
+	}
+	matched := Rules([]rules.Rule{{Name: rules.IntelRuleName("a")}}, hits)
+	if len(matched) != 2 || matched[1].Name != rules.IntelRuleName("b") {
+		t.Errorf("rules = %v", matched)
+	}
+}
@@ -0,0 +1,194 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+const (
+	FormatJSON   = "journal-json"
+	FormatExport = "journal-export"
+
+	maxFieldSize = 16 * 1024 * 1024
+)
+
+// ErrInvalidEntry marks a single undecodable entry; the stream itself can
+// still be read further.
+var ErrInvalidEntry = errors.New("invalid journal entry")
+
+func IsFormat(format string) bool {
+	return format == FormatJSON || format == FormatExport
+}
+
+// Reader decodes `journalctl -o json` or `journalctl -o export` streams into
+// structured entries.
+type Reader struct {
+	reader *bufio.Reader
+	format string
+}
+
+func NewReader(r io.Reader, format string) (*Reader, error) {
+	if !IsFormat(format) {
+		return nil, fmt.Errorf("unsupported journal format %q", format)
+	}
+	return &Reader{
+		reader: bufio.NewReaderSize(r, 64*1024),
+		format: format,
+	}, nil
+}
+
+// Next returns the next entry, or io.EOF at the end of the stream.
+func (r *Reader) Next() (*parser.Entry, error) {
+	var fields map[string]string
+	var err error
+	if r.format == FormatJSON {
+		fields, err = r.nextJSON()
+	} else {
+		fields, err = r.nextExport()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ToEntry(fields), nil
+}
+
+func (r *Reader) nextJSON() (map[string]string, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return decodeJSON(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func decodeJSON(data []byte) (map[string]string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if decoded, ok := decodeJSONValue(value); ok {
+			fields[key] = decoded
+		}
+	}
+	return fields, nil
+}
+
+// decodeJSONValue handles the value shapes journalctl emits: strings, null,
+// byte arrays for binary data and arrays for repeated fields.
+func decodeJSONValue(value json.RawMessage) (string, bool) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, true
+	}
+	var bytesValue []byte
+	var numbers []int
+	if err := json.Unmarshal(value, &numbers); err == nil {
+		for _, n := range numbers {
+			bytesValue = append(bytesValue, byte(n))
+		}
+		return string(bytesValue), true
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(value, &values); err == nil && len(values) > 0 {
+		return decodeJSONValue(values[0])
+	}
+	return "", false
+}
+
+func (r *Reader) nextExport() (map[string]string, error) {
+	fields := make(map[string]string)
+	for {
+		line, err := r.reader.ReadString('\n')
+		// A last field without its trailing newline still belongs to the
+		// final entry.
+		atEOF := err == io.EOF && line != ""
+		if err != nil && !atEOF {
+			if err == io.EOF && len(fields) > 0 {
+				return fields, nil
+			}
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) == 0 {
+				continue
+			}
+			return fields, nil
+		}
+		if idx := strings.IndexByte(line, '='); idx >= 0 {
+			fields[line[:idx]] = line[idx+1:]
+			if atEOF {
+				return fields, nil
+			}
+			continue
+		}
+		if atEOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		value, err := r.readBinaryField()
+		if err != nil {
+			return nil, err
+		}
+		fields[line] = value
+	}
+}
+
+func (r *Reader) readBinaryField() (string, error) {
+	var size uint64
+	if err := binary.Read(r.reader, binary.LittleEndian, &size); err != nil {
+		return "", fmt.Errorf("invalid binary field: %w", err)
+	}
+	if size > maxFieldSize {
+		return "", fmt.Errorf("binary field too large: %d bytes", size)
+	}
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return "", fmt.Errorf("invalid binary field: %w", err)
+	}
+	return string(data[:size]), nil
+}
+
+// ToEntry maps journal fields onto the structured entry used by rules.
+func ToEntry(fields map[string]string) *parser.Entry {
+	entry := &parser.Entry{
+		Host:    fields["_HOSTNAME"],
+		Program: first(fields["SYSLOG_IDENTIFIER"], fields["_COMM"]),
+		Unit:    first(fields["_SYSTEMD_UNIT"], fields["_SYSTEMD_USER_UNIT"]),
+		PID:     first(fields["_PID"], fields["SYSLOG_PID"]),
+		Message: fields["MESSAGE"],
+		Fields:  fields,
+	}
+	for _, key := range []string{"__REALTIME_TIMESTAMP", "_SOURCE_REALTIME_TIMESTAMP"} {
+		if usec, err := strconv.ParseInt(fields[key], 10, 64); err == nil {
+			entry.Timestamp = time.UnixMicro(usec)
+			break
+		}
+	}
+	entry.Line = entry.SyslogLine()
+	return entry
+}
+
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
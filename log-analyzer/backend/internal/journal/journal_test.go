@@ -0,0 +1,153 @@
+package journal
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// binaryField encodes a field the way `journalctl -o export` writes values
+// that contain newlines.
+func binaryField(name, value string) string {
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len(value)))
+	return name + "\n" + string(size) + value + "\n"
+}
+
+// readAll reads fields until the stream ends and returns the error that
+// ended it, nil for io.EOF.
+func readAll(t *testing.T, format, input string) ([]map[string]string, error) {
+	t.Helper()
+	reader, err := NewReader(strings.NewReader(input), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]string
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, ErrInvalidEntry) {
+			entries = append(entries, nil)
+			continue
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry.Fields)
+	}
+}
+
+func TestExportReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []map[string]string
+		wantErr bool
+	}{
+		{
+			name: "text and binary fields",
+			input: "__REALTIME_TIMESTAMP=1792317600000000\n_HOSTNAME=web\n" +
+				binaryField("MESSAGE", "first line\nsecond=line") + "_PID=811\n\n\n" +
+				"MESSAGE=next\n\n",
+			want: []map[string]string{
+				{"__REALTIME_TIMESTAMP": "1792317600000000", "_HOSTNAME": "web", "MESSAGE": "first line\nsecond=line", "_PID": "811"},
+				{"MESSAGE": "next"},
+			},
+		},
+		{
+			name:  "no blank line after the last entry",
+			input: "MESSAGE=a\n\nMESSAGE=b\n_PID=2\n",
+			want:  []map[string]string{{"MESSAGE": "a"}, {"MESSAGE": "b", "_PID": "2"}},
+		},
+		{
+			// The last field of a stream without a final newline is kept
+			// (e3c3a84).
+			name:  "no final newline",
+			input: "MESSAGE=a\n_PID=1",
+			want:  []map[string]string{{"MESSAGE": "a", "_PID": "1"}},
+		},
+		{
+			name:  "empty value at the end",
+			input: "MESSAGE=",
+			want:  []map[string]string{{"MESSAGE": ""}},
+		},
+		{
+			name:    "binary field name at the end",
+			input:   "_PID=1\nMESSAGE",
+			wantErr: true,
+		},
+		{
+			name:    "truncated binary value",
+			input:   binaryField("MESSAGE", "complete value")[:20],
+			wantErr: true,
+		},
+		{
+			name:    "oversized binary value",
+			input:   "MESSAGE\n\xff\xff\xff\xff\x00\x00\x00\x00",
+			wantErr: true,
+		},
+		{name: "empty stream", input: "\n\n"},
+	}
+	for _, tt := range tests {
+		got, err := readAll(t, FormatExport, tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJSONReader(t *testing.T) {
+	input := `{"MESSAGE": "Failed password for root", "_PID": "811", "_HOSTNAME": "web", "CODE_LINE": null}
+
+{"MESSAGE": [104, 105, 10, 255], "SYSLOG_IDENTIFIER": ["sshd", "sshd-session"]}
+not json
+{"MESSAGE": "last", "_SYSTEMD_UNIT": "cron.service"}`
+	got, err := readAll(t, FormatJSON, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"MESSAGE": "Failed password for root", "_PID": "811", "_HOSTNAME": "web", "CODE_LINE": ""},
+		{"MESSAGE": "hi\n\xff", "SYSLOG_IDENTIFIER": "sshd"},
+		nil,
+		{"MESSAGE": "last", "_SYSTEMD_UNIT": "cron.service"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("entries:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestToEntry(t *testing.T) {
+	entry := ToEntry(map[string]string{
+		"__REALTIME_TIMESTAMP": "1792317600000000",
+		"_HOSTNAME":            "web",
+		"_COMM":                "sshd",
+		"SYSLOG_PID":           "811",
+		"_SYSTEMD_UNIT":        "ssh.service",
+		"MESSAGE":              "Failed password for root from 203.0.113.7 port 22 ssh2",
+	})
+	if !entry.Timestamp.Equal(time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("timestamp = %v", entry.Timestamp)
+	}
+	if entry.Host != "web" || entry.Program != "sshd" || entry.PID != "811" || entry.Unit != "ssh.service" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if want := " web sshd[811]: Failed password for root"; !strings.Contains(entry.Line, want) {
+		t.Errorf("line = %q, want it to contain %q", entry.Line, want)
+	}
+}
+
+func TestNewReaderFormat(t *testing.T) {
+	if _, err := NewReader(strings.NewReader(""), "plain"); err == nil {
+		t.Error("plain was accepted as a journal format")
+	}
+}
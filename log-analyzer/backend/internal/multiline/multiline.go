@@ -0,0 +1,67 @@
+package multiline
+
+import (
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/rules"
+)
+
+// Assembler joins continuation lines into events according to a LogFile's
+// multiline configuration. Without a configuration every line is an event.
+type Assembler struct {
+	config  *rules.MultilineConfig
+	lines   []string
+	updated time.Time
+}
+
+func NewAssembler(config *rules.MultilineConfig) *Assembler {
+	return &Assembler{config: config}
+}
+
+// Add feeds a line and returns the events completed by it.
+func (a *Assembler) Add(line string) []string {
+	if a.config == nil {
+		return []string{line}
+	}
+
+	var events []string
+	if len(a.lines) > 0 && !a.config.IsContinuation(line) {
+		events = append(events, a.take())
+	}
+	a.lines = append(a.lines, line)
+	a.updated = time.Now()
+	if len(a.lines) >= a.config.MaxLines {
+		events = append(events, a.take())
+	}
+	return events
+}
+
+// StartsEvent reports whether line would begin a new event rather than
+// continue the pending one.
+func (a *Assembler) StartsEvent(line string) bool {
+	return a.config == nil || len(a.lines) == 0 || !a.config.IsContinuation(line)
+}
+
+// Flush returns the pending event, if any.
+func (a *Assembler) Flush() (string, bool) {
+	if len(a.lines) == 0 {
+		return "", false
+	}
+	return a.take(), true
+}
+
+// Expired reports whether the pending event has waited longer than the
+// configured flush timeout for more continuation lines.
+func (a *Assembler) Expired(now time.Time) bool {
+	if a.config == nil || len(a.lines) == 0 {
+		return false
+	}
+	return now.Sub(a.updated) >= a.config.Timeout()
+}
+
+func (a *Assembler) take() string {
+	event := strings.Join(a.lines, "\n")
+	a.lines = a.lines[:0]
+	return event
+}
@@ -0,0 +1,377 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/ids"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+const (
+	maxDigestAlerts = 10000
+	digestTopN      = 10
+)
+
+// emailSender mails severe alerts at once and keeps the others for the
+// next digest. Every recipient group has its own digest queue, so a group
+// whose mail fails gets its alerts again without repeating them to the
+// groups that were reached.
+type emailSender struct {
+	config    rules.EmailOutput
+	immediate int
+	interval  time.Duration
+	mu        sync.Mutex
+	queues    []digestQueue
+}
+
+// digestQueue holds the alerts waiting for one recipient group's digest.
+type digestQueue struct {
+	alerts  []alerts.Alert
+	since   time.Time
+	skipped int
+}
+
+func newEmailSender(config rules.EmailOutput) *emailSender {
+	immediate := config.ImmediateSeverity
+	if immediate == "" {
+		immediate = "critical"
+	}
+	queues := make([]digestQueue, len(config.Recipients))
+	for i := range queues {
+		queues[i].since = time.Now()
+	}
+	return &emailSender{
+		config:    config,
+		immediate: analyzer.SeverityLevel(immediate),
+		interval:  parseDuration(config.DigestInterval, 24*time.Hour),
+		queues:    queues,
+	}
+}
+
+func (s *emailSender) Send(ctx context.Context, alert alerts.Alert) error {
+	if analyzer.SeverityLevel(alert.Severity) >= s.immediate {
+		return s.mailAlert(ctx, alert, false)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, recipient := range s.config.Recipients {
+		if !matchesFilter(alert, recipient.MinSeverity, recipient.Rules) {
+			continue
+		}
+		queue := &s.queues[i]
+		if len(queue.alerts) >= maxDigestAlerts {
+			queue.skipped++
+			continue
+		}
+		queue.alerts = append(queue.alerts, alert)
+	}
+	return nil
+}
+
+// Test mails a sample alert to every recipient group.
+func (s *emailSender) Test(ctx context.Context) error {
+	return s.mailAlert(ctx, SampleAlert(), true)
+}
+
+func (s *emailSender) mailAlert(ctx context.Context, alert alerts.Alert, everyone bool) error {
+	var to []string
+	seen := make(map[string]bool)
+	for _, recipient := range s.config.Recipients {
+		if !everyone && !matchesFilter(alert, recipient.MinSeverity, recipient.Rules) {
+			continue
+		}
+		for _, address := range recipient.To {
+			if !seen[address] {
+				seen[address] = true
+				to = append(to, address)
+			}
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", alert.Summary)
+	fmt.Fprintf(&b, "Önem     : %s\n", alert.Severity)
+	fmt.Fprintf(&b, "Kurallar : %s\n", strings.Join(alert.MatchedRules, ", "))
+	fmt.Fprintf(&b, "Zaman    : %s\n", alert.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "Kaynak   : %s\n", alert.Source)
+	if alert.Host != "" {
+		fmt.Fprintf(&b, "Sunucu   : %s\n", alert.Host)
+	}
+	if alert.Count > 1 {
+		fmt.Fprintf(&b, "Tekrar   : %d kez, son %s\n", alert.Count, alert.LastSeen.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(&b, "\nLog satırı:\n%s\n", alert.Line)
+	if alert.ID != "" {
+		fmt.Fprintf(&b, "\nUyarı kimliği: %s\n", alert.ID)
+	}
+	return s.mail(ctx, to, alertTitle(alert)+": "+truncate(alert.Summary, 80), b.String())
+}
+
+// Flush mails every recipient group the digest of its pending alerts. A
+// group whose mail fails keeps its alerts for the next try; the others do
+// not get them again.
+func (s *emailSender) Flush(ctx context.Context) error {
+	until := time.Now()
+	var failed []error
+	for i, recipient := range s.config.Recipients {
+		s.mu.Lock()
+		queue := s.queues[i]
+		s.queues[i] = digestQueue{since: until}
+		s.mu.Unlock()
+		if len(queue.alerts) == 0 {
+			continue
+		}
+
+		subject, body := digest(queue.alerts, queue.since, until, queue.skipped)
+		if err := s.mail(ctx, recipient.To, subject, body); err != nil {
+			failed = append(failed, fmt.Errorf("digest to %s: %w", strings.Join(recipient.To, ", "), err))
+			s.mu.Lock()
+			retry := &s.queues[i]
+			retry.alerts = append(queue.alerts, retry.alerts...)
+			retry.since = queue.since
+			retry.skipped += queue.skipped
+			s.mu.Unlock()
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// runDigests mails a digest every interval, aligned to DigestAt if set.
+func (s *emailSender) runDigests(name string, stop <-chan struct{}) {
+	for {
+		timer := time.NewTimer(time.Until(s.nextDigest(time.Now())))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		if err := s.Flush(ctx); err != nil {
+			log.Printf("Output %s could not send digest: %v", name, err)
+		}
+		cancel()
+	}
+}
+
+func (s *emailSender) nextDigest(now time.Time) time.Time {
+	at, err := time.Parse("15:04", s.config.DigestAt)
+	if err != nil {
+		return now.Add(s.interval)
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	for next.After(now.Add(s.interval)) {
+		next = next.Add(-s.interval)
+	}
+	for !next.After(now) {
+		next = next.Add(s.interval)
+	}
+	return next
+}
+
+type digestCount struct {
+	name  string
+	count int
+}
+
+func topCounts(counts map[string]int, limit int) []digestCount {
+	result := make([]digestCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, digestCount{name, count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].count != result[j].count {
+			return result[i].count > result[j].count
+		}
+		return result[i].name < result[j].name
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// digest summarises alerts by severity, rule, source address and file.
+func digest(list []alerts.Alert, since, until time.Time, skipped int) (string, string) {
+	severities := make(map[string]int)
+	byRule := make(map[string]int)
+	bySource := make(map[string]int)
+	byFile := make(map[string]int)
+	total := 0
+	for _, alert := range list {
+		count := alert.Count
+		if count < 1 {
+			count = 1
+		}
+		total += count
+		severities[alert.Severity] += count
+		for _, rule := range alert.MatchedRules {
+			byRule[rule] += count
+		}
+		for _, ip := range rules.FieldValues(parser.NewEntry(alert.Line), "ip") {
+			bySource[ip] += count
+		}
+		byFile[alert.Source] += count
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dönem: %s - %s\n", since.Format("2006-01-02 15:04"), until.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Toplam: %d uyarı, tekrarlarla %d olay\n", len(list), total)
+	if skipped > 0 {
+		fmt.Fprintf(&b, "Sınır aşıldığı için özete alınmayan: %d uyarı\n", skipped)
+	}
+	sections := []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"Önem derecesine göre", severities},
+		{"Kurala göre", byRule},
+		{"En çok görülen kaynak adresler", bySource},
+		{"Dosyaya göre", byFile},
+	}
+	for _, section := range sections {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", section.title)
+		for _, c := range topCounts(section.counts, digestTopN) {
+			fmt.Fprintf(&b, "  %6d  %s\n", c.count, c.name)
+		}
+	}
+	return fmt.Sprintf("Özet: %d uyarı (%s)", len(list), since.Format("2006-01-02 15:04")), b.String()
+}
+
+func (s *emailSender) mail(ctx context.Context, to []string, subject, body string) error {
+	prefix := s.config.SubjectPrefix
+	if prefix == "" {
+		prefix = "[Log Analyzer]"
+	}
+	message, err := buildMessage(s.config.From, to, prefix+" "+subject, body)
+	if err != nil {
+		return err
+	}
+
+	mode := s.config.TLS
+	port := s.config.Port
+	if port == 0 {
+		port = 587
+		if mode == "tls" {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: s.config.Host, InsecureSkipVerify: s.config.InsecureSkipVerify}
+	if mode == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if mode == "" || mode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if mode == "starttls" {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", addr)
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(s.config.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, address := range to {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return err
+		}
+		if err := client.Rcpt(parsed.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMessage(from string, to []string, subject, body string) ([]byte, error) {
+	domain := "log-analyzer"
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(parsed.Address, "@"); at >= 0 {
+			domain = parsed.Address[at+1:]
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", ids.New(), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/rules"
+)
+
+// smtpServer is just enough of an SMTP server for net/smtp. It refuses
+// RCPT for the addresses in reject and keeps every accepted message.
+type smtpServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	reject   map[string]bool
+	messages []smtpMessage
+}
+
+type smtpMessage struct {
+	to   []string
+	data string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: listener, reject: make(map[string]bool)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 test ESMTP")
+	var to []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 test")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			to = nil
+			reply("250 ok")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			address := strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			s.mu.Lock()
+			rejected := s.reject[address]
+			s.mu.Unlock()
+			if rejected {
+				reply("550 no such user")
+				continue
+			}
+			to = append(to, address)
+			reply("250 ok")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, smtpMessage{to: to, data: data.String()})
+			s.mu.Unlock()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// received returns the messages delivered to address, decoded.
+func (s *smtpServer) received(t *testing.T, address string) []string {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var bodies []string
+	for _, m := range s.messages {
+		for _, to := range m.to {
+			if to != address {
+				continue
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(m.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			bodies = append(bodies, subject+"\n"+string(body))
+		}
+	}
+	return bodies
+}
+
+func (s *smtpServer) emailOutput(recipients ...rules.EmailRecipient) rules.EmailOutput {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return rules.EmailOutput{
+		Host:              "127.0.0.1",
+		Port:              addr.Port,
+		From:              "Log Analyzer <alerts@example.com>",
+		Recipients:        recipients,
+		ImmediateSeverity: "critical",
+	}
+}
+
+func digestAlert(rule, severity, line string) alerts.Alert {
+	alert := SampleAlert()
+	alert.MatchedRules = []string{rule}
+	alert.Severity = severity
+	alert.Line = line
+	return alert
+}
+
+func TestEmailDigestPerRecipient(t *testing.T) {
+	server := newSMTPServer(t)
+	sender := newEmailSender(server.emailOutput(
+		rules.EmailRecipient{To: []string{"ops@example.com"}},
+		rules.EmailRecipient{To: []string{"web@example.com"}, Rules: []string{"SQL Injection Denemesi"}},
+	))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sender.Send(ctx, digestAlert("Parola Denemesi", "yüksek", "Failed password for root from 203.0.113.7"))
+	sender.Send(ctx, digestAlert("Parola Denemesi", "yüksek", "Failed password for root from 203.0.113.7"))
+	sender.Send(ctx, digestAlert("SQL Injection Denemesi", "orta", "GET /?q=UNION SELECT from 198.51.100.9"))
+
+	server.mu.Lock()
+	server.reject["web@example.com"] = true
+	server.mu.Unlock()
+	if err := sender.Flush(ctx); err == nil || !strings.Contains(err.Error(), "web@example.com") {
+		t.Fatalf("Flush error = %v, want the failed web@example.com digest", err)
+	}
+
+	ops := server.received(t, "ops@example.com")
+	if len(ops) != 1 {
+		t.Fatalf("ops got %d digests, want 1", len(ops))
+	}
+	for _, want := range []string{"Özet: 3 uyarı", "2  Parola Denemesi", "2  203.0.113.7", "1  198.51.100.9"} {
+		if !strings.Contains(ops[0], want) {
+			t.Errorf("ops digest lacks %q:\n%s", want, ops[0])
+		}
+	}
+
+	// Only the group that failed is mailed on the next flush.
+	server.mu.Lock()
+	server.reject["web@example.com"] = false
+	server.mu.Unlock()
+	if err := sender.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received(t, "ops@example.com")); n != 1 {
+		t.Errorf("ops got %d digests after the retry, want still 1", n)
+	}
+	web := server.received(t, "web@example.com")
+	if len(web) != 1 || !strings.Contains(web[0], "Özet: 1 uyarı") || strings.Contains(web[0], "Parola") {
+		t.Errorf("web digests = %q, want one with only the SQL injection alert", web)
+	}
+
+	if err := sender.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received(t, "ops@example.com")) + len(server.received(t, "web@example.com")); n != 2 {
+		t.Errorf("an empty flush sent mail: %d digests in total", n)
+	}
+}
+
+func TestEmailImmediate(t *testing.T) {
+	server := newSMTPServer(t)
+	sender := newEmailSender(server.emailOutput(
+		rules.EmailRecipient{To: []string{"ops@example.com"}},
+		rules.EmailRecipient{To: []string{"dba@example.com"}, MinSeverity: "critical", Rules: []string{"Veritabanı"}},
+	))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := sender.Send(ctx, digestAlert("Parola Denemesi", "kritik", "Failed password for root")); err != nil {
+		t.Fatal(err)
+	}
+	ops := server.received(t, "ops@example.com")
+	if len(ops) != 1 || !strings.Contains(ops[0], "Failed password for root") {
+		t.Errorf("ops got %q, want the critical alert at once", ops)
+	}
+	if dba := server.received(t, "dba@example.com"); len(dba) != 0 {
+		t.Errorf("dba got %d mails for a rule it does not follow", len(dba))
+	}
+}
+
+func TestDispatcherSendsDigestsOnClose(t *testing.T) {
+	server := newSMTPServer(t)
+	email := server.emailOutput(rules.EmailRecipient{To: []string{"ops@example.com"}})
+	d := NewDispatcher([]rules.Output{{Name: "posta", Type: "email", Email: &email}})
+
+	d.Notify(digestAlert("Parola Denemesi", "yüksek", "Failed password for root from 203.0.113.7"))
+	waitFor(t, "queued digest", func() bool { return d.Statuses()[0].Sent == 1 })
+	if n := len(server.received(t, "ops@example.com")); n != 0 {
+		t.Fatalf("ops got %d mails before the digest", n)
+	}
+
+	d.Close()
+	ops := server.received(t, "ops@example.com")
+	if len(ops) != 1 || !strings.Contains(ops[0], "Özet: 1 uyarı") {
+		t.Errorf("ops got %q, want the pending digest on close", ops)
+	}
+}
@@ -0,0 +1,429 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/ids"
+	"log-analyzer/backend/internal/rules"
+)
+
+// Sender delivers one alert to a destination.
+type Sender interface {
+	Send(ctx context.Context, alert alerts.Alert) error
+}
+
+// NewSender builds the sender of an output.
+func NewSender(output rules.Output) (Sender, error) {
+	switch output.Type {
+	case "webhook":
+		return newWebhookSender(*output.Webhook)
+	case "email":
+		return newEmailSender(*output.Email), nil
+	case "syslog":
+		return newSyslogSender(*output.Syslog)
+	default:
+		return nil, fmt.Errorf("unsupported output type %q", output.Type)
+	}
+}
+
+// tester is a sender with its own way to send a test message.
+type tester interface {
+	Test(ctx context.Context) error
+}
+
+// digester is a sender that batches alerts and sends them on a schedule.
+type digester interface {
+	Flush(ctx context.Context) error
+	runDigests(name string, stop <-chan struct{})
+}
+
+// Test sends a sample alert to an output right away, without retries.
+func Test(ctx context.Context, output rules.Output) error {
+	sender, err := NewSender(output)
+	if err != nil {
+		return err
+	}
+	if t, ok := sender.(tester); ok {
+		return t.Test(ctx)
+	}
+	return sender.Send(ctx, SampleAlert())
+}
+
+// ErrNoDigest is returned when flushing an output that sends no digests.
+var ErrNoDigest = errors.New("output has no digest")
+
+const (
+	defaultQueueSize = 256
+	maxDeadLetters   = 1000
+	maxBackoff       = 5 * time.Minute
+)
+
+// Status reports the deliveries of one output.
+type Status struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Sent        int64      `json:"sent"`
+	Failed      int64      `json:"failed"`
+	Retried     int64      `json:"retried"`
+	Queued      int        `json:"queued"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastSent    *time.Time `json:"lastSent,omitempty"`
+	LastFailure *time.Time `json:"lastFailure,omitempty"`
+}
+
+// DeadLetter is an alert an output gave up on after its retries.
+type DeadLetter struct {
+	ID       string       `json:"id"`
+	Output   string       `json:"output"`
+	Alert    alerts.Alert `json:"alert"`
+	Error    string       `json:"error"`
+	Attempts int          `json:"attempts"`
+	FailedAt time.Time    `json:"failedAt"`
+}
+
+type destination struct {
+	output     rules.Output
+	sender     Sender
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+	queue      chan alerts.Alert
+	status     Status
+}
+
+// accepts applies the output's severity and rule filters.
+func (d *destination) accepts(alert alerts.Alert) bool {
+	return matchesFilter(alert, d.output.MinSeverity, d.output.Rules)
+}
+
+// matchesFilter reports whether alert is at least minSeverity and matched
+// one of ruleNames; empty filters match everything.
+func matchesFilter(alert alerts.Alert, minSeverity string, ruleNames []string) bool {
+	if minSeverity != "" && analyzer.SeverityLevel(alert.Severity) < analyzer.SeverityLevel(minSeverity) {
+		return false
+	}
+	if len(ruleNames) == 0 {
+		return true
+	}
+	for _, wanted := range ruleNames {
+		for _, matched := range alert.MatchedRules {
+			if strings.EqualFold(wanted, matched) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Dispatcher fans live alerts out to the configured outputs. Every output
+// has its own queue and worker so a slow or failing destination neither
+// blocks the alert pipeline nor the other outputs.
+type Dispatcher struct {
+	mu           sync.Mutex
+	destinations []*destination
+	deadLetters  []DeadLetter
+	dirty        bool
+	stop         chan struct{}
+	workers      sync.WaitGroup
+}
+
+func NewDispatcher(outputs []rules.Output) *Dispatcher {
+	d := &Dispatcher{stop: make(chan struct{})}
+	for _, output := range outputs {
+		sender, err := NewSender(output)
+		if err != nil {
+			log.Printf("Output %s could not be started: %v", output.Name, err)
+			continue
+		}
+		dest := &destination{
+			output:     output,
+			sender:     sender,
+			timeout:    10 * time.Second,
+			maxRetries: 3,
+			backoff:    2 * time.Second,
+			queue:      make(chan alerts.Alert, queueSize(output.Delivery().QueueSize)),
+			status:     Status{Name: output.Name, Type: output.Type},
+		}
+		delivery := output.Delivery()
+		dest.timeout = parseDuration(delivery.Timeout, dest.timeout)
+		dest.backoff = parseDuration(delivery.Backoff, dest.backoff)
+		if delivery.MaxRetries != nil {
+			dest.maxRetries = *delivery.MaxRetries
+		}
+		d.destinations = append(d.destinations, dest)
+		d.workers.Add(1)
+		go d.run(dest)
+		if digests, ok := sender.(digester); ok {
+			d.workers.Add(1)
+			go func(name string) {
+				defer d.workers.Done()
+				digests.runDigests(name, d.stop)
+			}(output.Name)
+		}
+	}
+	return d
+}
+
+func queueSize(configured int) int {
+	if configured > 0 {
+		return configured
+	}
+	return defaultQueueSize
+}
+
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+// Notify queues an alert for every output whose filters accept it. It never
+// blocks; an alert that does not fit in a full queue goes to the dead
+// letters.
+func (d *Dispatcher) Notify(alert alerts.Alert) {
+	for _, dest := range d.destinations {
+		if !dest.accepts(alert) {
+			continue
+		}
+		select {
+		case dest.queue <- alert:
+		default:
+			d.fail(dest, alert, 0, fmt.Errorf("queue full"))
+		}
+	}
+}
+
+func (d *Dispatcher) run(dest *destination) {
+	defer d.workers.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case alert := <-dest.queue:
+			d.deliver(dest, alert)
+		}
+	}
+}
+
+// deliver sends an alert, retrying with exponential backoff before giving
+// it up to the dead letters.
+func (d *Dispatcher) deliver(dest *destination, alert alerts.Alert) {
+	backoff := dest.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), dest.timeout)
+		err := dest.sender.Send(ctx, alert)
+		cancel()
+		if err == nil {
+			now := time.Now()
+			d.mu.Lock()
+			dest.status.Sent++
+			dest.status.LastSent = &now
+			d.mu.Unlock()
+			return
+		}
+		if attempt > dest.maxRetries {
+			d.fail(dest, alert, attempt, err)
+			return
+		}
+
+		d.mu.Lock()
+		dest.status.Retried++
+		dest.status.LastError = err.Error()
+		d.mu.Unlock()
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (d *Dispatcher) fail(dest *destination, alert alerts.Alert, attempts int, err error) {
+	log.Printf("Output %s dropped alert %s: %v", dest.output.Name, alert.ID, err)
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dest.status.Failed++
+	dest.status.LastError = err.Error()
+	dest.status.LastFailure = &now
+	d.deadLetters = append(d.deadLetters, DeadLetter{
+		ID:       ids.New(),
+		Output:   dest.output.Name,
+		Alert:    alert,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: now,
+	})
+	if len(d.deadLetters) > maxDeadLetters {
+		d.deadLetters = d.deadLetters[len(d.deadLetters)-maxDeadLetters:]
+	}
+	d.dirty = true
+}
+
+func (d *Dispatcher) Statuses() []Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]Status, 0, len(d.destinations))
+	for _, dest := range d.destinations {
+		status := dest.status
+		status.Queued = len(dest.queue)
+		result = append(result, status)
+	}
+	return result
+}
+
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DeadLetter{}, d.deadLetters...)
+}
+
+// RetryDeadLetters queues dead letters for another round of delivery; with
+// no IDs all of them are retried. Letters of outputs that are no longer
+// configured stay put. It returns how many were queued.
+func (d *Dispatcher) RetryDeadLetters(ids ...string) int {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	d.mu.Lock()
+	var retry []DeadLetter
+	kept := d.deadLetters[:0]
+	for _, letter := range d.deadLetters {
+		if (len(ids) == 0 || wanted[letter.ID]) && d.destination(letter.Output) != nil {
+			retry = append(retry, letter)
+		} else {
+			kept = append(kept, letter)
+		}
+	}
+	d.deadLetters = kept
+	d.dirty = d.dirty || len(retry) > 0
+	d.mu.Unlock()
+
+	for _, letter := range retry {
+		dest := d.destination(letter.Output)
+		select {
+		case dest.queue <- letter.Alert:
+		default:
+			d.fail(dest, letter.Alert, letter.Attempts, fmt.Errorf("queue full"))
+		}
+	}
+	return len(retry)
+}
+
+// FlushDigest sends the pending digest of an output now.
+func (d *Dispatcher) FlushDigest(ctx context.Context, name string) error {
+	dest := d.destination(name)
+	if dest == nil {
+		return fmt.Errorf("output %s is not running", name)
+	}
+	digests, ok := dest.sender.(digester)
+	if !ok {
+		return ErrNoDigest
+	}
+	return digests.Flush(ctx)
+}
+
+// ClearDeadLetters drops all dead letters and returns how many there were.
+func (d *Dispatcher) ClearDeadLetters() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	removed := len(d.deadLetters)
+	d.deadLetters = nil
+	d.dirty = d.dirty || removed > 0
+	return removed
+}
+
+func (d *Dispatcher) destination(name string) *destination {
+	for _, dest := range d.destinations {
+		if dest.output.Name == name {
+			return dest
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the dead letters if they changed since the last call.
+func (d *Dispatcher) Marshal() ([]byte, bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return nil, false, nil
+	}
+	data, err := json.Marshal(d.deadLetters)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode dead letters: %w", err)
+	}
+	d.dirty = false
+	return data, true, nil
+}
+
+// Load replaces the dead letters with previously marshalled ones.
+func (d *Dispatcher) Load(data []byte) error {
+	var loaded []DeadLetter
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse dead letters: %w", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deadLetters = loaded
+	return nil
+}
+
+// Close stops the workers, waits for the deliveries under way, sends the
+// pending digests and closes the connections of the senders; queued alerts
+// are not delivered.
+func (d *Dispatcher) Close() {
+	close(d.stop)
+	d.workers.Wait()
+	for _, dest := range d.destinations {
+		if digests, ok := dest.sender.(digester); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), dest.timeout)
+			if err := digests.Flush(ctx); err != nil {
+				log.Printf("Output %s could not send digest on shutdown: %v", dest.output.Name, err)
+			}
+			cancel()
+		}
+		if c, ok := dest.sender.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Printf("Output %s could not be closed: %v", dest.output.Name, err)
+			}
+		}
+	}
+}
+
+// SampleAlert is a made-up alert for testing outputs.
+func SampleAlert() alerts.Alert {
+	now := time.Now()
+	return alerts.Alert{
+		ID:           "test",
+		Timestamp:    now,
+		Source:       "log-analyzer",
+		LogFile:      "/var/log/auth.log",
+		Line:         "Failed password for root from 203.0.113.7 port 52114 ssh2",
+		Summary:      "Test uyarısı: root için başarısız parola denemesi (203.0.113.7)",
+		MatchedRules: []string{"Test"},
+		Severity:     "yüksek",
+		Host:         "web",
+		Count:        1,
+		LastSeen:     now,
+		Status:       alerts.StatusNew,
+	}
+}
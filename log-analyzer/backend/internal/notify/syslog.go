@@ -0,0 +1,281 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+	"log-analyzer/backend/internal/syslog"
+)
+
+const (
+	eventVendor  = "log-analyzer"
+	eventProduct = "log-analyzer"
+	eventVersion = "1.0"
+)
+
+// syslogSender forwards alerts to a SIEM as RFC 5424 syslog messages with a
+// CEF or LEEF payload.
+type syslogSender struct {
+	config   rules.SyslogOutput
+	client   *syslog.Client
+	facility int
+	hostname string
+}
+
+func newSyslogSender(config rules.SyslogOutput) (*syslogSender, error) {
+	clientConfig := syslog.Config{Protocol: config.Protocol, Address: config.Address, Framing: config.Framing}
+	if config.Protocol == "tls" {
+		tlsConfig, err := clientTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		clientConfig.TLSConfig = tlsConfig
+	}
+	facility, ok := syslog.FacilityCode(config.Facility)
+	if !ok {
+		facility, _ = syslog.FacilityCode("local0")
+	}
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	if config.Format == "" {
+		config.Format = "cef"
+	}
+	if config.AppName == "" {
+		config.AppName = "log-analyzer"
+	}
+	return &syslogSender{
+		config:   config,
+		client:   syslog.NewClient(clientConfig),
+		facility: facility,
+		hostname: hostname,
+	}, nil
+}
+
+func clientTLSConfig(config rules.SyslogOutput) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(config.Address)
+	if err != nil {
+		host = config.Address
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if config.TLSCA != "" {
+		pem, err := os.ReadFile(config.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read syslog CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.TLSCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (s *syslogSender) Send(ctx context.Context, alert alerts.Alert) error {
+	level := analyzer.SeverityLevel(alert.Severity)
+	payload := formatCEF(alert, s.hostname)
+	if s.config.Format == "leef" {
+		payload = formatLEEF(alert, s.hostname)
+	}
+	return s.client.Send(ctx, syslog.Message{
+		Facility:  s.facility,
+		Severity:  syslogSeverity(level),
+		Timestamp: alert.Timestamp,
+		Hostname:  s.hostname,
+		AppName:   s.config.AppName,
+		MsgID:     strings.ToUpper(s.config.Format),
+		Message:   payload,
+	})
+}
+
+// Close closes the connection to the collector.
+func (s *syslogSender) Close() error {
+	return s.client.Close()
+}
+
+// syslogSeverity maps a severity level to crit, err, warning, notice or info.
+func syslogSeverity(level int) int {
+	switch level {
+	case 4:
+		return 2
+	case 3:
+		return 3
+	case 2:
+		return 4
+	case 1:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// cefSeverity maps a severity level onto the 0-10 scale of CEF and LEEF.
+func cefSeverity(level int) int {
+	switch level {
+	case 4:
+		return 10
+	case 3:
+		return 8
+	case 2:
+		return 5
+	case 1:
+		return 3
+	default:
+		return 0
+	}
+}
+
+type extension struct {
+	key, value string
+}
+
+// alertExtensions lists the alert fields shared by CEF and LEEF, using the
+// CEF dictionary names; LEEF renames a few of them.
+func alertExtensions(alert alerts.Alert, hostname string) []extension {
+	ext := []extension{
+		{"rt", strconv.FormatInt(alert.Timestamp.UnixMilli(), 10)},
+		{"msg", alert.Summary},
+		{"fname", alert.LogFile},
+		{"dvchost", hostname},
+	}
+	if alert.Host != "" {
+		ext = append(ext, extension{"shost", alert.Host})
+	}
+	entry := parser.NewEntry(alert.Line)
+	if ips := rules.FieldValues(entry, "ip"); len(ips) > 0 {
+		ext = append(ext, extension{"src", ips[0]})
+	}
+	if users := rules.FieldValues(entry, "user"); len(users) > 0 {
+		ext = append(ext, extension{"suser", users[0]})
+	}
+	if alert.Count > 1 {
+		ext = append(ext, extension{"cnt", strconv.Itoa(alert.Count)})
+	}
+	custom := []extension{
+		{"rules", strings.Join(alert.MatchedRules, ", ")},
+		{"alertId", alert.ID},
+		{"source", alert.Source},
+		{"line", alert.Line},
+	}
+	for i, c := range custom {
+		if c.value != "" {
+			key := "cs" + strconv.Itoa(i+1)
+			ext = append(ext, extension{key + "Label", c.key}, extension{key, c.value})
+		}
+	}
+	names := make([]string, 0, len(alert.Fields))
+	for name := range alert.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ext = append(ext, extension{extensionKey(name), alert.Fields[name]})
+	}
+	return ext
+}
+
+// extensionKey turns a field name into a key that needs no escaping.
+func extensionKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func formatCEF(alert alerts.Alert, hostname string) string {
+	signature := "alert"
+	if len(alert.MatchedRules) > 0 {
+		signature = alert.MatchedRules[0]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|", eventVendor, eventProduct, eventVersion,
+		cefHeader(signature), cefHeader(alert.Summary), cefSeverity(analyzer.SeverityLevel(alert.Severity)))
+	var pairs []string
+	for _, e := range alertExtensions(alert, hostname) {
+		if e.value != "" {
+			pairs = append(pairs, e.key+"="+cefValue(e.value))
+		}
+	}
+	b.WriteString(strings.Join(pairs, " "))
+	return b.String()
+}
+
+func cefHeader(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func cefValue(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "=", "\\=")
+	return strings.NewReplacer("\r\n", "\\n", "\n", "\\n", "\r", "\\r").Replace(s)
+}
+
+var leefKeys = map[string]string{
+	"dvchost": "identHostName",
+	"shost":   "srcHostName",
+	"suser":   "usrName",
+	"fname":   "resource",
+}
+
+// formatLEEF renders a LEEF 1.0 event with tab separated attributes.
+func formatLEEF(alert alerts.Alert, hostname string) string {
+	eventID := "alert"
+	if len(alert.MatchedRules) > 0 {
+		eventID = alert.MatchedRules[0]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:1.0|%s|%s|%s|%s|", eventVendor, eventProduct, eventVersion, cefHeader(eventID))
+	attrs := []string{
+		"devTime=" + alert.Timestamp.Format("Jan 02 2006 15:04:05"),
+		"devTimeFormat=MMM dd yyyy HH:mm:ss",
+		"sev=" + strconv.Itoa(cefSeverity(analyzer.SeverityLevel(alert.Severity))),
+		"cat=" + leefValue(alert.Severity),
+	}
+	ext := alertExtensions(alert, hostname)
+	for i, e := range ext {
+		if e.value == "" || e.key == "rt" || strings.HasSuffix(e.key, "Label") {
+			continue
+		}
+		key := e.key
+		if renamed, ok := leefKeys[key]; ok {
+			key = renamed
+		} else if strings.HasPrefix(key, "cs") && i > 0 && ext[i-1].key == key+"Label" {
+			key = ext[i-1].value
+		}
+		attrs = append(attrs, key+"="+leefValue(e.value))
+	}
+	b.WriteString(strings.Join(attrs, "\t"))
+	return b.String()
+}
+
+func leefValue(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
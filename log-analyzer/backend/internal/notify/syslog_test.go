@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/rules"
+)
+
+func TestClientTLSServerName(t *testing.T) {
+	tests := []struct {
+		address, want string
+	}{
+		{"siem.example.com:6514", "siem.example.com"},
+		{"192.0.2.10:6514", "192.0.2.10"},
+		{"[2001:db8::10]:6514", "2001:db8::10"},
+		{"siem.example.com", "siem.example.com"},
+	}
+	for _, tt := range tests {
+		config, err := clientTLSConfig(rules.SyslogOutput{Address: tt.address})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.ServerName != tt.want {
+			t.Errorf("ServerName for %s = %q, want %q", tt.address, config.ServerName, tt.want)
+		}
+	}
+}
+
+// syslogAlert has a character in every field that CEF or LEEF must escape.
+func syslogAlert() alerts.Alert {
+	return alerts.Alert{
+		ID:           "a1",
+		Timestamp:    time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		LogFile:      "/var/log/auth.log",
+		Line:         "Failed password for root from 203.0.113.7 port 22",
+		Summary:      "a|b=c\\d\nnext\tend",
+		MatchedRules: []string{"Parola|Deneme", "Diğer"},
+		Severity:     "yüksek",
+		Host:         "web",
+		Fields:       map[string]string{"src ip": "x=y"},
+		Count:        1,
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	got := formatCEF(syslogAlert(), "siem-host")
+	want := `CEF:0|log-analyzer|log-analyzer|1.0|Parola\|Deneme|a\|b=c\\d next` + "\tend|8|" +
+		`rt=1792317600000 msg=a|b\=c\\d\nnext` + "\tend" +
+		` fname=/var/log/auth.log dvchost=siem-host shost=web src=203.0.113.7 suser=root` +
+		` cs1Label=rules cs1=Parola|Deneme, Diğer cs2Label=alertId cs2=a1` +
+		` cs4Label=line cs4=Failed password for root from 203.0.113.7 port 22 src_ip=x\=y`
+	if got != want {
+		t.Errorf("CEF:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestFormatLEEF(t *testing.T) {
+	got := formatLEEF(syslogAlert(), "siem-host")
+	want := `LEEF:1.0|log-analyzer|log-analyzer|1.0|Parola\|Deneme|` + strings.Join([]string{
+		"devTime=Oct 18 2026 10:00:00",
+		"devTimeFormat=MMM dd yyyy HH:mm:ss",
+		"sev=8",
+		"cat=yüksek",
+		`msg=a|b=c\d next end`,
+		"resource=/var/log/auth.log",
+		"identHostName=siem-host",
+		"srcHostName=web",
+		"src=203.0.113.7",
+		"usrName=root",
+		"rules=Parola|Deneme, Diğer",
+		"alertId=a1",
+		"line=Failed password for root from 203.0.113.7 port 22",
+		"src_ip=x=y",
+	}, "\t")
+	if got != want {
+		t.Errorf("LEEF:\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/analyzer"
+	"log-analyzer/backend/internal/rules"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body, prefixed with
+// "sha256=", when the webhook has a secret.
+const SignatureHeader = "X-Log-Analyzer-Signature"
+
+type webhookSender struct {
+	config   rules.WebhookOutput
+	template *template.Template
+	client   *http.Client
+}
+
+func newWebhookSender(config rules.WebhookOutput) (*webhookSender, error) {
+	sender := &webhookSender{config: config, client: &http.Client{}}
+	if config.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(rules.TemplateFuncs).Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+		sender.template = tmpl
+	}
+	return sender, nil
+}
+
+func (s *webhookSender) Send(ctx context.Context, alert alerts.Alert) error {
+	body, err := s.body(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "log-analyzer")
+	for name, value := range s.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if s.config.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign([]byte(s.config.Secret), body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookSender) body(alert alerts.Alert) ([]byte, error) {
+	if s.template != nil {
+		var buf bytes.Buffer
+		if err := s.template.Execute(&buf, alert); err != nil {
+			return nil, fmt.Errorf("webhook template failed: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	switch s.config.Format {
+	case "slack", "mattermost":
+		fields := make([]map[string]interface{}, 0)
+		for _, f := range alertFacts(alert) {
+			fields = append(fields, map[string]interface{}{"title": f.name, "value": f.value, "short": f.short})
+		}
+		return json.Marshal(map[string]interface{}{
+			"text": alertTitle(alert),
+			"attachments": []map[string]interface{}{{
+				"color":  severityColor(alert.Severity),
+				"text":   alert.Summary,
+				"fields": fields,
+			}},
+		})
+	case "teams":
+		facts := make([]map[string]string, 0)
+		for _, f := range alertFacts(alert) {
+			facts = append(facts, map[string]string{"name": f.name, "value": f.value})
+		}
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"themeColor": strings.TrimPrefix(severityColor(alert.Severity), "#"),
+			"summary":    alertTitle(alert),
+			"title":      alertTitle(alert),
+			"text":       alert.Summary,
+			"sections":   []map[string]interface{}{{"facts": facts}},
+		})
+	default:
+		return json.Marshal(alert)
+	}
+}
+
+func alertTitle(alert alerts.Alert) string {
+	title := fmt.Sprintf("[%s] %s", strings.ToUpperSpecial(unicode.TurkishCase, alert.Severity), strings.Join(alert.MatchedRules, ", "))
+	if alert.Count > 1 {
+		title += fmt.Sprintf(" (%d kez)", alert.Count)
+	}
+	return title
+}
+
+type fact struct {
+	name, value string
+	short       bool
+}
+
+func alertFacts(alert alerts.Alert) []fact {
+	facts := []fact{
+		{"Kaynak", alert.Source, true},
+		{"Zaman", alert.Timestamp.Format("2006-01-02 15:04:05"), true},
+	}
+	if alert.Host != "" {
+		facts = append(facts, fact{"Sunucu", alert.Host, true})
+	}
+	return append(facts, fact{"Satır", alert.Line, false})
+}
+
+func severityColor(severity string) string {
+	switch analyzer.SeverityLevel(severity) {
+	case 4:
+		return "#dc2626"
+	case 3:
+		return "#f59e0b"
+	case 2:
+		return "#3b82f6"
+	case 1:
+		return "#10b981"
+	default:
+		return "#6b7280"
+	}
+}
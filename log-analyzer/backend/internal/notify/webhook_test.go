@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/rules"
+)
+
+// webhookServer fails the first failures requests with a 500 and records
+// every request body with its signature header.
+type webhookServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	failures   int
+	bodies     [][]byte
+	signatures []string
+}
+
+func newWebhookServer(t *testing.T, failures int) *webhookServer {
+	s := &webhookServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.bodies = append(s.bodies, body)
+		s.signatures = append(s.signatures, r.Header.Get(SignatureHeader))
+		if len(s.bodies) <= s.failures {
+			http.Error(w, "busy", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func webhookOutput(url, secret string, maxRetries int) rules.Output {
+	return rules.Output{
+		Name: "hook",
+		Type: "webhook",
+		Webhook: &rules.WebhookOutput{
+			URL:             url,
+			Secret:          secret,
+			DeliveryOptions: rules.DeliveryOptions{MaxRetries: &maxRetries, Backoff: "5ms", Timeout: "1s"},
+		},
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookRetriesUntilDelivered(t *testing.T) {
+	server := newWebhookServer(t, 2)
+	d := NewDispatcher([]rules.Output{webhookOutput(server.URL, "s3cret", 3)})
+	defer d.Close()
+
+	d.Notify(SampleAlert())
+	waitFor(t, "delivery", func() bool { return d.Statuses()[0].Sent == 1 })
+
+	status := d.Statuses()[0]
+	if server.requests() != 3 || status.Retried != 2 || status.Failed != 0 {
+		t.Errorf("requests = %d, status = %+v; want 3 requests, 2 retries, no failure", server.requests(), status)
+	}
+	if len(d.DeadLetters()) != 0 {
+		t.Errorf("delivered alert left %d dead letters", len(d.DeadLetters()))
+	}
+}
+
+func TestWebhookDeadLetterAfterRetries(t *testing.T) {
+	server := newWebhookServer(t, 100)
+	d := NewDispatcher([]rules.Output{webhookOutput(server.URL, "", 2)})
+	defer d.Close()
+
+	d.Notify(SampleAlert())
+	waitFor(t, "dead letter", func() bool { return len(d.DeadLetters()) == 1 })
+
+	letter := d.DeadLetters()[0]
+	if letter.Attempts != 3 || letter.Output != "hook" || letter.ID == "" {
+		t.Errorf("dead letter = %+v, want 3 attempts on hook with an ID", letter)
+	}
+	if server.requests() != 3 {
+		t.Errorf("requests = %d, want 3", server.requests())
+	}
+	if server.signatures[0] != "" {
+		t.Errorf("unsigned webhook sent signature %q", server.signatures[0])
+	}
+
+	// Retried dead letters go through the queue again.
+	server.mu.Lock()
+	server.failures = 0
+	server.mu.Unlock()
+	if n := d.RetryDeadLetters(letter.ID); n != 1 {
+		t.Fatalf("RetryDeadLetters = %d, want 1", n)
+	}
+	waitFor(t, "redelivery", func() bool { return d.Statuses()[0].Sent == 1 })
+}
+
+func TestWebhookSignature(t *testing.T) {
+	server := newWebhookServer(t, 0)
+	sender, err := newWebhookSender(*webhookOutput(server.URL, "s3cret", 0).Webhook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), SampleAlert()); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(server.bodies[0])
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); server.signatures[0] != want {
+		t.Errorf("signature = %q, want %q", server.signatures[0], want)
+	}
+	var sent alerts.Alert
+	if err := json.Unmarshal(server.bodies[0], &sent); err != nil || sent.ID != "test" {
+		t.Errorf("body is not the alert: %v %s", err, server.bodies[0])
+	}
+}
+
+func TestWebhookFormats(t *testing.T) {
+	tests := []struct {
+		format, template string
+		key              string
+	}{
+		{format: "slack", key: "attachments"},
+		{format: "teams", key: "sections"},
+		{template: `{"msg": {{json .Summary}}}`, key: "msg"},
+	}
+	for _, tt := range tests {
+		sender, err := newWebhookSender(rules.WebhookOutput{Format: tt.format, Template: tt.template})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		body, err := sender.body(SampleAlert())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		var decoded map[string]any
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			t.Fatalf("%s: body is not JSON: %v\n%s", tt.key, err, body)
+		}
+		if _, ok := decoded[tt.key]; !ok {
+			t.Errorf("%s body has no %q: %s", tt.format+tt.template, tt.key, body)
+		}
+	}
+}
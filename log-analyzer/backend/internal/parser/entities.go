@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Entity types.
+const (
+	EntityIP     = "ip"
+	EntityUser   = "user"
+	EntityEmail  = "email"
+	EntityURL    = "url"
+	EntityDomain = "domain"
+	EntityPath   = "path"
+	EntityHash   = "hash"
+)
+
+// EntityTypes lists the entity types in display order.
+var EntityTypes = []string{EntityIP, EntityUser, EntityEmail, EntityURL, EntityDomain, EntityPath, EntityHash}
+
+var (
+	lineIPs     = regexp.MustCompile(`\b(\d{1,3}(?:\.\d{1,3}){3})\b|(?:^|[^\w:])([0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}(?:(?:\.\d{1,3}){3})?)`)
+	lineUsers   = regexp.MustCompile(`\bfor (?:invalid user |illegal user )?([\w.@-]+)|\b(?:user|ruser|USER|logname)[= ]([\w.@-]+)|\bsu(?:\[\d+\])?: .*\(to ([\w.@-]+)\)|\bsudo(?:\[\d+\])?: +([\w.@-]+) :`)
+	lineEmails  = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@(?:[A-Za-z0-9-]+\.)+[A-Za-z]{2,}\b`)
+	lineURLs    = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s"'<>]+`)
+	lineDomains = regexp.MustCompile(`(?i)\b(?:[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{1,62}\b`)
+	linePaths   = regexp.MustCompile(`(?:^|[\s"'=(\[])(/(?:[\w.@+~-]+/)*[\w.@+~-]+/?|[A-Za-z]:\\(?:[^\\\s"'<>|]+\\)*[^\\\s"'<>|]+)`)
+	lineHashes  = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{128}|[0-9A-Fa-f]{64}|[0-9A-Fa-f]{40}|[0-9A-Fa-f]{32})\b`)
+)
+
+// fileExtensions are name endings the domain pattern would otherwise take
+// for top-level domains, as in "index.php" or "sshd.service".
+var fileExtensions = map[string]bool{
+	"php": true, "html": true, "htm": true, "js": true, "css": true, "log": true, "conf": true,
+	"cfg": true, "ini": true, "txt": true, "json": true, "xml": true, "yml": true, "yaml": true,
+	"jpg": true, "jpeg": true, "png": true, "gif": true, "svg": true, "ico": true, "woff": true,
+	"woff2": true, "map": true, "gz": true, "tgz": true, "tar": true, "zip": true, "bak": true,
+	"old": true, "swp": true, "tmp": true, "lock": true, "pid": true, "sock": true, "db": true,
+	"sql": true, "exe": true, "dll": true, "jsp": true, "asp": true, "aspx": true, "cgi": true,
+	"service": true, "socket": true, "timer": true, "mount": true, "target": true, "err": true,
+	"out": true,
+}
+
+// Entities are the values of interest found in a log line, by type. Each
+// list holds distinct values in order of appearance.
+type Entities struct {
+	IPs     []string `json:"ips,omitempty"`
+	Users   []string `json:"users,omitempty"`
+	Emails  []string `json:"emails,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+	Domains []string `json:"domains,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+	Hashes  []string `json:"hashes,omitempty"`
+}
+
+// Entity is a single typed value.
+type Entity struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// IsEntityType reports whether name is one of EntityTypes.
+func IsEntityType(name string) bool {
+	for _, typ := range EntityTypes {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractEntities finds the addresses, user names (in sshd, sudo, su and
+// PAM idioms), email addresses, URLs, domains, file paths and hex hashes
+// (MD5 to SHA-512) of text.
+func ExtractEntities(text string) Entities {
+	return Entities{
+		IPs:     extractIPs(text),
+		Users:   extractUsers(text),
+		Emails:  extractEmails(text),
+		URLs:    extractURLs(text),
+		Domains: extractDomains(text),
+		Paths:   extractPaths(text),
+		Hashes:  extractHashes(text),
+	}
+}
+
+// ExtractEntity finds the values of one entity type in text, running only
+// the pattern of that type. Unknown types give nil.
+func ExtractEntity(text, typ string) []string {
+	switch typ {
+	case EntityIP:
+		return extractIPs(text)
+	case EntityUser:
+		return extractUsers(text)
+	case EntityEmail:
+		return extractEmails(text)
+	case EntityURL:
+		return extractURLs(text)
+	case EntityDomain:
+		return extractDomains(text)
+	case EntityPath:
+		return extractPaths(text)
+	case EntityHash:
+		return extractHashes(text)
+	}
+	return nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func extractIPs(text string) []string {
+	var values []string
+	// IPv6 addresses may start or end with "::", where \b does not match,
+	// so their group is anchored on the character before them instead.
+	for _, m := range lineIPs.FindAllStringSubmatch(text, -1) {
+		value := m[1] + m[2]
+		if net.ParseIP(value) != nil {
+			values = appendUnique(values, value)
+		}
+	}
+	return values
+}
+
+func extractUsers(text string) []string {
+	var values []string
+	for _, m := range lineUsers.FindAllStringSubmatch(text, -1) {
+		for _, value := range m[1:] {
+			if value != "" {
+				values = appendUnique(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func extractEmails(text string) []string {
+	var values []string
+	for _, value := range lineEmails.FindAllString(text, -1) {
+		values = appendUnique(values, value)
+	}
+	return values
+}
+
+func extractURLs(text string) []string {
+	var values []string
+	for _, value := range lineURLs.FindAllString(text, -1) {
+		values = appendUnique(values, strings.TrimRight(value, ".,;:)]}"))
+	}
+	return values
+}
+
+func extractDomains(text string) []string {
+	var values []string
+	for _, value := range lineDomains.FindAllString(text, -1) {
+		value = strings.ToLower(value)
+		if !fileExtensions[value[strings.LastIndexByte(value, '.')+1:]] {
+			values = appendUnique(values, value)
+		}
+	}
+	return values
+}
+
+func extractPaths(text string) []string {
+	var values []string
+	for _, m := range linePaths.FindAllStringSubmatch(text, -1) {
+		values = appendUnique(values, m[1])
+	}
+	return values
+}
+
+func extractHashes(text string) []string {
+	var values []string
+	for _, value := range lineHashes.FindAllString(text, -1) {
+		values = appendUnique(values, strings.ToLower(value))
+	}
+	return values
+}
+
+// Empty reports whether no entity was found.
+func (e Entities) Empty() bool {
+	return len(e.IPs)+len(e.Users)+len(e.Emails)+len(e.URLs)+len(e.Domains)+len(e.Paths)+len(e.Hashes) == 0
+}
+
+// Values returns the values of one entity type.
+func (e Entities) Values(typ string) []string {
+	var values []string
+	switch typ {
+	case EntityIP:
+		values = e.IPs
+	case EntityUser:
+		values = e.Users
+	case EntityEmail:
+		values = e.Emails
+	case EntityURL:
+		values = e.URLs
+	case EntityDomain:
+		values = e.Domains
+	case EntityPath:
+		values = e.Paths
+	case EntityHash:
+		values = e.Hashes
+	}
+	// Callers may append; keep them off the shared backing array.
+	return values[:len(values):len(values)]
+}
+
+// All returns every entity, grouped by type in EntityTypes order.
+func (e Entities) All() []Entity {
+	var all []Entity
+	for _, typ := range EntityTypes {
+		for _, value := range e.Values(typ) {
+			all = append(all, Entity{Type: typ, Value: value})
+		}
+	}
+	return all
+}
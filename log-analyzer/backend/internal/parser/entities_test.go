@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractEntities(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want Entities
+	}{
+		{
+			name: "sshd",
+			line: "sshd[811]: Failed password for invalid user admin from 203.0.113.7 port 22 ssh2",
+			want: Entities{IPs: []string{"203.0.113.7"}, Users: []string{"admin"}},
+		},
+		{
+			name: "sudo",
+			line: "sudo:    alice : TTY=pts/0 ; PWD=/home/alice ; USER=root ; COMMAND=/usr/bin/id",
+			want: Entities{Users: []string{"alice", "root"}, Paths: []string{"/home/alice", "/usr/bin/id"}},
+		},
+		{
+			name: "access log",
+			line: `2001:db8::1 - - "GET http://Evil.Example/x.php?a=1 HTTP/1.1" 200 "mail admin@example.org"`,
+			want: Entities{
+				IPs:     []string{"2001:db8::1"},
+				Emails:  []string{"admin@example.org"},
+				URLs:    []string{"http://Evil.Example/x.php?a=1"},
+				Domains: []string{"evil.example", "example.org"},
+			},
+		},
+		{
+			name: "hash and file names",
+			line: "clamd: /var/www/upload/shell.php sha1=DA39A3EE5E6B4B0D3255BFEF95601890AFD80709 in index.html",
+			want: Entities{
+				Paths:  []string{"/var/www/upload/shell.php"},
+				Hashes: []string{"da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+			},
+		},
+		{
+			name: "loopback and link-local",
+			line: "sshd[811]: Connection from ::1 port 4711, listening on fe80:: and [::1]:22",
+			want: Entities{IPs: []string{"::1", "fe80::"}},
+		},
+		{
+			name: "mapped address",
+			line: "client ::ffff:203.0.113.9 at 10:00:00 via 00:1a:2b:3c:4d:5e",
+			want: Entities{IPs: []string{"::ffff:203.0.113.9"}},
+		},
+		{
+			name: "repeats",
+			line: "203.0.113.7 203.0.113.7 999.1.1.1",
+			want: Entities{IPs: []string{"203.0.113.7"}},
+		},
+		{name: "nothing", line: "disk full"},
+	}
+	for _, tt := range tests {
+		got := ExtractEntities(tt.line)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+		if got.Empty() != (tt.name == "nothing") {
+			t.Errorf("%s: Empty = %v", tt.name, got.Empty())
+		}
+		for _, typ := range EntityTypes {
+			one, want := ExtractEntity(tt.line, typ), tt.want.Values(typ)
+			if len(one) != len(want) || len(one) > 0 && !reflect.DeepEqual(one, want) {
+				t.Errorf("%s: ExtractEntity(%s) = %v, want %v", tt.name, typ, one, want)
+			}
+		}
+	}
+}
+
+func TestEntityValuesCaches(t *testing.T) {
+	entry := NewEntry("Failed password for root from 203.0.113.7 port 22")
+	ips := entry.EntityValues(EntityIP)
+	if !reflect.DeepEqual(ips, []string{"203.0.113.7"}) {
+		t.Fatalf("ips = %v", ips)
+	}
+	if entry.entities != nil {
+		t.Error("looking up one type extracted all of them")
+	}
+	_ = append(ips, "198.51.100.1")
+	if again := entry.EntityValues(EntityIP); len(again) != 1 {
+		t.Errorf("appending to the result changed the cache: %v", again)
+	}
+	entry.Entities()
+	if users := entry.EntityValues(EntityUser); !reflect.DeepEqual(users, []string{"root"}) {
+		t.Errorf("users = %v", users)
+	}
+}
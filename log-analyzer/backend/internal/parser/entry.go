@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+// Entry is the structured record rules are matched against. Plain log files
+// only fill Line; structured sources (syslog, journal) also fill the named
+// fields and keep their original key/value pairs in Fields.
+type Entry struct {
+	Timestamp time.Time
+	Host      string
+	Program   string
+	Unit      string
+	PID       string
+	Message   string
+	Line      string
+	Fields    map[string]string
+	entities  *Entities
+	// values caches entity types extracted one at a time, before all of
+	// them were needed.
+	values map[string][]string
+}
+
+func NewEntry(line string) *Entry {
+	return &Entry{Line: line}
+}
+
+// Entities returns the entities of the line, extracting them on first use.
+func (e *Entry) Entities() Entities {
+	if e.entities == nil {
+		entities := ExtractEntities(e.Line)
+		e.entities = &entities
+	}
+	return *e.entities
+}
+
+// EntityValues returns the values of one entity type in the line. Unless
+// all entities were extracted already, only the pattern of that type runs.
+func (e *Entry) EntityValues(typ string) []string {
+	if e.entities != nil {
+		return e.entities.Values(typ)
+	}
+	values, ok := e.values[typ]
+	if !ok {
+		values = ExtractEntity(e.Line, typ)
+		if e.values == nil {
+			e.values = make(map[string][]string)
+		}
+		e.values[typ] = values
+	}
+	// Callers may append; keep them off the cached backing array.
+	return values[:len(values):len(values)]
+}
+
+// Field returns a named value for rules with a `field` selector. Unknown
+// names are looked up in the raw source fields.
+func (e *Entry) Field(name string) string {
+	switch strings.ToLower(name) {
+	case "", "line":
+		return e.Line
+	case "message":
+		if e.Message == "" {
+			return e.Line
+		}
+		return e.Message
+	case "host":
+		return e.Host
+	case "program":
+		return e.Program
+	case "unit":
+		return e.Unit
+	case "pid":
+		return e.PID
+	}
+	return e.Fields[name]
+}
+
+// Attributes returns the non-empty named fields for display on alerts.
+func (e *Entry) Attributes() map[string]string {
+	attrs := make(map[string]string)
+	for key, value := range map[string]string{
+		"host":    e.Host,
+		"program": e.Program,
+		"unit":    e.Unit,
+		"pid":     e.PID,
+	} {
+		if value != "" {
+			attrs[key] = value
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+// SyslogLine renders a structured entry in the classic syslog file layout so
+// rules written for /var/log files keep matching it.
+func (e *Entry) SyslogLine() string {
+	var b strings.Builder
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	b.WriteString(ts.Format(time.Stamp))
+	if e.Host != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.Host)
+	}
+	program := e.Program
+	if program == "" {
+		program = strings.TrimSuffix(e.Unit, ".service")
+	}
+	if program != "" {
+		b.WriteByte(' ')
+		b.WriteString(program)
+		if e.PID != "" {
+			b.WriteString("[" + e.PID + "]")
+		}
+		b.WriteByte(':')
+	}
+	if e.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(e.Message)
+	}
+	return b.String()
+}
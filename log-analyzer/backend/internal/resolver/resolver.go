@@ -0,0 +1,362 @@
+// Package resolver enriches alerts with DNS names. Lookups go through a
+// bounded TTL cache and run on background workers, so callers never wait
+// for the network.
+package resolver
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+const (
+	queueSize = 1000
+	// maxQueries bounds the lookups made for one line.
+	maxQueries = 5
+	// maxAddresses bounds the addresses kept for a forward lookup.
+	maxAddresses = 4
+)
+
+// accessClient matches the client host name at the start of a Common or
+// Combined Log Format line, as logged with HostnameLookups.
+var accessClient = regexp.MustCompile(`^((?:[A-Za-z0-9_](?:[A-Za-z0-9_-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z][A-Za-z0-9-]*) \S+ \S+ \[`)
+
+type kind int
+
+const (
+	reverse kind = iota
+	forward
+)
+
+type query struct {
+	kind  kind
+	value string
+}
+
+func (q query) key() string {
+	if q.kind == reverse {
+		return "ptr:" + q.value
+	}
+	return "host:" + q.value
+}
+
+type cacheEntry struct {
+	key     string
+	names   []string
+	expires time.Time
+}
+
+// call is a lookup in flight that later callers for the same name wait on.
+type call struct {
+	done  chan struct{}
+	names []string
+}
+
+type job struct {
+	queries []query
+	done    func(map[string]string)
+}
+
+// Stats counts cache use and lookups.
+type Stats struct {
+	Hits     int `json:"hits"`
+	Misses   int `json:"misses"`
+	Failures int `json:"failures"`
+	Dropped  int `json:"dropped"`
+	Cached   int `json:"cached"`
+}
+
+// Resolver looks up the PTR names of addresses and the addresses of host
+// names. Failed lookups are cached for the negative TTL.
+type Resolver struct {
+	config      rules.DNS
+	resolver    *net.Resolver
+	timeout     time.Duration
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	pending map[string]*call
+	stats   Stats
+
+	jobs chan job
+	stop chan struct{}
+}
+
+// New starts a resolver for config, or returns nil when config is nil. A
+// nil resolver finds nothing.
+func New(config *rules.DNS) *Resolver {
+	if config == nil {
+		return nil
+	}
+	r := &Resolver{
+		config:   *config,
+		resolver: net.DefaultResolver,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		pending:  make(map[string]*call),
+		jobs:     make(chan job, queueSize),
+		stop:     make(chan struct{}),
+	}
+	r.timeout, r.ttl, r.negativeTTL = config.Timings()
+	if server := config.Server; server != "" {
+		r.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	for i := 0; i < config.Workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+// Close stops the workers; queued lookups are abandoned.
+func (r *Resolver) Close() {
+	if r != nil {
+		close(r.stop)
+	}
+}
+
+func (r *Resolver) work() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		case j := <-r.jobs:
+			results := make(map[query][]string, len(j.queries))
+			for _, q := range j.queries {
+				results[q] = r.resolve(q)
+			}
+			if fields := toFields(j.queries, results); len(fields) > 0 {
+				j.done(fields)
+			}
+		}
+	}
+}
+
+// Cached returns the alert fields for line that can be answered from the
+// cache: "rdns" with the names of its addresses and "resolved_ip" with the
+// addresses of its client host. complete is false when some answers are
+// not cached yet.
+func (r *Resolver) Cached(line string) (fields map[string]string, complete bool) {
+	if r == nil {
+		return nil, true
+	}
+	queries := r.queries(line)
+	results := make(map[query][]string, len(queries))
+	complete = true
+	for _, q := range queries {
+		if names, ok := r.cached(q.key()); ok {
+			results[q] = names
+		} else {
+			complete = false
+		}
+	}
+	return toFields(queries, results), complete
+}
+
+// Resolve looks up the names of line in the background and passes the
+// fields found to done. When the queue is full the lookup is dropped.
+func (r *Resolver) Resolve(line string, done func(map[string]string)) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.jobs <- job{queries: r.queries(line), done: done}:
+	default:
+		r.mu.Lock()
+		r.stats.Dropped++
+		r.mu.Unlock()
+	}
+}
+
+// Stats returns the counters and current cache size.
+func (r *Resolver) Stats() Stats {
+	if r == nil {
+		return Stats{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats
+	stats.Cached = r.order.Len()
+	return stats
+}
+
+func (r *Resolver) queries(line string) []query {
+	var queries []query
+	seen := make(map[string]bool)
+	if r.config.Reverse {
+		for _, ip := range rules.FieldValues(parser.NewEntry(line), "ip") {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil || addr.IsUnspecified() || addr.IsLoopback() || addr.IsMulticast() {
+				continue
+			}
+			ip = addr.Unmap().String()
+			if !seen[ip] && len(queries) < maxQueries {
+				seen[ip] = true
+				queries = append(queries, query{kind: reverse, value: ip})
+			}
+		}
+	}
+	if r.config.Forward {
+		if m := accessClient.FindStringSubmatch(line); m != nil {
+			queries = append(queries, query{kind: forward, value: strings.ToLower(m[1])})
+		}
+	}
+	return queries
+}
+
+func toFields(queries []query, results map[query][]string) map[string]string {
+	var names, addresses []string
+	for _, q := range queries {
+		found := results[q]
+		if len(found) == 0 {
+			continue
+		}
+		if q.kind == reverse {
+			names = appendUnique(names, found[0])
+		} else {
+			for _, address := range found {
+				addresses = appendUnique(addresses, address)
+			}
+		}
+	}
+	fields := make(map[string]string)
+	if len(names) > 0 {
+		fields["rdns"] = strings.Join(names, ", ")
+	}
+	if len(addresses) > 0 {
+		fields["resolved_ip"] = strings.Join(addresses, ", ")
+	}
+	return fields
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// cached returns a cached answer and counts the hit or miss; a cached
+// failure is an empty answer.
+func (r *Resolver) cached(key string) ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names, ok := r.get(key)
+	if ok {
+		r.stats.Hits++
+	} else {
+		r.stats.Misses++
+	}
+	return names, ok
+}
+
+// get looks key up in the cache; r.mu must be held.
+func (r *Resolver) get(key string) ([]string, bool) {
+	element, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		r.order.Remove(element)
+		delete(r.entries, key)
+		return nil, false
+	}
+	r.order.MoveToFront(element)
+	return entry.names, true
+}
+
+func (r *Resolver) store(key string, names []string, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := &cacheEntry{key: key, names: names, expires: time.Now().Add(ttl)}
+	if element, ok := r.entries[key]; ok {
+		element.Value = entry
+		r.order.MoveToFront(element)
+		return
+	}
+	r.entries[key] = r.order.PushFront(entry)
+	for r.order.Len() > r.config.CacheSize {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// resolve answers q from the cache or the network. Concurrent lookups of
+// the same name share one query.
+func (r *Resolver) resolve(q query) []string {
+	key := q.key()
+	r.mu.Lock()
+	if names, ok := r.get(key); ok {
+		r.mu.Unlock()
+		return names
+	}
+	if c, ok := r.pending[key]; ok {
+		r.mu.Unlock()
+		<-c.done
+		return c.names
+	}
+	c := &call{done: make(chan struct{})}
+	r.pending[key] = c
+	r.mu.Unlock()
+
+	c.names = r.query(q)
+	ttl := r.ttl
+	if len(c.names) == 0 {
+		ttl = r.negativeTTL
+	}
+	r.store(key, c.names, ttl)
+
+	r.mu.Lock()
+	delete(r.pending, key)
+	r.mu.Unlock()
+	close(c.done)
+	return c.names
+}
+
+func (r *Resolver) query(q query) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var names []string
+	var err error
+	if q.kind == reverse {
+		names, err = r.resolver.LookupAddr(ctx, q.value)
+		for i := range names {
+			names[i] = strings.ToLower(strings.TrimSuffix(names[i], "."))
+		}
+	} else {
+		names, err = r.resolver.LookupHost(ctx, q.value)
+		sort.Strings(names)
+		if len(names) > maxAddresses {
+			names = names[:maxAddresses]
+		}
+	}
+	if err != nil {
+		r.mu.Lock()
+		r.stats.Failures++
+		r.mu.Unlock()
+		return nil
+	}
+	return names
+}
@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/rules"
+)
+
+// offline makes every network lookup of r fail.
+func offline(r *Resolver) {
+	r.resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(context.Context, string, string) (net.Conn, error) {
+			return nil, errors.New("offline")
+		},
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	r := New(&rules.DNS{Reverse: true, CacheSize: 2})
+	defer r.Close()
+
+	r.store("ptr:a", []string{"a.example"}, time.Minute)
+	r.store("ptr:b", []string{"b.example"}, time.Minute)
+	if _, ok := r.cached("ptr:a"); !ok {
+		t.Fatal("ptr:a is not cached")
+	}
+	r.store("ptr:c", []string{"c.example"}, time.Minute)
+
+	if _, ok := r.cached("ptr:b"); ok {
+		t.Error("ptr:b, the least recently used entry, was kept")
+	}
+	for _, key := range []string{"ptr:a", "ptr:c"} {
+		if _, ok := r.cached(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	if stats := r.Stats(); stats.Cached != 2 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 2 cached, 3 hits, 1 miss", stats)
+	}
+
+	// Storing a cached key again refreshes it instead of adding an entry.
+	r.store("ptr:a", []string{"a2.example"}, time.Minute)
+	if names, _ := r.cached("ptr:a"); !reflect.DeepEqual(names, []string{"a2.example"}) || r.Stats().Cached != 2 {
+		t.Errorf("ptr:a = %v with %d cached", names, r.Stats().Cached)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	r := New(&rules.DNS{Reverse: true, CacheSize: 10})
+	defer r.Close()
+
+	r.store("ptr:old", []string{"old.example"}, -time.Second)
+	r.store("ptr:new", []string{"new.example"}, time.Minute)
+	if _, ok := r.cached("ptr:old"); ok {
+		t.Error("an expired entry was returned")
+	}
+	if _, ok := r.cached("ptr:new"); !ok {
+		t.Error("a fresh entry was not returned")
+	}
+	if stats := r.Stats(); stats.Cached != 1 {
+		t.Errorf("%d entries cached, want the expired one removed", stats.Cached)
+	}
+}
+
+func TestFailuresCachedForNegativeTTL(t *testing.T) {
+	r := New(&rules.DNS{Reverse: true, CacheSize: 10, Timeout: "200ms", NegativeTTL: "50ms"})
+	defer r.Close()
+	offline(r)
+
+	q := query{kind: reverse, value: "203.0.113.7"}
+	if names := r.resolve(q); names != nil {
+		t.Fatalf("offline lookup found %v", names)
+	}
+	r.resolve(q)
+	if stats := r.Stats(); stats.Failures != 1 {
+		t.Errorf("%d failed lookups, want the second answered from the cache", stats.Failures)
+	}
+	if names, ok := r.cached(q.key()); !ok || names != nil {
+		t.Errorf("cached failure = %v, %v", names, ok)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	r.resolve(q)
+	if stats := r.Stats(); stats.Failures != 2 {
+		t.Errorf("%d failed lookups, want a new lookup after the negative TTL", stats.Failures)
+	}
+}
+
+func TestCachedAndResolve(t *testing.T) {
+	r := New(&rules.DNS{Reverse: true, Forward: true, CacheSize: 10, Workers: 1})
+	defer r.Close()
+	offline(r)
+
+	line := `scanner.example - - [18/Oct/2026:10:00:00 +0000] "GET / HTTP/1.1" 404 0 from 203.0.113.7 via 198.51.100.2`
+	r.store("ptr:203.0.113.7", []string{"scan.example.net"}, time.Minute)
+	r.store("host:scanner.example", []string{"203.0.113.7"}, time.Minute)
+
+	fields, complete := r.Cached(line)
+	if complete {
+		t.Error("complete although 198.51.100.2 is not cached")
+	}
+	want := map[string]string{"rdns": "scan.example.net", "resolved_ip": "203.0.113.7"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("cached fields = %v, want %v", fields, want)
+	}
+
+	done := make(chan map[string]string, 1)
+	r.Resolve(line, func(fields map[string]string) { done <- fields })
+	select {
+	case fields := <-done:
+		if !reflect.DeepEqual(fields, want) {
+			t.Errorf("resolved fields = %v, want %v", fields, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Resolve did not call back")
+	}
+	if _, complete := r.Cached(line); !complete {
+		t.Error("the failed lookup was not cached")
+	}
+
+	var nilResolver *Resolver
+	if fields, complete := nilResolver.Cached(line); fields != nil || !complete {
+		t.Errorf("nil resolver = %v, %v", fields, complete)
+	}
+}
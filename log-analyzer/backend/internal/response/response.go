@@ -0,0 +1,646 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/ids"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+const (
+	queueSize      = 256
+	maxLogEntries  = 2000
+	defaultTimeout = 30 * time.Second
+	expiryInterval = 5 * time.Second
+	undoRetryDelay = time.Minute
+)
+
+// Results of a logged run.
+const (
+	ResultOK          = "ok"
+	ResultFailed      = "failed"
+	ResultDryRun      = "dry_run"
+	ResultRateLimited = "rate_limited"
+)
+
+// Event is what action templates see, e.g. {{.Target}} or {{.Rule}}.
+type Event struct {
+	Kind     string            `json:"event"`
+	Action   string            `json:"action"`
+	Target   string            `json:"target"`
+	Rule     string            `json:"rule,omitempty"`
+	Severity string            `json:"severity,omitempty"`
+	AlertID  string            `json:"alertId,omitempty"`
+	Host     string            `json:"host,omitempty"`
+	LogFile  string            `json:"logFile,omitempty"`
+	Line     string            `json:"line,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Time     time.Time         `json:"time"`
+	Until    *time.Time        `json:"until,omitempty"`
+}
+
+// Block is an action in effect for a target. Until is nil for actions
+// without a TTL, which stay until undone by hand.
+type Block struct {
+	Action  string     `json:"action"`
+	Target  string     `json:"target"`
+	Rule    string     `json:"rule,omitempty"`
+	AlertID string     `json:"alertId,omitempty"`
+	Since   time.Time  `json:"since"`
+	Until   *time.Time `json:"until,omitempty"`
+	DryRun  bool       `json:"dryRun,omitempty"`
+	pending bool
+}
+
+// Entry is one line of the action log.
+type Entry struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Type    string    `json:"type"`
+	Kind    string    `json:"kind"`
+	Target  string    `json:"target"`
+	Rule    string    `json:"rule,omitempty"`
+	AlertID string    `json:"alertId,omitempty"`
+	Actor   string    `json:"actor"`
+	Result  string    `json:"result"`
+	Error   string    `json:"error,omitempty"`
+	Output  string    `json:"output,omitempty"`
+}
+
+type action struct {
+	config  rules.Action
+	ttl     time.Duration
+	timeout time.Duration
+	ignore  []*net.IPNet
+	per     time.Duration
+	runs    []time.Time
+	// limited holds the targets refused since the limit was last free, so
+	// a flood logs each of them once.
+	limited map[string]bool
+}
+
+func newAction(config rules.Action) *action {
+	a := &action{
+		config:  config,
+		timeout: defaultTimeout,
+	}
+	a.config.Target = strings.ToLower(config.Target)
+	if a.config.Target == "" {
+		a.config.Target = "ip"
+	}
+	a.ttl, _ = time.ParseDuration(config.TTL)
+	if timeout, err := time.ParseDuration(config.Timeout); err == nil && timeout > 0 {
+		a.timeout = timeout
+	}
+	for _, entry := range config.Ignore {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			a.ignore = append(a.ignore, network)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			a.ignore = append(a.ignore, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		}
+	}
+	if config.RateLimit != nil {
+		a.per, _ = time.ParseDuration(config.RateLimit.Per)
+	}
+	return a
+}
+
+// ignored reports whether the action must leave target alone.
+func (a *action) ignored(target string) bool {
+	if a.config.Target != "ip" {
+		for _, entry := range a.config.Ignore {
+			if entry == target {
+				return true
+			}
+		}
+		return false
+	}
+	ip := net.ParseIP(target)
+	for _, network := range a.ignore {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allow records a run unless the rate limit is used up.
+func (a *action) allow(now time.Time) bool {
+	if a.config.RateLimit == nil {
+		return true
+	}
+	kept := a.runs[:0]
+	for _, run := range a.runs {
+		if now.Sub(run) < a.per {
+			kept = append(kept, run)
+		}
+	}
+	a.runs = kept
+	if len(a.runs) >= a.config.RateLimit.Max {
+		return false
+	}
+	a.runs = append(a.runs, now)
+	a.limited = nil
+	return true
+}
+
+// ValidTarget rejects targets that are unsafe to pass to commands or files:
+// IP targets must parse, others must be a single printable word that does
+// not look like an option.
+func ValidTarget(field, target string) error {
+	if field == "ip" {
+		if net.ParseIP(target) == nil {
+			return fmt.Errorf("invalid ip %q", target)
+		}
+		return nil
+	}
+	if target == "" || len(target) > 256 || strings.HasPrefix(target, "-") {
+		return fmt.Errorf("invalid target %q", target)
+	}
+	for _, r := range target {
+		if r <= ' ' || r == 0x7f {
+			return fmt.Errorf("invalid target %q", target)
+		}
+	}
+	return nil
+}
+
+type job struct {
+	action *action
+	event  Event
+}
+
+// Responder runs the response actions of live alerts. Actions run one at a
+// time on a worker so a slow command never holds up the alert pipeline.
+type Responder struct {
+	mu          sync.Mutex
+	runMu       sync.Mutex
+	actions     map[string]*action
+	names       []string
+	ruleActions map[string][]string
+	active      map[string]*Block
+	log         []Entry
+	dirty       bool
+	queue       chan job
+	changes     chan struct{}
+	stop        chan struct{}
+	workers     sync.WaitGroup
+}
+
+// ErrNotFound is returned for unknown actions and targets without a block.
+var ErrNotFound = errors.New("not found")
+
+func NewResponder(actions []rules.Action, ruleActions map[string][]string) *Responder {
+	r := &Responder{
+		actions:     make(map[string]*action),
+		ruleActions: ruleActions,
+		active:      make(map[string]*Block),
+		queue:       make(chan job, queueSize),
+		changes:     make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+	for _, config := range actions {
+		r.actions[config.Name] = newAction(config)
+		r.names = append(r.names, config.Name)
+	}
+	r.workers.Add(2)
+	go r.run()
+	go r.expire()
+	return r
+}
+
+func blockKey(action, target string) string {
+	return action + "\x00" + target
+}
+
+// Handle queues the actions of the rules an alert matched, once per target
+// that is not blocked yet. Grouped alerts are handed in again as they grow
+// so actions with a min_count fire once the count is reached.
+func (r *Responder) Handle(alert alerts.Alert) {
+	now := time.Now()
+	entry := parser.NewEntry(alert.Line)
+	for _, ruleName := range alert.MatchedRules {
+		for _, name := range r.ruleActions[ruleName] {
+			a, ok := r.actions[name]
+			if !ok || alert.Count < a.config.MinCount {
+				continue
+			}
+			target, ok := actionTarget(alert, ruleName, entry, a.config)
+			if !ok || ValidTarget(a.config.Target, target) != nil || a.ignored(target) {
+				continue
+			}
+			event := newEvent(alert, ruleName, name, target, now)
+			r.mu.Lock()
+			if _, blocked := r.active[blockKey(name, target)]; blocked {
+				r.mu.Unlock()
+				continue
+			}
+			if !a.allow(now) {
+				if !a.limited[target] {
+					if a.limited == nil {
+						a.limited = make(map[string]bool)
+					}
+					a.limited[target] = true
+					r.record(a, event, "system", ResultRateLimited, nil, "")
+				}
+				r.mu.Unlock()
+				continue
+			}
+			r.active[blockKey(name, target)] = &Block{Action: name, Target: target, Rule: ruleName, AlertID: alert.ID, Since: now, pending: true}
+			r.mu.Unlock()
+
+			select {
+			case r.queue <- job{a, event}:
+			default:
+				r.mu.Lock()
+				delete(r.active, blockKey(name, target))
+				r.record(a, event, "system", ResultFailed, fmt.Errorf("queue full"), "")
+				r.mu.Unlock()
+			}
+		}
+	}
+}
+
+// actionTarget picks the one value an action acts on. With target_field
+// set only that alert field is used. Otherwise a field named like the
+// target type ("ip") is used, and failing that the line itself, but only
+// when it holds exactly one such value: a line naming several addresses,
+// such as a proxy log with client and upstream, does not say which one is
+// the attacker. The actions of an IOC feed act on the value that matched
+// the feed, and on nothing when several values of the type did.
+func actionTarget(alert alerts.Alert, rule string, entry *parser.Entry, config rules.Action) (string, bool) {
+	if config.TargetField != "" {
+		value := alert.Fields[config.TargetField]
+		return value, value != ""
+	}
+	if rules.IsIntelRule(rule) {
+		value := alert.Fields[rules.IntelField(config.Target)]
+		if strings.Contains(value, ", ") {
+			return "", false
+		}
+		return value, value != ""
+	}
+	if value := alert.Fields[config.Target]; value != "" {
+		return value, true
+	}
+	target := ""
+	for _, value := range rules.FieldValues(entry, config.Target) {
+		if target != "" && value != target {
+			return "", false
+		}
+		target = value
+	}
+	return target, target != ""
+}
+
+func newEvent(alert alerts.Alert, rule, action, target string, now time.Time) Event {
+	return Event{
+		Kind:     "block",
+		Action:   action,
+		Target:   target,
+		Rule:     rule,
+		Severity: alert.Severity,
+		AlertID:  alert.ID,
+		Host:     alert.Host,
+		LogFile:  alert.LogFile,
+		Line:     alert.Line,
+		Fields:   alert.Fields,
+		Time:     now,
+	}
+}
+
+func (r *Responder) run() {
+	defer r.workers.Done()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case j := <-r.queue:
+			if j.event.Kind == "unblock" {
+				r.unblock(j.action, j.event, "system")
+			} else {
+				r.block(j.action, j.event, "system")
+			}
+			select {
+			case r.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Changes signals after every automatic block and unblock, so the state can
+// be saved right away instead of on the next periodic save.
+func (r *Responder) Changes() <-chan struct{} {
+	return r.changes
+}
+
+// block runs an action for its target and keeps the block until its TTL
+// expires. A failed run is logged and forgotten, so a later alert tries
+// again.
+func (r *Responder) block(a *action, event Event, actor string) (Entry, error) {
+	if a.ttl > 0 {
+		until := event.Time.Add(a.ttl)
+		event.Until = &until
+	}
+	output, err := r.execute(a, event)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := blockKey(a.config.Name, event.Target)
+	result := ResultOK
+	switch {
+	case err != nil:
+		result = ResultFailed
+		delete(r.active, key)
+	case a.config.DryRun:
+		result = ResultDryRun
+	}
+	if err == nil {
+		r.active[key] = &Block{
+			Action:  a.config.Name,
+			Target:  event.Target,
+			Rule:    event.Rule,
+			AlertID: event.AlertID,
+			Since:   event.Time,
+			Until:   event.Until,
+			DryRun:  a.config.DryRun,
+		}
+	}
+	return r.record(a, event, actor, result, err, output), err
+}
+
+// unblock undoes an action; on failure the block stays and is tried again
+// a minute later.
+func (r *Responder) unblock(a *action, event Event, actor string) (Entry, error) {
+	output, err := r.execute(a, event)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := blockKey(a.config.Name, event.Target)
+	result := ResultOK
+	switch {
+	case err != nil:
+		result = ResultFailed
+		if block, ok := r.active[key]; ok {
+			retry := time.Now().Add(undoRetryDelay)
+			block.Until = &retry
+			block.pending = false
+		}
+	case a.config.DryRun:
+		result = ResultDryRun
+		delete(r.active, key)
+	default:
+		delete(r.active, key)
+	}
+	return r.record(a, event, actor, result, err, output), err
+}
+
+func (r *Responder) execute(a *action, event Event) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	return run(ctx, a.config, event, a.config.DryRun)
+}
+
+// record appends to the action log; r.mu must be held.
+func (r *Responder) record(a *action, event Event, actor, result string, err error, output string) Entry {
+	entry := Entry{
+		ID:      ids.New(),
+		Time:    time.Now(),
+		Action:  a.config.Name,
+		Type:    a.config.Type,
+		Kind:    event.Kind,
+		Target:  event.Target,
+		Rule:    event.Rule,
+		AlertID: event.AlertID,
+		Actor:   actor,
+		Result:  result,
+		Output:  output,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		log.Printf("Action %s failed to %s %s: %v", a.config.Name, event.Kind, event.Target, err)
+	}
+	r.log = append(r.log, entry)
+	if len(r.log) > maxLogEntries {
+		r.log = r.log[len(r.log)-maxLogEntries:]
+	}
+	r.dirty = true
+	return entry
+}
+
+// expire queues the undo of blocks whose TTL ran out.
+func (r *Responder) expire() {
+	defer r.workers.Done()
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			var due []job
+			for key, block := range r.active {
+				if block.pending || block.Until == nil || block.Until.After(now) {
+					continue
+				}
+				a, ok := r.actions[block.Action]
+				if !ok {
+					delete(r.active, key)
+					r.dirty = true
+					continue
+				}
+				block.pending = true
+				due = append(due, job{a, unblockEvent(block, now)})
+			}
+			r.mu.Unlock()
+			for _, j := range due {
+				select {
+				case r.queue <- j:
+				default:
+					r.mu.Lock()
+					if block, ok := r.active[blockKey(j.event.Action, j.event.Target)]; ok {
+						block.pending = false
+					}
+					r.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+func unblockEvent(block *Block, now time.Time) Event {
+	return Event{
+		Kind:    "unblock",
+		Action:  block.Action,
+		Target:  block.Target,
+		Rule:    block.Rule,
+		AlertID: block.AlertID,
+		Time:    now,
+	}
+}
+
+// Block runs an action for a target by hand and reports the logged result.
+// It ignores min_count and the rate limit but not the ignore list.
+func (r *Responder) Block(name, target, actor string) (Entry, error) {
+	a, ok := r.actions[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("action %s: %w", name, ErrNotFound)
+	}
+	if err := ValidTarget(a.config.Target, target); err != nil {
+		return Entry{}, err
+	}
+	if a.ignored(target) {
+		return Entry{}, fmt.Errorf("target %s is on the ignore list of %s", target, name)
+	}
+	key := blockKey(name, target)
+	r.mu.Lock()
+	if _, blocked := r.active[key]; blocked {
+		r.mu.Unlock()
+		return Entry{}, fmt.Errorf("%s is already blocked by %s", target, name)
+	}
+	now := time.Now()
+	r.active[key] = &Block{Action: name, Target: target, Since: now, pending: true}
+	r.mu.Unlock()
+	return r.block(a, Event{Kind: "block", Action: name, Target: target, Time: now}, actor)
+}
+
+// Unblock undoes an action for a target by hand.
+func (r *Responder) Unblock(name, target, actor string) (Entry, error) {
+	a, ok := r.actions[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("action %s: %w", name, ErrNotFound)
+	}
+	r.mu.Lock()
+	block, ok := r.active[blockKey(name, target)]
+	if !ok || block.pending {
+		r.mu.Unlock()
+		return Entry{}, fmt.Errorf("block of %s by %s: %w", target, name, ErrNotFound)
+	}
+	block.pending = true
+	event := unblockEvent(block, time.Now())
+	r.mu.Unlock()
+	return r.unblock(a, event, actor)
+}
+
+// Actions returns the configured actions.
+func (r *Responder) Actions() []rules.Action {
+	result := make([]rules.Action, 0, len(r.names))
+	for _, name := range r.names {
+		result = append(result, r.actions[name].config)
+	}
+	return result
+}
+
+// Active returns the blocks in effect, oldest first.
+func (r *Responder) Active() []Block {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]Block, 0, len(r.active))
+	for _, block := range r.active {
+		result = append(result, *block)
+	}
+	sortBlocks(result)
+	return result
+}
+
+// Filter selects log entries. Zero values match everything.
+type Filter struct {
+	Action string
+	Kind   string
+	Target string
+	Result string
+	Limit  int
+}
+
+// Log returns matching log entries, newest first.
+func (r *Responder) Log(filter Filter) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := []Entry{}
+	for i := len(r.log) - 1; i >= 0; i-- {
+		entry := r.log[i]
+		if (filter.Action != "" && entry.Action != filter.Action) ||
+			(filter.Kind != "" && entry.Kind != filter.Kind) ||
+			(filter.Target != "" && entry.Target != filter.Target) ||
+			(filter.Result != "" && entry.Result != filter.Result) {
+			continue
+		}
+		result = append(result, entry)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+type state struct {
+	Active []Block `json:"active"`
+	Log    []Entry `json:"log"`
+}
+
+// Marshal encodes the blocks and the log if they changed since the last
+// call. Blocks whose first run is still queued are left out.
+func (r *Responder) Marshal() ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil, false, nil
+	}
+	saved := state{Active: []Block{}, Log: r.log}
+	for _, block := range r.active {
+		if !block.pending || block.Until != nil {
+			saved.Active = append(saved.Active, *block)
+		}
+	}
+	sortBlocks(saved.Active)
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode action state: %w", err)
+	}
+	r.dirty = false
+	return data, true, nil
+}
+
+// Load restores previously marshalled blocks and log; blocks that expired
+// meanwhile are undone by the next expiry check.
+func (r *Responder) Load(data []byte) error {
+	var loaded state
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse action state: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = loaded.Log
+	r.active = make(map[string]*Block, len(loaded.Active))
+	for i := range loaded.Active {
+		block := loaded.Active[i]
+		r.active[blockKey(block.Action, block.Target)] = &block
+	}
+	return nil
+}
+
+// Close stops the worker and the expiry checks and waits for the action
+// that is running, so its result is in the state saved afterwards.
+func (r *Responder) Close() {
+	close(r.stop)
+	r.workers.Wait()
+}
@@ -0,0 +1,96 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/alerts"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+func TestActionTarget(t *testing.T) {
+	proxy := rules.Rule{Name: "Proxy", Pattern: `client=(?P<src_ip>\S+) upstream=\S+`, Enabled: true}
+	if err := proxy.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	const proxyLine = "client=203.0.113.7 upstream=10.0.0.5 status=403"
+
+	tests := []struct {
+		name   string
+		line   string
+		fields map[string]string
+		rule   *rules.Rule
+		ioc    bool
+		action rules.Action
+		want   string
+	}{
+		{name: "single address in the line", line: "Failed password for root from 203.0.113.7 port 22", action: rules.Action{Target: "ip"}, want: "203.0.113.7"},
+		{name: "same address twice", line: "203.0.113.7 - GET /?next=http://203.0.113.7/", action: rules.Action{Target: "ip"}, want: "203.0.113.7"},
+		{name: "several addresses", line: proxyLine, action: rules.Action{Target: "ip"}},
+		{name: "named capture", line: proxyLine, rule: &proxy, action: rules.Action{Target: "ip", TargetField: "src_ip"}, want: "203.0.113.7"},
+		{name: "target field missing", line: "Failed password for root from 203.0.113.7 port 22", action: rules.Action{Target: "ip", TargetField: "src_ip"}},
+		{name: "field named like the type", line: proxyLine, fields: map[string]string{"ip": "198.51.100.2"}, action: rules.Action{Target: "ip"}, want: "198.51.100.2"},
+		{name: "structured field", line: "login failed", fields: map[string]string{"client": "198.51.100.2"}, action: rules.Action{Target: "ip", TargetField: "client"}, want: "198.51.100.2"},
+		{name: "no address", line: "disk full", action: rules.Action{Target: "ip"}},
+		{name: "indicator among several addresses", line: proxyLine, ioc: true, fields: map[string]string{"ioc_ip": "10.0.0.5"}, action: rules.Action{Target: "ip"}, want: "10.0.0.5"},
+		{name: "indicator of another type", line: "203.0.113.7 GET http://bad.example/", ioc: true, fields: map[string]string{"ioc_domain": "bad.example"}, action: rules.Action{Target: "ip"}},
+		{name: "several indicators", line: proxyLine, ioc: true, fields: map[string]string{"ioc_ip": "203.0.113.7, 10.0.0.5"}, action: rules.Action{Target: "ip"}},
+		{name: "indicator domain", line: "query bad.example from 203.0.113.7", ioc: true, fields: map[string]string{"ioc_domain": "bad.example"}, action: rules.Action{Target: "domain"}, want: "bad.example"},
+	}
+	for _, tt := range tests {
+		entry := parser.NewEntry(tt.line)
+		fields := tt.fields
+		if tt.rule != nil {
+			fields = rules.WithCaptures([]rules.Rule{*tt.rule}, entry, fields)
+		}
+		rule := "Proxy"
+		if tt.ioc {
+			rule = rules.IntelRuleName("blocklist")
+		}
+		alert := alerts.Alert{Line: tt.line, Fields: fields}
+		got, ok := actionTarget(alert, rule, entry, tt.action)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: target = %q, %v; want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCloseWaitsForRunningAction(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	r := NewResponder([]rules.Action{{
+		Name:    "engelle",
+		Type:    "webhook",
+		Enabled: true,
+		Target:  "ip",
+		Webhook: &rules.WebhookAction{URL: server.URL},
+	}}, map[string][]string{"Parola Denemesi": {"engelle"}})
+	r.Handle(alerts.Alert{
+		ID:           "1",
+		Line:         "Failed password for root from 203.0.113.7 port 22",
+		MatchedRules: []string{"Parola Denemesi"},
+		Count:        1,
+	})
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the action did not run")
+	}
+
+	r.Close()
+	entries := r.Log(Filter{Action: "engelle"})
+	if len(entries) != 1 || entries[0].Result != ResultOK {
+		t.Fatalf("log after Close = %+v, want the finished block", entries)
+	}
+	if active := r.Active(); len(active) != 1 || active[0].pending {
+		t.Errorf("active after Close = %+v, want a saved block", active)
+	}
+}
@@ -0,0 +1,245 @@
+package response
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"log-analyzer/backend/internal/notify"
+	"log-analyzer/backend/internal/rules"
+)
+
+const maxOutput = 4096
+
+// run carries out one block or unblock event. In a dry run it only
+// describes what it would do.
+func run(ctx context.Context, config rules.Action, event Event, dryRun bool) (string, error) {
+	switch config.Type {
+	case "command":
+		args := config.Command.Run
+		if event.Kind == "unblock" {
+			args = config.Command.Undo
+		}
+		if len(args) == 0 {
+			return "", nil
+		}
+		return runCommand(ctx, args, event, dryRun)
+	case "blocklist":
+		return updateBlocklist(ctx, config.Blocklist, event, dryRun)
+	case "webhook":
+		return callWebhook(ctx, config.Webhook, event, dryRun)
+	default:
+		return "", fmt.Errorf("unsupported action type %q", config.Type)
+	}
+}
+
+func render(text string, event Event) (string, error) {
+	tmpl, err := template.New("action").Funcs(rules.TemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, event); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// runCommand renders every argument on its own and runs the program
+// without a shell, so a target cannot inject further commands.
+func runCommand(ctx context.Context, templates []string, event Event, dryRun bool) (string, error) {
+	args := make([]string, len(templates))
+	for i, text := range templates {
+		arg, err := render(text, event)
+		if err != nil {
+			return "", fmt.Errorf("command template failed: %w", err)
+		}
+		args[i] = arg
+	}
+	if dryRun {
+		return strings.Join(quoteArgs(args), " "), nil
+	}
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	text := truncate(string(output))
+	if err != nil {
+		return text, fmt.Errorf("%s: %w", args[0], err)
+	}
+	return text, nil
+}
+
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\$") {
+			arg = fmt.Sprintf("%q", arg)
+		}
+		quoted[i] = arg
+	}
+	return quoted
+}
+
+// updateBlocklist adds or removes the target's line and reloads the
+// consumer of the file if the file changed.
+func updateBlocklist(ctx context.Context, config *rules.BlocklistAction, event Event, dryRun bool) (string, error) {
+	lineTemplate := config.Line
+	if lineTemplate == "" {
+		lineTemplate = "{{.Target}}"
+	}
+	line, err := render(lineTemplate, event)
+	if err != nil {
+		return "", fmt.Errorf("blocklist template failed: %w", err)
+	}
+	line = strings.TrimSpace(line)
+
+	lines, err := readLines(config.Path)
+	if err != nil {
+		return "", err
+	}
+	present := false
+	kept := lines[:0]
+	for _, existing := range lines {
+		if existing == line {
+			present = true
+			if event.Kind == "unblock" {
+				continue
+			}
+		}
+		kept = append(kept, existing)
+	}
+	verb := "add"
+	if event.Kind == "unblock" {
+		verb = "remove"
+	}
+	if present == (event.Kind == "unblock") {
+		summary := fmt.Sprintf("%s %q in %s", verb, line, config.Path)
+		if dryRun {
+			return summary, nil
+		}
+		if event.Kind == "block" {
+			kept = append(kept, line)
+		}
+		if err := writeLines(config.Path, kept); err != nil {
+			return "", err
+		}
+		if len(config.Reload) > 0 {
+			output, err := runCommand(ctx, config.Reload, event, false)
+			return strings.TrimSpace(summary + "\n" + output), err
+		}
+		return summary, nil
+	}
+	return fmt.Sprintf("%q already %sd in %s", line, verb, config.Path), nil
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// writeLines replaces the file atomically so readers never see half of it.
+func writeLines(path string, lines []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// callWebhook posts the event as JSON, or the rendered template, signed
+// like the webhook outputs when a secret is set.
+func callWebhook(ctx context.Context, config *rules.WebhookAction, event Event, dryRun bool) (string, error) {
+	var body []byte
+	if config.Template != "" {
+		rendered, err := render(config.Template, event)
+		if err != nil {
+			return "", fmt.Errorf("webhook template failed: %w", err)
+		}
+		body = []byte(rendered)
+	} else {
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return "", err
+		}
+		body = encoded
+	}
+	if dryRun {
+		return fmt.Sprintf("POST %s %s", config.URL, body), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "log-analyzer")
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	if config.Secret != "" {
+		req.Header.Set(notify.SignatureHeader, "sha256="+notify.Sign([]byte(config.Secret), body))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return truncate(string(response)), fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return truncate(string(response)), nil
+}
+
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxOutput {
+		s = s[:maxOutput] + "…"
+	}
+	return s
+}
+
+func sortBlocks(blocks []Block) {
+	sort.Slice(blocks, func(i, j int) bool {
+		if !blocks[i].Since.Equal(blocks[j].Since) {
+			return blocks[i].Since.Before(blocks[j].Since)
+		}
+		return blocks[i].Target < blocks[j].Target
+	})
+}
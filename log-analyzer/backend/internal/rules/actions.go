@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Action is a response to alerts, such as blocking their source address.
+// Rules name the actions they trigger. An action runs once per target
+// (the value of the Target field, "ip" by default) and, with a TTL, is
+// undone when the TTL expires.
+type Action struct {
+	Name    string `yaml:"name" json:"name"`
+	Type    string `yaml:"type" json:"type"`
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	// DryRun logs what would be done without doing it.
+	DryRun bool   `yaml:"dry_run" json:"dry_run,omitempty"`
+	Target string `yaml:"target" json:"target,omitempty"`
+	// TargetField names the alert field holding the target, such as a
+	// named capture (?P<src_ip>...) of the rule pattern or a structured
+	// field of the source.
+	TargetField string `yaml:"target_field" json:"target_field,omitempty"`
+	// MinCount waits until a grouped alert was seen this many times.
+	MinCount int    `yaml:"min_count" json:"min_count,omitempty"`
+	TTL      string `yaml:"ttl" json:"ttl,omitempty"`
+	Timeout  string `yaml:"timeout" json:"timeout,omitempty"`
+	// Ignore lists targets never acted on; for IP targets entries may be
+	// CIDR ranges.
+	Ignore    []string         `yaml:"ignore" json:"ignore,omitempty"`
+	RateLimit *RateLimit       `yaml:"rate_limit" json:"rate_limit,omitempty"`
+	Command   *CommandAction   `yaml:"command" json:"command,omitempty"`
+	Blocklist *BlocklistAction `yaml:"blocklist" json:"blocklist,omitempty"`
+	Webhook   *WebhookAction   `yaml:"webhook" json:"webhook,omitempty"`
+}
+
+// RateLimit allows at most Max runs of an action per Per.
+type RateLimit struct {
+	Max int    `yaml:"max" json:"max"`
+	Per string `yaml:"per" json:"per"`
+}
+
+// CommandAction runs a program without a shell. Every argument is a
+// template; Undo runs when the TTL expires.
+type CommandAction struct {
+	Run  []string `yaml:"run" json:"run"`
+	Undo []string `yaml:"undo" json:"undo,omitempty"`
+}
+
+// BlocklistAction keeps one templated line per target in a file, such as
+// "deny {{.Target}};" for nginx, and runs Reload after every change.
+type BlocklistAction struct {
+	Path   string   `yaml:"path" json:"path"`
+	Line   string   `yaml:"line" json:"line,omitempty"`
+	Reload []string `yaml:"reload" json:"reload,omitempty"`
+}
+
+// WebhookAction posts block and unblock events to a URL, as JSON or as the
+// rendered Template.
+type WebhookAction struct {
+	URL      string            `yaml:"url" json:"url"`
+	Template string            `yaml:"template" json:"template,omitempty"`
+	Headers  map[string]string `yaml:"headers" json:"-"`
+	Secret   string            `yaml:"secret" json:"-"`
+}
+
+func validateAction(action Action) error {
+	if action.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	var templates []string
+	switch action.Type {
+	case "command":
+		if action.Command == nil || len(action.Command.Run) == 0 {
+			return fmt.Errorf("command run is required")
+		}
+		templates = append(append(templates, action.Command.Run...), action.Command.Undo...)
+	case "blocklist":
+		if action.Blocklist == nil || action.Blocklist.Path == "" {
+			return fmt.Errorf("blocklist path is required")
+		}
+		if !filepath.IsAbs(action.Blocklist.Path) {
+			return fmt.Errorf("blocklist path must be absolute")
+		}
+		templates = append(append(templates, action.Blocklist.Line), action.Blocklist.Reload...)
+	case "webhook":
+		if action.Webhook == nil || action.Webhook.URL == "" {
+			return fmt.Errorf("webhook url is required")
+		}
+		if u, err := url.Parse(action.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid webhook url %q", action.Webhook.URL)
+		}
+		templates = append(templates, action.Webhook.Template)
+	default:
+		return fmt.Errorf("unsupported action type %q", action.Type)
+	}
+	for _, text := range templates {
+		if _, err := template.New("action").Funcs(TemplateFuncs).Parse(text); err != nil {
+			return fmt.Errorf("invalid template %q: %w", text, err)
+		}
+	}
+
+	for name, value := range map[string]string{"ttl": action.TTL, "timeout": action.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+	if action.MinCount < 0 {
+		return fmt.Errorf("min_count must not be negative")
+	}
+	if limit := action.RateLimit; limit != nil {
+		if d, err := time.ParseDuration(limit.Per); err != nil || d <= 0 || limit.Max <= 0 {
+			return fmt.Errorf("rate_limit needs a positive max and per duration")
+		}
+	}
+	if strings.EqualFold(action.Target, "ip") || action.Target == "" {
+		for _, entry := range action.Ignore {
+			if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+				return fmt.Errorf("invalid ignore entry %q", entry)
+			}
+		}
+	}
+	return nil
+}
+
+// GetEnabledActions returns the enabled actions. With the dry_run_actions
+// setting every action is a dry run.
+func (m *Manager) GetEnabledActions() []Action {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var enabled []Action
+	for _, action := range m.config.Actions {
+		if action.Enabled {
+			action.DryRun = action.DryRun || m.config.Settings.DryRunActions
+			enabled = append(enabled, action)
+		}
+	}
+	return enabled
+}
+
+// RuleActions maps the enabled rules, and the rule names of enabled IOC
+// feeds, to the names of the actions they trigger.
+func (m *Manager) RuleActions() map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string][]string)
+	for _, rule := range m.config.Rules {
+		if rule.Enabled && len(rule.Actions) > 0 {
+			result[rule.Name] = append([]string(nil), rule.Actions...)
+		}
+	}
+	if m.config.ThreatIntel != nil {
+		for _, feed := range m.config.ThreatIntel.Feeds {
+			if feed.Enabled && len(feed.Actions) > 0 {
+				result[IntelRuleName(feed.Name)] = append([]string(nil), feed.Actions...)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package rules
+
+// ahoCorasick finds, in a single pass, which of a set of literals occur in a
+// text. Matching is ASCII case-insensitive; the literals must be lowercase.
+//
+// The automaton is stored as a dense DFA over byte classes: only bytes that
+// occur in some literal get their own class, everything else shares class 0.
+type ahoCorasick struct {
+	classes    [256]uint8
+	numClasses int
+	delta      []int32
+	outputs    [][]int32
+}
+
+func newAhoCorasick(literals []string) *ahoCorasick {
+	ac := &ahoCorasick{numClasses: 1}
+	for _, literal := range literals {
+		for i := 0; i < len(literal); i++ {
+			if ac.classes[literal[i]] == 0 {
+				ac.classes[literal[i]] = uint8(ac.numClasses)
+				ac.numClasses++
+			}
+		}
+	}
+	for b := 'A'; b <= 'Z'; b++ {
+		ac.classes[b] = ac.classes[b+'a'-'A']
+	}
+
+	// Build the trie; -1 marks a missing edge.
+	trie := [][]int32{newRow(ac.numClasses)}
+	outputs := [][]int32{nil}
+	for id, literal := range literals {
+		state := int32(0)
+		for i := 0; i < len(literal); i++ {
+			c := ac.classes[literal[i]]
+			if trie[state][c] < 0 {
+				trie = append(trie, newRow(ac.numClasses))
+				outputs = append(outputs, nil)
+				trie[state][c] = int32(len(trie) - 1)
+			}
+			state = trie[state][c]
+		}
+		outputs[state] = append(outputs[state], int32(id))
+	}
+
+	// Breadth-first pass resolving failure links into the transition table.
+	fail := make([]int32, len(trie))
+	queue := make([]int32, 0, len(trie))
+	for c := 0; c < ac.numClasses; c++ {
+		if next := trie[0][c]; next >= 0 {
+			fail[next] = 0
+			queue = append(queue, next)
+		} else {
+			trie[0][c] = 0
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		outputs[state] = append(outputs[state], outputs[fail[state]]...)
+		for c := 0; c < ac.numClasses; c++ {
+			next := trie[state][c]
+			if next < 0 {
+				trie[state][c] = trie[fail[state]][c]
+				continue
+			}
+			fail[next] = trie[fail[state]][c]
+			queue = append(queue, next)
+		}
+	}
+
+	ac.delta = make([]int32, 0, len(trie)*ac.numClasses)
+	for _, row := range trie {
+		ac.delta = append(ac.delta, row...)
+	}
+	ac.outputs = outputs
+	return ac
+}
+
+func newRow(n int) []int32 {
+	row := make([]int32, n)
+	for i := range row {
+		row[i] = -1
+	}
+	return row
+}
+
+// scan calls found for every literal occurrence in text.
+func (ac *ahoCorasick) scan(text string, found func(id int32)) {
+	state := int32(0)
+	n := int32(ac.numClasses)
+	for i := 0; i < len(text); i++ {
+		state = ac.delta[state*n+int32(ac.classes[text[i]])]
+		for _, id := range ac.outputs[state] {
+			found(id)
+		}
+	}
+}
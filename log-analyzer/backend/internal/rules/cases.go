@@ -0,0 +1,65 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+// DefaultCaseEntities are used when the cases block names no entities.
+var DefaultCaseEntities = []string{"ip", "user"}
+
+// CaseConfig correlates live alerts into cases: alerts sharing a value of
+// one of the entity fields less than Window apart end up in the same case.
+// Entities name entry fields or entity pseudo fields such as "ip". A zero
+// window turns automatic cases off.
+type CaseConfig struct {
+	Entities []string `yaml:"entities" json:"entities,omitempty"`
+	Window   string   `yaml:"window" json:"window"`
+	window   time.Duration
+}
+
+func (c *CaseConfig) compile() error {
+	c.window = 0
+	if c.Window == "" {
+		return nil
+	}
+	window, err := time.ParseDuration(c.Window)
+	if err != nil {
+		return fmt.Errorf("invalid window: %w", err)
+	}
+	c.window = window
+	return nil
+}
+
+// CaseEntities returns the correlation entities of entry as "field:value"
+// strings and the correlation window; a zero window means automatic cases
+// are off.
+func (m *Manager) CaseEntities(entry *parser.Entry) ([]string, time.Duration) {
+	m.mu.RLock()
+	config, geo := m.config.Settings.Cases, m.geo
+	m.mu.RUnlock()
+	if config == nil || config.window <= 0 {
+		return nil, 0
+	}
+
+	fields := config.Entities
+	if len(fields) == 0 {
+		fields = DefaultCaseEntities
+	}
+	var entities []string
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		field = strings.ToLower(field)
+		for _, value := range fieldValues(geo, entry, field) {
+			entity := field + ":" + value
+			if !seen[entity] {
+				seen[entity] = true
+				entities = append(entities, entity)
+			}
+		}
+	}
+	return entities, config.window
+}
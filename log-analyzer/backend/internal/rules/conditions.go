@@ -0,0 +1,84 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/parser"
+)
+
+var conditionSyntax = regexp.MustCompile(`^\s*([\w.-]+)\s+(==|=|!=|not\s+in|in)\s+(.+?)\s*$`)
+
+// condition is a compiled rule condition such as `geo.country not in [TR]`
+// or `user != root`. It holds when some value of the field is (or, negated,
+// is not) one of the listed values; a field without values never holds.
+type condition struct {
+	field  string
+	negate bool
+	values []string
+}
+
+func parseCondition(text string) (condition, error) {
+	m := conditionSyntax.FindStringSubmatch(text)
+	if m == nil {
+		return condition{}, fmt.Errorf("invalid condition %q: want <field> ==|!=|in|not in <value>", text)
+	}
+	if err := validateGeoField(m[1]); err != nil {
+		return condition{}, err
+	}
+	op := strings.Join(strings.Fields(m[2]), " ")
+	c := condition{field: m[1], negate: op == "!=" || op == "not in"}
+	if op == "in" || op == "not in" {
+		list := m[3]
+		if !strings.HasPrefix(list, "[") || !strings.HasSuffix(list, "]") {
+			return condition{}, fmt.Errorf("invalid condition %q: %s needs a [list]", text, op)
+		}
+		for _, item := range strings.Split(list[1:len(list)-1], ",") {
+			if item = unquote(item); item != "" {
+				c.values = append(c.values, item)
+			}
+		}
+		if len(c.values) == 0 {
+			return condition{}, fmt.Errorf("invalid condition %q: empty list", text)
+		}
+	} else {
+		c.values = []string{unquote(m[3])}
+	}
+	return c, nil
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+func (c condition) holds(entry *parser.Entry, geo *geoip.DB) bool {
+	for _, value := range fieldValues(geo, entry, c.field) {
+		listed := false
+		for _, candidate := range c.values {
+			if strings.EqualFold(value, candidate) {
+				listed = true
+				break
+			}
+		}
+		if listed != c.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// usesGeo reports whether a rule condition needs the GeoIP databases.
+func (r *Rule) usesGeo() bool {
+	for _, c := range r.conditions {
+		if _, ok := geoField(c.field); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// DNS lookup defaults.
+const (
+	DefaultDNSTimeout     = 2 * time.Second
+	DefaultDNSCacheTTL    = time.Hour
+	DefaultDNSNegativeTTL = 5 * time.Minute
+	DefaultDNSCacheSize   = 10000
+	DefaultDNSWorkers     = 4
+)
+
+// DNS enriches live alerts with host names: Reverse looks up the PTR names
+// of the addresses in a line, Forward the addresses of the client host name
+// an access log line starts with. Server, such as "127.0.0.1:53", replaces
+// the system resolver.
+type DNS struct {
+	Enabled     bool   `yaml:"enabled" json:"enabled"`
+	Reverse     bool   `yaml:"reverse" json:"reverse"`
+	Forward     bool   `yaml:"forward" json:"forward"`
+	Server      string `yaml:"server" json:"server,omitempty"`
+	Timeout     string `yaml:"timeout" json:"timeout,omitempty"`
+	CacheTTL    string `yaml:"cache_ttl" json:"cache_ttl,omitempty"`
+	NegativeTTL string `yaml:"negative_ttl" json:"negative_ttl,omitempty"`
+	CacheSize   int    `yaml:"cache_size" json:"cache_size,omitempty"`
+	Workers     int    `yaml:"workers" json:"workers,omitempty"`
+}
+
+func validateDNS(config DNS) error {
+	if !config.Reverse && !config.Forward {
+		return fmt.Errorf("reverse or forward is required")
+	}
+	if config.Server != "" {
+		if _, _, err := net.SplitHostPort(config.Server); err != nil {
+			return fmt.Errorf("invalid server %q: %w", config.Server, err)
+		}
+	}
+	for name, value := range map[string]string{"timeout": config.Timeout, "cache_ttl": config.CacheTTL, "negative_ttl": config.NegativeTTL} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+	if config.CacheSize < 0 || config.Workers < 0 {
+		return fmt.Errorf("cache_size and workers must not be negative")
+	}
+	return nil
+}
+
+// Timings returns the timeout and cache lifetimes of config with the
+// defaults filled in.
+func (config DNS) Timings() (timeout, ttl, negativeTTL time.Duration) {
+	parse := func(value string, fallback time.Duration) time.Duration {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+		return fallback
+	}
+	return parse(config.Timeout, DefaultDNSTimeout), parse(config.CacheTTL, DefaultDNSCacheTTL), parse(config.NegativeTTL, DefaultDNSNegativeTTL)
+}
+
+// GetDNS returns the DNS enrichment config, or nil when it is disabled.
+func (m *Manager) GetDNS() *DNS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.config.DNS == nil || !m.config.DNS.Enabled {
+		return nil
+	}
+	config := *m.config.DNS
+	if config.CacheSize == 0 {
+		config.CacheSize = DefaultDNSCacheSize
+	}
+	if config.Workers == 0 {
+		config.Workers = DefaultDNSWorkers
+	}
+	return &config
+}
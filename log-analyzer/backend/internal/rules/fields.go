@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"strings"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/parser"
+)
+
+// FieldValues returns the values of a named field of entry. Besides the
+// entry fields it knows the entity types of the parser ("ip", "user",
+// "email", "url", "domain", "path", "hash") as pseudo fields, found in the
+// line itself since plain log files carry no structured fields. A source
+// field of the same name, such as the url of a JSON access log, wins over
+// the pseudo field, except for "ip" and "user", which always come from the
+// line. The "geo.<name>" fields need the GeoIP databases of the config and
+// are only known to Manager.FieldValues.
+func FieldValues(entry *parser.Entry, field string) []string {
+	return fieldValues(nil, entry, field)
+}
+
+// FieldValues is FieldValues with the geo.* fields looked up in the GeoIP
+// databases of the loaded config.
+func (m *Manager) FieldValues(entry *parser.Entry, field string) []string {
+	return fieldValues(m.geoIP(), entry, field)
+}
+
+func fieldValues(geo *geoip.DB, entry *parser.Entry, field string) []string {
+	var values []string
+	if name, ok := geoField(field); ok {
+		for _, location := range locate(geo, entry) {
+			if value := location.Field(name); value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	name := strings.ToLower(field)
+	if name == parser.EntityIP || name == parser.EntityUser {
+		return entry.EntityValues(name)
+	}
+	if value := entry.Field(field); value != "" {
+		return append(values, value)
+	}
+	if parser.IsEntityType(name) {
+		return entry.EntityValues(name)
+	}
+	return values
+}
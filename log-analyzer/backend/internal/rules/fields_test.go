@@ -0,0 +1,42 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+func TestFieldValues(t *testing.T) {
+	const line = "GET http://evil.example/x from 203.0.113.7 for alice"
+	structured := parser.NewEntry(line)
+	structured.Fields = map[string]string{
+		"url":  "https://shop.example/cart",
+		"path": "/cart",
+		"ip":   "198.51.100.2",
+		"user": "bob",
+	}
+	plain := parser.NewEntry(line)
+
+	tests := []struct {
+		entry *parser.Entry
+		field string
+		want  []string
+	}{
+		{plain, "url", []string{"http://evil.example/x"}},
+		{plain, "domain", []string{"evil.example"}},
+		{plain, "IP", []string{"203.0.113.7"}},
+		{structured, "url", []string{"https://shop.example/cart"}},
+		{structured, "path", []string{"/cart"}},
+		{structured, "domain", []string{"evil.example"}},
+		// ip and user have always come from the line.
+		{structured, "ip", []string{"203.0.113.7"}},
+		{structured, "user", []string{"alice"}},
+		{plain, "program", nil},
+	}
+	for _, tt := range tests {
+		if got := FieldValues(tt.entry, tt.field); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FieldValues(%q) with fields %v = %v, want %v", tt.field, tt.entry.Fields, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/parser"
+)
+
+// GeoIP lists the MaxMind-format databases (City, Country and/or ASN)
+// addresses are looked up in.
+type GeoIP struct {
+	Databases []string `yaml:"databases" json:"databases"`
+}
+
+// maxLocations bounds the addresses of one line that are looked up.
+const maxLocations = 5
+
+func openGeoIP(config *GeoIP) (*geoip.DB, error) {
+	if len(config.Databases) == 0 {
+		return nil, fmt.Errorf("databases are required")
+	}
+	return geoip.Open(config.Databases...)
+}
+
+// geoField returns the location attribute named by a "geo.<name>" field.
+func geoField(field string) (string, bool) {
+	name, ok := strings.CutPrefix(strings.ToLower(field), "geo.")
+	return name, ok
+}
+
+func validateGeoField(field string) error {
+	name, ok := geoField(field)
+	if ok && !geoip.IsField(name) {
+		return fmt.Errorf("unknown geo field %q (known: %s)", field, strings.Join(geoip.Fields, ", "))
+	}
+	return nil
+}
+
+func (m *Manager) geoIP() *geoip.DB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.geo
+}
+
+// UseGeoIP lets rules that did not come from the config, such as candidates
+// sent for testing, look up their geo.* conditions in its databases.
+func (m *Manager) UseGeoIP(ruleList []Rule) {
+	geo := m.geoIP()
+	for i := range ruleList {
+		ruleList[i].geo = geo
+	}
+}
+
+// Locate looks up the addresses found in the line of entry.
+func (m *Manager) Locate(entry *parser.Entry) []geoip.Location {
+	return locate(m.geoIP(), entry)
+}
+
+func locate(db *geoip.DB, entry *parser.Entry) []geoip.Location {
+	if db == nil {
+		return nil
+	}
+	var result []geoip.Location
+	seen := make(map[string]bool)
+	for _, ip := range FieldValues(entry, "ip") {
+		if seen[ip] || len(result) >= maxLocations {
+			continue
+		}
+		seen[ip] = true
+		if location, ok := db.Lookup(ip); ok {
+			result = append(result, location)
+		}
+	}
+	return result
+}
@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/parser"
+)
+
+// TestGeoIPPerManager checks that each manager looks geo fields up in its
+// own databases.
+func TestGeoIPPerManager(t *testing.T) {
+	db, err := geoip.Open("../geoip/testdata/test-city.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withGeo := &Manager{config: &Config{}, geo: db}
+	withoutGeo := &Manager{config: &Config{}}
+	entry := parser.NewEntry("Failed password for root from 203.0.113.9 port 22 ssh2")
+
+	if got := withGeo.FieldValues(entry, "geo.city"); !reflect.DeepEqual(got, []string{"Istanbul"}) {
+		t.Errorf("geo.city = %v, want [Istanbul]", got)
+	}
+	if got := withoutGeo.FieldValues(entry, "geo.city"); got != nil {
+		t.Errorf("geo.city without databases = %v", got)
+	}
+	if got := FieldValues(entry, "geo.city"); got != nil {
+		t.Errorf("package FieldValues resolved geo.city to %v", got)
+	}
+
+	rule := Rule{Name: "Yurt içi", Pattern: "Failed password", Conditions: []string{"geo.country == TR"}}
+	if err := rule.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		manager *Manager
+		want    bool
+	}{{withGeo, true}, {withoutGeo, false}} {
+		candidate := []Rule{rule}
+		tt.manager.UseGeoIP(candidate)
+		if got := candidate[0].Match(entry); got != tt.want {
+			t.Errorf("match with databases %v = %v", tt.manager.geo != nil, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/dedup"
+	"log-analyzer/backend/internal/parser"
+)
+
+// DefaultDedupKeys are used when a dedup block names no keys.
+var DefaultDedupKeys = []string{"host", "message"}
+
+// DedupConfig groups repeated alerts: a match whose key equals that of an
+// alert seen less than Window ago is counted on that alert instead of
+// raising a new one. Keys name entry fields, entity pseudo fields such as
+// "ip" and "user", or "message", which is compared with numbers, addresses and
+// timestamps masked. A zero window turns grouping off.
+type DedupConfig struct {
+	Keys   []string `yaml:"keys" json:"keys,omitempty"`
+	Window string   `yaml:"window" json:"window"`
+	window time.Duration
+}
+
+func (d *DedupConfig) compile() error {
+	d.window = 0
+	if d.Window == "" {
+		return nil
+	}
+	window, err := time.ParseDuration(d.Window)
+	if err != nil {
+		return fmt.Errorf("invalid window: %w", err)
+	}
+	d.window = window
+	return nil
+}
+
+// DedupKey returns the group key of an alert for the matched rules and how
+// long the group stays open. The first matched rule with a dedup block
+// decides the keys, falling back to settings.dedup. A key field the entry
+// lacks is hashed as missing; when all of them are missing, or grouping is
+// off, the returned key is empty and the alert is not grouped.
+func (m *Manager) DedupKey(matched []Rule, entry *parser.Entry) (string, time.Duration) {
+	var config *DedupConfig
+	for _, rule := range matched {
+		if rule.Dedup != nil {
+			config = rule.Dedup
+			break
+		}
+	}
+	if config == nil {
+		m.mu.RLock()
+		config = m.config.Settings.Dedup
+		m.mu.RUnlock()
+	}
+	if config == nil || config.window <= 0 || len(matched) == 0 {
+		return "", 0
+	}
+
+	keys := config.Keys
+	if len(keys) == 0 {
+		keys = DefaultDedupKeys
+	}
+	hash := fnv.New64a()
+	for _, rule := range matched {
+		hash.Write([]byte(rule.Name))
+		hash.Write([]byte{0})
+	}
+	found := false
+	for _, key := range keys {
+		value := ""
+		if strings.EqualFold(key, "message") {
+			value = dedup.Normalize(entry.Field("message"))
+		} else {
+			value = strings.Join(m.FieldValues(entry, key), ",")
+		}
+		hash.Write([]byte(key + "="))
+		if value == "" {
+			// Missing is its own value, so it never equals a real one.
+			hash.Write([]byte{1})
+		} else {
+			hash.Write([]byte(value))
+			found = true
+		}
+		hash.Write([]byte{0})
+	}
+	// With every key missing only the rule names would be left, which would
+	// fold unrelated events into one alert.
+	if !found {
+		return "", 0
+	}
+	return fmt.Sprintf("%016x", hash.Sum64()), config.window
+}
@@ -0,0 +1,33 @@
+package rules
+
+import (
+	"testing"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+func TestDedupKeyMissingFields(t *testing.T) {
+	m := &Manager{config: &Config{}}
+	rule := Rule{Name: "Parola Denemesi", Dedup: &DedupConfig{Keys: []string{"ip", "user"}, Window: "10m"}}
+	if err := rule.Dedup.compile(); err != nil {
+		t.Fatal(err)
+	}
+	key := func(line string) string {
+		key, _ := m.DedupKey([]Rule{rule}, parser.NewEntry(line))
+		return key
+	}
+
+	withIP := key("Failed password for invalid user from 203.0.113.7 port 22")
+	if withIP == "" {
+		t.Fatal("a line with an address was not grouped")
+	}
+	if other := key("Failed password for invalid user from 203.0.113.7 port 4022"); other != withIP {
+		t.Errorf("same address and no user: keys %s and %s differ", withIP, other)
+	}
+	if other := key("Failed password for invalid user from 198.51.100.2 port 22"); other == withIP {
+		t.Error("different addresses share a group key")
+	}
+	if none := key("Failed password, no details"); none != "" {
+		t.Errorf("a line without any key field got group key %s", none)
+	}
+}
@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultIntelRefresh is how often feeds are reloaded when threat_intel
+// sets no refresh interval.
+const DefaultIntelRefresh = 15 * time.Minute
+
+// ThreatIntel configures the IOC feeds that lines are checked against.
+type ThreatIntel struct {
+	Refresh string      `yaml:"refresh" json:"refresh,omitempty"`
+	Feeds   []IntelFeed `yaml:"feeds" json:"feeds"`
+}
+
+// IntelFeed is a local file of indicators. Format is plain (one indicator
+// per line), csv, stix (a STIX 2.1 bundle) or misp (a MISP JSON export).
+// Type fixes the indicator type of plain and csv feeds: ip, domain, hash,
+// url or user_agent; "auto" (the default) guesses it from each value.
+type IntelFeed struct {
+	Name     string `yaml:"name" json:"name"`
+	Path     string `yaml:"path" json:"path"`
+	Format   string `yaml:"format" json:"format"`
+	Type     string `yaml:"type" json:"type,omitempty"`
+	Enabled  bool   `yaml:"enabled" json:"enabled"`
+	Severity string `yaml:"severity" json:"severity,omitempty"`
+	// Column and TypeColumn name the CSV columns holding the indicator and
+	// its type; by default the first column holds the indicator.
+	Column     string   `yaml:"column" json:"column,omitempty"`
+	TypeColumn string   `yaml:"type_column" json:"type_column,omitempty"`
+	Delimiter  string   `yaml:"delimiter" json:"delimiter,omitempty"`
+	Actions    []string `yaml:"actions" json:"actions,omitempty"`
+}
+
+// IntelRuleName is the rule name alerts raised by a feed carry, so outputs
+// and actions can select them like rule matches.
+func IntelRuleName(feed string) string {
+	return intelRulePrefix + feed
+}
+
+const intelRulePrefix = "IOC: "
+
+// IsIntelRule reports whether name is the rule name of a feed.
+func IsIntelRule(name string) bool {
+	return strings.HasPrefix(name, intelRulePrefix)
+}
+
+// IntelField is the alert field listing the values of one indicator type
+// that matched a feed, such as ioc_ip. Actions of a feed take their target
+// from it rather than from whatever else the line contains.
+func IntelField(typ string) string {
+	return "ioc_" + typ
+}
+
+var intelTypes = map[string]bool{
+	"": true, "auto": true, "ip": true, "domain": true, "hash": true, "url": true, "user_agent": true,
+}
+
+func validateThreatIntel(config *ThreatIntel) error {
+	if config.Refresh != "" {
+		if refresh, err := time.ParseDuration(config.Refresh); err != nil || refresh < time.Second {
+			return fmt.Errorf("invalid refresh %q", config.Refresh)
+		}
+	}
+	names := make(map[string]bool)
+	for i, feed := range config.Feeds {
+		if err := validateFeed(feed); err != nil {
+			return fmt.Errorf("invalid feed %d (%s): %w", i+1, feed.Name, err)
+		}
+		if names[feed.Name] {
+			return fmt.Errorf("duplicate feed name %q", feed.Name)
+		}
+		names[feed.Name] = true
+	}
+	return nil
+}
+
+func validateFeed(feed IntelFeed) error {
+	if feed.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if feed.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	switch feed.Format {
+	case "plain", "csv", "stix", "misp":
+	default:
+		return fmt.Errorf("unsupported feed format %q", feed.Format)
+	}
+	if !intelTypes[strings.ToLower(feed.Type)] {
+		return fmt.Errorf("unknown indicator type %q", feed.Type)
+	}
+	if feed.Severity != "" && !severityNames[strings.ToLower(feed.Severity)] {
+		return fmt.Errorf("unknown severity %q", feed.Severity)
+	}
+	if len(feed.Delimiter) > 1 {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+	return nil
+}
+
+// GetThreatIntel returns the refresh interval and the enabled feeds.
+func (m *Manager) GetThreatIntel() (time.Duration, []IntelFeed) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	config := m.config.ThreatIntel
+	if config == nil {
+		return DefaultIntelRefresh, nil
+	}
+	refresh, err := time.ParseDuration(config.Refresh)
+	if err != nil {
+		refresh = DefaultIntelRefresh
+	}
+	var enabled []IntelFeed
+	for _, feed := range config.Feeds {
+		if feed.Enabled {
+			enabled = append(enabled, feed)
+		}
+	}
+	return refresh, enabled
+}
@@ -0,0 +1,406 @@
+package rules
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+	LintInfo    = "info"
+)
+
+// LintFinding is a single problem reported by Lint.
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Related  string `json:"related,omitempty"`
+}
+
+// LintSeverityLevel orders finding severities for filtering.
+func LintSeverityLevel(severity string) int {
+	switch severity {
+	case LintError:
+		return 3
+	case LintWarning:
+		return 2
+	case LintInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// slowFactor is how many times slower than the median rule a pattern must
+// be over the corpus before it is reported.
+const slowFactor = 5
+
+// minOverlapHits is the number of corpus hits a rule needs before overlap
+// with another rule is considered meaningful.
+const minOverlapHits = 3
+
+// Lint checks rules for problems. Static checks always run; the overlap,
+// never-matching and performance checks need a corpus of sample lines.
+func Lint(ruleList []Rule, corpus []string) []LintFinding {
+	var findings []LintFinding
+	add := func(rule, check, severity, message, related string) {
+		findings = append(findings, LintFinding{Rule: rule, Check: check, Severity: severity, Message: message, Related: related})
+	}
+
+	compiled := make([]Rule, 0, len(ruleList))
+	patterns := make(map[string]string)
+	for _, rule := range ruleList {
+		if strings.TrimSpace(rule.Description) == "" {
+			add(rule.Name, "missing-description", LintInfo, "kuralın açıklaması yok", "")
+		}
+		if err := rule.Compile(); err != nil {
+			add(rule.Name, "invalid-pattern", LintError, err.Error(), "")
+			continue
+		}
+		key := rule.Field + "\x00" + rule.Pattern + "\x00" + rule.ExcludePattern
+		if other, ok := patterns[key]; ok {
+			add(rule.Name, "duplicate", LintWarning, "desen başka bir kuralla birebir aynı", other)
+		} else {
+			patterns[key] = rule.Name
+		}
+
+		re, err := syntax.Parse(rule.Pattern, syntax.Perl)
+		if err != nil {
+			add(rule.Name, "invalid-pattern", LintError, err.Error(), "")
+			continue
+		}
+		switch leading, trailing := startsWithWildcard(re), endsWithWildcard(re); {
+		case leading && trailing:
+			add(rule.Name, "redundant-wildcard", LintWarning, "desen '.*' ile başlayıp bitiyor; eşleşme zaten kısmi olduğundan ikisi de gereksiz iş", "")
+		case leading:
+			add(rule.Name, "redundant-wildcard", LintWarning, "desen '.*' ile başlıyor; eşleşme zaten kısmi olduğundan gereksiz iş", "")
+		case trailing:
+			add(rule.Name, "redundant-wildcard", LintWarning, "desen '.*' ile bitiyor; eşleşme zaten kısmi olduğundan gereksiz iş", "")
+		}
+		variants, duplicates := caseVariants(re)
+		if len(variants) > 0 {
+			add(rule.Name, "case-variants", LintWarning,
+				fmt.Sprintf("yalnızca büyük/küçük harfle ayrılan seçenekler (%s); (?i) kullanın", strings.Join(variants, ", ")), "")
+		}
+		if len(duplicates) > 0 {
+			add(rule.Name, "duplicate-alternative", LintInfo,
+				fmt.Sprintf("aynı seçenek birden fazla kez yazılmış: %s", strings.Join(duplicates, ", ")), "")
+		}
+		if neverMatches(re.Simplify()) {
+			add(rule.Name, "never-matches", LintError, "desen hiçbir satırla eşleşemez", "")
+		} else if rule.regex.MatchString("") && (rule.excludeRegex == nil || !rule.excludeRegex.MatchString("")) {
+			add(rule.Name, "matches-everything", LintError, "desen boş satırla da eşleşiyor, yani her satırla eşleşir", "")
+		}
+		compiled = append(compiled, rule)
+	}
+
+	if len(corpus) > 0 {
+		for _, rule := range ruleList {
+			corpus = append(corpus, rule.ShouldMatch...)
+		}
+		findings = append(findings, lintCorpus(compiled, corpus)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return LintSeverityLevel(findings[i].Severity) > LintSeverityLevel(findings[j].Severity)
+	})
+	return findings
+}
+
+// lintCorpus runs every rule over the corpus to find rules that never fire,
+// rules whose hits are a subset of another rule's, and slow patterns.
+func lintCorpus(ruleList []Rule, corpus []string) []LintFinding {
+	var findings []LintFinding
+	entries := make([]*parser.Entry, len(corpus))
+	for i, line := range corpus {
+		entries[i] = parser.NewEntry(line)
+	}
+
+	hits := make([][]int, len(ruleList))
+	durations := make([]time.Duration, len(ruleList))
+	for i := range ruleList {
+		start := time.Now()
+		for j, entry := range entries {
+			if ruleList[i].Match(entry) {
+				hits[i] = append(hits[i], j)
+			}
+		}
+		durations[i] = time.Since(start)
+		if len(hits[i]) == 0 && ruleList[i].Enabled {
+			findings = append(findings, LintFinding{
+				Rule: ruleList[i].Name, Check: "no-corpus-match", Severity: LintInfo,
+				Message: fmt.Sprintf("örnek %d satırın hiçbiriyle eşleşmedi", len(corpus)),
+			})
+		}
+	}
+
+	for i := range ruleList {
+		if len(hits[i]) < minOverlapHits {
+			continue
+		}
+		for j := range ruleList {
+			if i == j || len(hits[j]) < len(hits[i]) || !isSubset(hits[i], hits[j]) {
+				continue
+			}
+			if len(hits[i]) == len(hits[j]) {
+				// Report identical hit sets once.
+				if j < i {
+					findings = append(findings, LintFinding{
+						Rule: ruleList[i].Name, Check: "overlap", Severity: LintWarning,
+						Message: fmt.Sprintf("örnek satırlarda aynı %d satırla eşleşiyor", len(hits[i])),
+						Related: ruleList[j].Name,
+					})
+				}
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Rule: ruleList[i].Name, Check: "subsumed", Severity: LintInfo,
+				Message: fmt.Sprintf("eşleştiği %d satırın tamamı başka bir kuralla da eşleşiyor (%d satır)", len(hits[i]), len(hits[j])),
+				Related: ruleList[j].Name,
+			})
+		}
+	}
+
+	if len(durations) > 2 {
+		sorted := make([]time.Duration, len(durations))
+		copy(sorted, durations)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		median := sorted[len(sorted)/2]
+		for i, d := range durations {
+			if median > 0 && d > median*slowFactor && d > time.Millisecond {
+				findings = append(findings, LintFinding{
+					Rule: ruleList[i].Name, Check: "slow-pattern", Severity: LintWarning,
+					Message: fmt.Sprintf("örnek satırlarda %s sürdü (medyan %s)", d.Round(time.Microsecond), median.Round(time.Microsecond)),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func isSubset(a, b []int) bool {
+	j := 0
+	for _, v := range a {
+		for j < len(b) && b[j] < v {
+			j++
+		}
+		if j == len(b) || b[j] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func isWildcard(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpStar && len(re.Sub) == 1 &&
+		(re.Sub[0].Op == syntax.OpAnyCharNotNL || re.Sub[0].Op == syntax.OpAnyChar)
+}
+
+func startsWithWildcard(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar:
+		return isWildcard(re)
+	case syntax.OpConcat:
+		return len(re.Sub) > 0 && startsWithWildcard(re.Sub[0])
+	case syntax.OpCapture:
+		return startsWithWildcard(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if startsWithWildcard(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func endsWithWildcard(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar:
+		return isWildcard(re)
+	case syntax.OpConcat:
+		return len(re.Sub) > 0 && endsWithWildcard(re.Sub[len(re.Sub)-1])
+	case syntax.OpCapture:
+		return endsWithWildcard(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if endsWithWildcard(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// caseVariants returns alternation branches that differ only in letter case,
+// such as `error|Error|ERROR`, and branches that are repeated verbatim.
+func caseVariants(re *syntax.Regexp) (variants, duplicates []string) {
+	var walk func(*syntax.Regexp)
+	walk = func(re *syntax.Regexp) {
+		if re.Op == syntax.OpAlternate {
+			groups := make(map[string][]string)
+			var keys []string
+			for _, sub := range re.Sub {
+				if foldsCase(sub) {
+					continue
+				}
+				text := trimWildcards(sub).String()
+				key := strings.ToLower(text)
+				if _, ok := groups[key]; !ok {
+					keys = append(keys, key)
+				}
+				groups[key] = append(groups[key], text)
+			}
+			for _, key := range keys {
+				distinct := uniqueStrings(groups[key])
+				if len(distinct) < len(groups[key]) {
+					duplicates = append(duplicates, distinct[0])
+				}
+				if len(distinct) > 1 {
+					variants = append(variants, strings.Join(distinct, "|"))
+				}
+			}
+		}
+		for _, sub := range re.Sub {
+			walk(sub)
+		}
+	}
+	walk(re)
+	return variants, duplicates
+}
+
+func uniqueStrings(values []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func trimWildcards(re *syntax.Regexp) *syntax.Regexp {
+	if re.Op != syntax.OpConcat {
+		return re
+	}
+	subs := re.Sub
+	for len(subs) > 0 && isWildcard(subs[0]) {
+		subs = subs[1:]
+	}
+	for len(subs) > 0 && isWildcard(subs[len(subs)-1]) {
+		subs = subs[:len(subs)-1]
+	}
+	if len(subs) == 1 {
+		return subs[0]
+	}
+	trimmed := *re
+	trimmed.Sub = subs
+	return &trimmed
+}
+
+func foldsCase(re *syntax.Regexp) bool {
+	if re.Flags&syntax.FoldCase != 0 && (re.Op == syntax.OpLiteral || re.Op == syntax.OpCharClass) {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if foldsCase(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// neverMatches reports patterns that cannot match any input, e.g. an empty
+// character class or `$a`.
+func neverMatches(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return true
+	case syntax.OpCharClass:
+		return len(re.Rune) == 0
+	case syntax.OpConcat:
+		for i, sub := range re.Sub {
+			if neverMatches(sub) {
+				return true
+			}
+			// End-of-text followed by something that must consume input.
+			if (sub.Op == syntax.OpEndText || (sub.Op == syntax.OpEndLine && re.Flags&syntax.OneLine != 0)) && i+1 < len(re.Sub) && consumes(re.Sub[i+1]) {
+				return true
+			}
+		}
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return neverMatches(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min > 0 && neverMatches(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !neverMatches(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func consumes(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) > 0
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpPlus, syntax.OpCapture:
+		return consumes(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if consumes(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadCorpus reads up to maxLines non-empty lines from each file.
+func ReadCorpus(paths []string, maxLines int) ([]string, error) {
+	var corpus []string
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		count := 0
+		for scanner.Scan() && (maxLines <= 0 || count < maxLines) {
+			if line := scanner.Text(); line != "" {
+				corpus = append(corpus, line)
+				count++
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	return corpus, nil
+}
+
+// Lint runs the linter over the loaded rules.
+func (m *Manager) Lint(corpus []string) []LintFinding {
+	return Lint(m.GetRules(), corpus)
+}
@@ -0,0 +1,287 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"log-analyzer/backend/internal/syslog"
+)
+
+// TemplateFuncs are available in every template of the config.
+var TemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Output sends live alerts to an outside destination. MinSeverity and Rules
+// filter the alerts it receives; empty means all.
+type Output struct {
+	Name        string         `yaml:"name" json:"name"`
+	Type        string         `yaml:"type" json:"type"`
+	Enabled     bool           `yaml:"enabled" json:"enabled"`
+	MinSeverity string         `yaml:"min_severity" json:"min_severity,omitempty"`
+	Rules       []string       `yaml:"rules" json:"rules,omitempty"`
+	Webhook     *WebhookOutput `yaml:"webhook" json:"webhook,omitempty"`
+	Email       *EmailOutput   `yaml:"email" json:"email,omitempty"`
+	Syslog      *SyslogOutput  `yaml:"syslog" json:"syslog,omitempty"`
+}
+
+// DeliveryOptions control how an output retries: a failed send is tried
+// again MaxRetries times, waiting Backoff and doubling it each time. Up to
+// QueueSize alerts wait meanwhile.
+type DeliveryOptions struct {
+	Timeout    string `yaml:"timeout" json:"timeout,omitempty"`
+	MaxRetries *int   `yaml:"max_retries" json:"max_retries,omitempty"`
+	Backoff    string `yaml:"backoff" json:"backoff,omitempty"`
+	QueueSize  int    `yaml:"queue_size" json:"queue_size,omitempty"`
+}
+
+func (d DeliveryOptions) validate() error {
+	for _, value := range []string{d.Timeout, d.Backoff} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+	}
+	if d.MaxRetries != nil && *d.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+	if d.QueueSize < 0 {
+		return fmt.Errorf("queue_size must not be negative")
+	}
+	return nil
+}
+
+// Delivery returns the retry options of the output's type.
+func (o Output) Delivery() DeliveryOptions {
+	switch {
+	case o.Webhook != nil:
+		return o.Webhook.DeliveryOptions
+	case o.Email != nil:
+		return o.Email.DeliveryOptions
+	case o.Syslog != nil:
+		return o.Syslog.DeliveryOptions
+	}
+	return DeliveryOptions{}
+}
+
+// WebhookOutput POSTs alerts to URL. Format picks a ready payload (json,
+// slack, mattermost, teams); Template, a Go text/template over the alert,
+// replaces it. With a Secret the body is signed with HMAC-SHA256.
+type WebhookOutput struct {
+	URL      string            `yaml:"url" json:"url"`
+	Format   string            `yaml:"format" json:"format,omitempty"`
+	Template string            `yaml:"template" json:"template,omitempty"`
+	Headers  map[string]string `yaml:"headers" json:"headers,omitempty"`
+	Secret   string            `yaml:"secret" json:"-"`
+
+	DeliveryOptions `yaml:",inline"`
+}
+
+// EmailOutput mails alerts over SMTP. Alerts at ImmediateSeverity or above
+// are mailed at once; the rest are collected into a digest mailed every
+// DigestInterval, optionally aligned to the DigestAt time of day. Each
+// recipient group has its own severity and rule filters.
+type EmailOutput struct {
+	Host               string           `yaml:"host" json:"host"`
+	Port               int              `yaml:"port" json:"port,omitempty"`
+	Username           string           `yaml:"username" json:"username,omitempty"`
+	Password           string           `yaml:"password" json:"-"`
+	From               string           `yaml:"from" json:"from"`
+	TLS                string           `yaml:"tls" json:"tls,omitempty"`
+	InsecureSkipVerify bool             `yaml:"insecure_skip_verify" json:"insecure_skip_verify,omitempty"`
+	Recipients         []EmailRecipient `yaml:"recipients" json:"recipients"`
+	ImmediateSeverity  string           `yaml:"immediate_severity" json:"immediate_severity,omitempty"`
+	DigestInterval     string           `yaml:"digest_interval" json:"digest_interval,omitempty"`
+	DigestAt           string           `yaml:"digest_at" json:"digest_at,omitempty"`
+	SubjectPrefix      string           `yaml:"subject_prefix" json:"subject_prefix,omitempty"`
+
+	DeliveryOptions `yaml:",inline"`
+}
+
+// SyslogOutput forwards alerts to a SIEM as RFC 5424 syslog messages with a
+// CEF or LEEF payload.
+type SyslogOutput struct {
+	Address            string `yaml:"address" json:"address"`
+	Protocol           string `yaml:"protocol" json:"protocol,omitempty"`
+	Framing            string `yaml:"framing" json:"framing,omitempty"`
+	Format             string `yaml:"format" json:"format,omitempty"`
+	Facility           string `yaml:"facility" json:"facility,omitempty"`
+	AppName            string `yaml:"app_name" json:"app_name,omitempty"`
+	Hostname           string `yaml:"hostname" json:"hostname,omitempty"`
+	TLSCA              string `yaml:"tls_ca" json:"tls_ca,omitempty"`
+	TLSCert            string `yaml:"tls_cert" json:"tls_cert,omitempty"`
+	TLSKey             string `yaml:"tls_key" json:"tls_key,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify,omitempty"`
+
+	DeliveryOptions `yaml:",inline"`
+}
+
+type EmailRecipient struct {
+	To          []string `yaml:"to" json:"to"`
+	MinSeverity string   `yaml:"min_severity" json:"min_severity,omitempty"`
+	Rules       []string `yaml:"rules" json:"rules,omitempty"`
+}
+
+// severityNames are the severities min_severity accepts, in either language.
+var severityNames = map[string]bool{
+	"critical": true, "kritik": true,
+	"high": true, "yüksek": true,
+	"medium": true, "orta": true,
+	"low": true, "düşük": true,
+}
+
+func validateOutput(output Output) error {
+	if output.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if output.MinSeverity != "" && !severityNames[strings.ToLower(output.MinSeverity)] {
+		return fmt.Errorf("unknown min_severity %q", output.MinSeverity)
+	}
+	switch output.Type {
+	case "webhook":
+		return validateWebhook(output.Webhook)
+	case "email":
+		return validateEmail(output.Email)
+	case "syslog":
+		return validateSyslogOutput(output.Syslog)
+	default:
+		return fmt.Errorf("unsupported output type %q", output.Type)
+	}
+}
+
+func validateWebhook(webhook *WebhookOutput) error {
+	if webhook == nil || webhook.URL == "" {
+		return fmt.Errorf("webhook url is required")
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid webhook url %q", webhook.URL)
+	}
+	switch webhook.Format {
+	case "", "json", "slack", "mattermost", "teams":
+	default:
+		return fmt.Errorf("unsupported webhook format %q", webhook.Format)
+	}
+	if webhook.Template != "" {
+		if _, err := template.New("webhook").Funcs(TemplateFuncs).Parse(webhook.Template); err != nil {
+			return fmt.Errorf("invalid webhook template: %w", err)
+		}
+	}
+	return webhook.validate()
+}
+
+func validateEmail(email *EmailOutput) error {
+	if email == nil || email.Host == "" {
+		return fmt.Errorf("email host is required")
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		return fmt.Errorf("invalid from address %q", email.From)
+	}
+	switch email.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("unsupported email tls mode %q", email.TLS)
+	}
+	if len(email.Recipients) == 0 {
+		return fmt.Errorf("at least one recipient group is required")
+	}
+	for _, recipient := range email.Recipients {
+		if len(recipient.To) == 0 {
+			return fmt.Errorf("recipient group without addresses")
+		}
+		for _, to := range recipient.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid recipient address %q", to)
+			}
+		}
+		if recipient.MinSeverity != "" && !severityNames[strings.ToLower(recipient.MinSeverity)] {
+			return fmt.Errorf("unknown recipient min_severity %q", recipient.MinSeverity)
+		}
+	}
+	if email.ImmediateSeverity != "" && !severityNames[strings.ToLower(email.ImmediateSeverity)] {
+		return fmt.Errorf("unknown immediate_severity %q", email.ImmediateSeverity)
+	}
+	if email.DigestInterval != "" {
+		if interval, err := time.ParseDuration(email.DigestInterval); err != nil || interval < time.Minute {
+			return fmt.Errorf("invalid digest_interval %q", email.DigestInterval)
+		}
+	}
+	if email.DigestAt != "" {
+		if _, err := time.Parse("15:04", email.DigestAt); err != nil {
+			return fmt.Errorf("invalid digest_at %q, expected HH:MM", email.DigestAt)
+		}
+	}
+	return email.validate()
+}
+
+func (m *Manager) GetEnabledOutputs() []Output {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var enabled []Output
+	for _, output := range m.config.Outputs {
+		if output.Enabled {
+			enabled = append(enabled, output)
+		}
+	}
+	return enabled
+}
+
+// GetOutput returns a configured output by name, enabled or not.
+func (m *Manager) GetOutput(name string) (Output, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, output := range m.config.Outputs {
+		if output.Name == name {
+			return output, true
+		}
+	}
+	return Output{}, false
+}
+
+func validateSyslogOutput(output *SyslogOutput) error {
+	if output == nil || output.Address == "" {
+		return fmt.Errorf("syslog address is required")
+	}
+	if _, _, err := net.SplitHostPort(output.Address); err != nil {
+		return fmt.Errorf("invalid syslog address %q: %w", output.Address, err)
+	}
+	switch output.Protocol {
+	case "", "udp", "tcp", "tls":
+	default:
+		return fmt.Errorf("unsupported syslog protocol %q", output.Protocol)
+	}
+	switch output.Framing {
+	case "", "octet-counting", "newline":
+	default:
+		return fmt.Errorf("unsupported syslog framing %q", output.Framing)
+	}
+	switch output.Format {
+	case "", "cef", "leef":
+	default:
+		return fmt.Errorf("unsupported syslog format %q", output.Format)
+	}
+	if output.Facility != "" {
+		if _, ok := syslog.FacilityCode(output.Facility); !ok {
+			return fmt.Errorf("unknown syslog facility %q", output.Facility)
+		}
+	}
+	if (output.TLSCert == "") != (output.TLSKey == "") {
+		return fmt.Errorf("tls_cert and tls_key must be given together")
+	}
+	return output.validate()
+}
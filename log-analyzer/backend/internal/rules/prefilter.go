@@ -0,0 +1,226 @@
+package rules
+
+import (
+	"regexp/syntax"
+	"time"
+	"unicode"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+// minLiteralLen is the shortest literal worth using as a prefilter; shorter
+// ones match nearly every line and only cost time.
+const minLiteralLen = 3
+
+// requiredLiterals returns lowercase literals of which every match of re
+// must contain at least one. ok is false when no such set could be found,
+// in which case the rule has to be evaluated on every line.
+func requiredLiterals(re *syntax.Regexp) (literals []string, ok bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		literal := foldSafeLiteral(re)
+		if len(literal) < minLiteralLen {
+			return nil, false
+		}
+		return []string{literal}, true
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min == 0 {
+			return nil, false
+		}
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpConcat:
+		// Any child's set is a valid filter for the whole; keep the most
+		// selective one.
+		var best []string
+		for _, sub := range re.Sub {
+			set, ok := requiredLiterals(sub)
+			if ok && betterLiterals(set, best) {
+				best = set
+			}
+		}
+		return best, best != nil
+	case syntax.OpAlternate:
+		var union []string
+		for _, sub := range re.Sub {
+			set, ok := requiredLiterals(sub)
+			if !ok {
+				return nil, false
+			}
+			union = append(union, set...)
+		}
+		return union, true
+	}
+	return nil, false
+}
+
+// betterLiterals prefers sets whose shortest literal is longer, then sets
+// with fewer alternatives.
+func betterLiterals(a, b []string) bool {
+	if b == nil {
+		return true
+	}
+	minA, minB := shortest(a), shortest(b)
+	if minA != minB {
+		return minA > minB
+	}
+	return len(a) < len(b)
+}
+
+func shortest(set []string) int {
+	n := -1
+	for _, s := range set {
+		if n < 0 || len(s) < n {
+			n = len(s)
+		}
+	}
+	return n
+}
+
+// foldSafeLiteral lowercases a literal for the ASCII case-insensitive
+// automaton. For (?i) literals, runes whose case folding reaches outside
+// ASCII (k and the Kelvin sign, s and the long s, any non-ASCII letter)
+// cannot be compared byte-wise, so only the longest run without them is
+// kept; every match still contains that run.
+func foldSafeLiteral(re *syntax.Regexp) string {
+	fold := re.Flags&syntax.FoldCase != 0
+	var best, current []byte
+	flush := func() {
+		if len(current) > len(best) {
+			best = append(best[:0], current...)
+		}
+		current = current[:0]
+	}
+	for _, r := range re.Rune {
+		if fold && !asciiFoldOnly(r) {
+			flush()
+			continue
+		}
+		current = appendLower(current, r)
+	}
+	flush()
+	return string(best)
+}
+
+func asciiFoldOnly(r rune) bool {
+	if r >= unicode.MaxASCII {
+		return unicode.SimpleFold(r) == r
+	}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func appendLower(buf []byte, r rune) []byte {
+	if r >= 'A' && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+	return append(buf, string(r)...)
+}
+
+// matchEngine evaluates the enabled rules of a config. Rules with required
+// literals are only run when an Aho-Corasick pass over the matched field
+// finds one of them; the rest are run on every entry. Results are identical
+// to evaluating every rule in order.
+type matchEngine struct {
+	rules       []*Rule
+	fields      []*fieldFilter
+	prefiltered int
+}
+
+// fieldFilter holds the automaton for the rules that look at one field.
+type fieldFilter struct {
+	field       string
+	ac          *ahoCorasick
+	literalRule []int
+	always      []int
+}
+
+func newMatchEngine(ruleList []Rule) *matchEngine {
+	e := &matchEngine{}
+	byField := make(map[string]*fieldFilter)
+	literalsByField := make(map[string][]string)
+	for i := range ruleList {
+		rule := &ruleList[i]
+		if !rule.Enabled || rule.regex == nil {
+			continue
+		}
+		index := len(e.rules)
+		e.rules = append(e.rules, rule)
+
+		filter, ok := byField[rule.Field]
+		if !ok {
+			filter = &fieldFilter{field: rule.Field}
+			byField[rule.Field] = filter
+			e.fields = append(e.fields, filter)
+		}
+		literals, ok := ruleLiterals(rule)
+		if !ok {
+			filter.always = append(filter.always, index)
+			continue
+		}
+		e.prefiltered++
+		for _, literal := range literals {
+			literalsByField[rule.Field] = append(literalsByField[rule.Field], literal)
+			filter.literalRule = append(filter.literalRule, index)
+		}
+	}
+	for _, filter := range e.fields {
+		if literals := literalsByField[filter.field]; len(literals) > 0 {
+			filter.ac = newAhoCorasick(literals)
+		}
+	}
+	return e
+}
+
+func ruleLiterals(rule *Rule) ([]string, bool) {
+	re, err := syntax.Parse(rule.Pattern, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	return requiredLiterals(re.Simplify())
+}
+
+// candidates marks the rules worth evaluating for entry.
+func (e *matchEngine) candidates(entry *parser.Entry) []bool {
+	marked := make([]bool, len(e.rules))
+	for _, filter := range e.fields {
+		for _, index := range filter.always {
+			marked[index] = true
+		}
+		if filter.ac != nil {
+			filter.ac.scan(entry.Field(filter.field), func(id int32) {
+				marked[filter.literalRule[id]] = true
+			})
+		}
+	}
+	return marked
+}
+
+// match returns the matching rules in config order. visit, if set, is called
+// for every rule actually evaluated.
+func (e *matchEngine) match(logFile *LogFile, entry *parser.Entry, visit func(rule *Rule, start time.Time)) []Rule {
+	marked := e.candidates(entry)
+	var matches []Rule
+	for i, rule := range e.rules {
+		if !marked[i] || (logFile != nil && !rule.AppliesTo(*logFile)) {
+			continue
+		}
+		var start time.Time
+		if visit != nil {
+			start = time.Now()
+		}
+		matched := rule.Match(entry)
+		if visit != nil {
+			visit(rule, start)
+		}
+		if matched {
+			matches = append(matches, *rule)
+		}
+	}
+	return matches
+}
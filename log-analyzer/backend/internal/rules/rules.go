@@ -3,8 +3,15 @@ package rules
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/journal"
+	"log-analyzer/backend/internal/parser"
 
 	"gopkg.in/yaml.v3"
 )
@@ -16,39 +23,305 @@ type Rule struct {
 	Severity      string `yaml:"severity" json:"severity"`
 	Description   string `yaml:"description" json:"description"`
 	Enabled       bool   `yaml:"enabled" json:"enabled"`
+	Field         string `yaml:"field" json:"field,omitempty"`
+	LogTypes      []string `yaml:"log_types" json:"log_types,omitempty"`
+	Paths         []string `yaml:"paths" json:"paths,omitempty"`
+	ShouldMatch    []string `yaml:"should_match" json:"should_match,omitempty"`
+	ShouldNotMatch []string `yaml:"should_not_match" json:"should_not_match,omitempty"`
+	Dedup         *DedupConfig `yaml:"dedup" json:"dedup,omitempty"`
+	Actions       []string `yaml:"actions" json:"actions,omitempty"`
+	// Conditions must all hold besides the pattern, e.g. "geo.country not in [TR]".
+	Conditions    []string `yaml:"conditions" json:"conditions,omitempty"`
 	regex         *regexp.Regexp
 	excludeRegex  *regexp.Regexp
+	conditions    []condition
+	// geo is the GeoIP database geo.* conditions look addresses up in.
+	geo           *geoip.DB
 }
 
 type LogFile struct {
-	Path    string `yaml:"path" json:"path"`
-	Type    string `yaml:"type" json:"type"`
-	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Path      string           `yaml:"path" json:"path"`
+	Type      string           `yaml:"type" json:"type"`
+	Enabled   bool             `yaml:"enabled" json:"enabled"`
+	Format    string           `yaml:"format" json:"format,omitempty"`
+	Multiline *MultilineConfig `yaml:"multiline" json:"multiline,omitempty"`
+}
+
+const (
+	DefaultMultilineMaxLines     = 500
+	DefaultMultilineFlushTimeout = 3 * time.Second
+)
+
+// MultilineConfig groups continuation lines (stack traces, PostgreSQL
+// STATEMENT/DETAIL lines, ...) into a single event before rule matching.
+// A line belongs to the current event when it matches ContinuationPattern,
+// or when StartPattern is set and the line does not match it.
+type MultilineConfig struct {
+	StartPattern        string `yaml:"start_pattern" json:"start_pattern,omitempty"`
+	ContinuationPattern string `yaml:"continuation_pattern" json:"continuation_pattern,omitempty"`
+	MaxLines            int    `yaml:"max_lines" json:"max_lines,omitempty"`
+	FlushTimeout        string `yaml:"flush_timeout" json:"flush_timeout,omitempty"`
+	startRegex          *regexp.Regexp
+	continuationRegex   *regexp.Regexp
+	flushTimeout        time.Duration
+}
+
+func (c *MultilineConfig) compile() error {
+	if c.StartPattern == "" && c.ContinuationPattern == "" {
+		return fmt.Errorf("start_pattern or continuation_pattern is required")
+	}
+	if c.StartPattern != "" {
+		regex, err := regexp.Compile(c.StartPattern)
+		if err != nil {
+			return fmt.Errorf("invalid start pattern: %w", err)
+		}
+		c.startRegex = regex
+	}
+	if c.ContinuationPattern != "" {
+		regex, err := regexp.Compile(c.ContinuationPattern)
+		if err != nil {
+			return fmt.Errorf("invalid continuation pattern: %w", err)
+		}
+		c.continuationRegex = regex
+	}
+	c.flushTimeout = DefaultMultilineFlushTimeout
+	if c.FlushTimeout != "" {
+		timeout, err := time.ParseDuration(c.FlushTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid flush timeout: %w", err)
+		}
+		c.flushTimeout = timeout
+	}
+	if c.MaxLines <= 0 {
+		c.MaxLines = DefaultMultilineMaxLines
+	}
+	return nil
+}
+
+func (c *MultilineConfig) IsContinuation(line string) bool {
+	if c.continuationRegex != nil && c.continuationRegex.MatchString(line) {
+		return true
+	}
+	return c.startRegex != nil && !c.startRegex.MatchString(line)
+}
+
+func (c *MultilineConfig) Timeout() time.Duration {
+	if c.flushTimeout <= 0 {
+		return DefaultMultilineFlushTimeout
+	}
+	return c.flushTimeout
+}
+
+// Input is a non-file log source such as a syslog listener.
+type Input struct {
+	Name    string        `yaml:"name" json:"name"`
+	Type    string        `yaml:"type" json:"type"`
+	LogType string        `yaml:"log_type" json:"log_type,omitempty"`
+	Enabled bool          `yaml:"enabled" json:"enabled"`
+	Syslog  *SyslogInput  `yaml:"syslog" json:"syslog,omitempty"`
+	Journal *JournalInput `yaml:"journal" json:"journal,omitempty"`
+}
+
+type SyslogInput struct {
+	Protocol    string `yaml:"protocol" json:"protocol"`
+	Address     string `yaml:"address" json:"address"`
+	Framing     string `yaml:"framing" json:"framing,omitempty"`
+	TLSCert     string `yaml:"tls_cert" json:"tls_cert,omitempty"`
+	TLSKey      string `yaml:"tls_key" json:"tls_key,omitempty"`
+	TLSClientCA string `yaml:"tls_client_ca" json:"tls_client_ca,omitempty"`
+}
+
+// JournalInput reads a journalctl stream from a file or named pipe (Path) or
+// from a spawned command. Without either, `journalctl -f -o json` is run.
+type JournalInput struct {
+	Format  string   `yaml:"format" json:"format,omitempty"`
+	Path    string   `yaml:"path" json:"path,omitempty"`
+	Command []string `yaml:"command" json:"command,omitempty"`
+}
+
+// Settings holds config-wide behaviour switches.
+type Settings struct {
+	// RequirePassingTests makes LoadConfig refuse a config whose embedded
+	// should_match/should_not_match examples fail.
+	RequirePassingTests bool `yaml:"require_passing_tests" json:"require_passing_tests"`
+	// StateDir holds runtime state such as rule statistics; relative to the
+	// config file, "state" by default.
+	StateDir string `yaml:"state_dir" json:"state_dir,omitempty"`
+	// Dedup is the grouping used by rules without their own dedup block.
+	Dedup *DedupConfig `yaml:"dedup" json:"dedup,omitempty"`
+	// Cases correlates live alerts into cases by shared entities.
+	Cases *CaseConfig `yaml:"cases" json:"cases,omitempty"`
+	// DryRunActions logs every response action instead of running it.
+	DryRunActions bool `yaml:"dry_run_actions" json:"dry_run_actions,omitempty"`
 }
 
 type Config struct {
-	Rules    []Rule    `yaml:"rules" json:"rules"`
-	LogFiles []LogFile `yaml:"log_files" json:"log_files"`
+	Settings    Settings     `yaml:"settings" json:"settings"`
+	Rules       []Rule       `yaml:"rules" json:"rules"`
+	LogFiles    []LogFile    `yaml:"log_files" json:"log_files"`
+	Inputs      []Input      `yaml:"inputs" json:"inputs"`
+	Outputs     []Output     `yaml:"outputs" json:"outputs"`
+	Actions     []Action     `yaml:"actions" json:"actions"`
+	ThreatIntel *ThreatIntel `yaml:"threat_intel" json:"threat_intel,omitempty"`
+	GeoIP       *GeoIP       `yaml:"geoip" json:"geoip,omitempty"`
+	DNS         *DNS         `yaml:"dns" json:"dns,omitempty"`
 }
 
 type Manager struct {
-	config     *Config
-	configPath string
-	mu         sync.RWMutex
+	config       *Config
+	configPath   string
+	mu           sync.RWMutex
+	stats        *statsTracker
+	suppressions *suppressionList
+	engine       *matchEngine
+	geo          *geoip.DB
 }
 
 func NewManager(configPath string) (*Manager, error) {
 	m := &Manager{
-		configPath: configPath,
+		configPath:   configPath,
+		stats:        newStatsTracker(),
+		suppressions: &suppressionList{},
 	}
 	
 	if err := m.LoadConfig(); err != nil {
 		return nil, err
 	}
+	// Unreadable statistics are not worth refusing to start over; counting
+	// simply starts again. A broken suppression list is, since starting
+	// without it would bring back every silenced alert.
+	m.loadStats()
+	if err := m.loadSuppressions(); err != nil {
+		return nil, err
+	}
 	
 	return m, nil
 }
 
+// Compile prepares the rule's patterns for matching.
+func (r *Rule) Compile() error {
+	for _, pattern := range r.Paths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q for rule %s: %w", pattern, r.Name, err)
+		}
+	}
+	regex, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern for rule %s: %w", r.Name, err)
+	}
+	r.regex = regex
+	r.excludeRegex = nil
+	if r.ExcludePattern != "" {
+		excludeRegex, err := regexp.Compile(r.ExcludePattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude pattern for rule %s: %w", r.Name, err)
+		}
+		r.excludeRegex = excludeRegex
+	}
+	r.conditions = nil
+	for _, text := range r.Conditions {
+		c, err := parseCondition(text)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		r.conditions = append(r.conditions, c)
+	}
+	if r.Dedup != nil {
+		if err := r.Dedup.compile(); err != nil {
+			return fmt.Errorf("invalid dedup config for rule %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether the rule is scoped to logFile. A rule without
+// log_types and paths applies everywhere; otherwise the source must have one
+// of the log types or match one of the path globs. Globs without a slash are
+// matched against the file name only. A source of unknown type, such as an
+// ad-hoc file, is never ruled out by log_types.
+func (r *Rule) AppliesTo(logFile LogFile) bool {
+	if len(r.LogTypes) == 0 && len(r.Paths) == 0 {
+		return true
+	}
+	if logFile.Type == "" && len(r.LogTypes) > 0 {
+		return true
+	}
+	for _, logType := range r.LogTypes {
+		if strings.EqualFold(logType, logFile.Type) {
+			return true
+		}
+	}
+	for _, pattern := range r.Paths {
+		target := logFile.Path
+		if !strings.Contains(pattern, "/") {
+			target = filepath.Base(target)
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a compiled rule matches the entry, ignoring whether
+// the rule is enabled.
+func (r *Rule) Match(entry *parser.Entry) bool {
+	if r.regex == nil {
+		return false
+	}
+	value := entry.Field(r.Field)
+	if !r.regex.MatchString(value) {
+		return false
+	}
+	if r.excludeRegex != nil && r.excludeRegex.MatchString(value) {
+		return false
+	}
+	for _, c := range r.conditions {
+		if !c.holds(entry, r.geo) {
+			return false
+		}
+	}
+	return true
+}
+
+// Captures returns the non-empty named groups of the pattern, such as
+// (?P<src_ip>...), for an entry the rule matched.
+func (r *Rule) Captures(entry *parser.Entry) map[string]string {
+	if r.regex == nil || r.regex.NumSubexp() == 0 {
+		return nil
+	}
+	match := r.regex.FindStringSubmatch(entry.Field(r.Field))
+	var captures map[string]string
+	for i, name := range r.regex.SubexpNames() {
+		if name == "" || i >= len(match) || match[i] == "" {
+			continue
+		}
+		if captures == nil {
+			captures = make(map[string]string)
+		}
+		captures[name] = match[i]
+	}
+	return captures
+}
+
+// WithCaptures adds the named captures of the matched rules to the fields of
+// an alert. Fields the source already has and captures of earlier rules are
+// kept.
+func WithCaptures(matched []Rule, entry *parser.Entry, fields map[string]string) map[string]string {
+	for i := range matched {
+		for name, value := range matched[i].Captures(entry) {
+			if _, ok := fields[name]; ok {
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
 func (m *Manager) LoadConfig() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -64,22 +337,146 @@ func (m *Manager) LoadConfig() error {
 	}
 	for i := range config.Rules {
 		if config.Rules[i].Enabled {
-			regex, err := regexp.Compile(config.Rules[i].Pattern)
-			if err != nil {
-				return fmt.Errorf("invalid regex pattern for rule %s: %w", config.Rules[i].Name, err)
+			if err := config.Rules[i].Compile(); err != nil {
+				return err
+			}
+		}
+	}
+	if config.Settings.Dedup != nil {
+		if err := config.Settings.Dedup.compile(); err != nil {
+			return fmt.Errorf("invalid dedup settings: %w", err)
+		}
+	}
+	if config.Settings.Cases != nil {
+		if err := config.Settings.Cases.compile(); err != nil {
+			return fmt.Errorf("invalid cases settings: %w", err)
+		}
+	}
+	if config.Settings.RequirePassingTests {
+		var failed []string
+		for _, result := range RunTests(config.Rules) {
+			if result.Failed() {
+				failed = append(failed, result.Rule)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("embedded rule tests failed: %s", strings.Join(failed, ", "))
+		}
+	}
+	for i := range config.LogFiles {
+		if format := config.LogFiles[i].Format; format != "" && format != "plain" && !journal.IsFormat(format) {
+			return fmt.Errorf("unsupported format %q for %s", format, config.LogFiles[i].Path)
+		}
+		if config.LogFiles[i].Multiline != nil {
+			if err := config.LogFiles[i].Multiline.compile(); err != nil {
+				return fmt.Errorf("invalid multiline config for %s: %w", config.LogFiles[i].Path, err)
+			}
+		}
+	}
+	for i, input := range config.Inputs {
+		if err := validateInput(input); err != nil {
+			return fmt.Errorf("invalid input %d (%s): %w", i+1, input.Name, err)
+		}
+	}
+	outputNames := make(map[string]bool)
+	for i, output := range config.Outputs {
+		if err := validateOutput(output); err != nil {
+			return fmt.Errorf("invalid output %d (%s): %w", i+1, output.Name, err)
+		}
+		if outputNames[output.Name] {
+			return fmt.Errorf("duplicate output name %q", output.Name)
+		}
+		outputNames[output.Name] = true
+	}
+	actionNames := make(map[string]bool)
+	for i, action := range config.Actions {
+		if err := validateAction(action); err != nil {
+			return fmt.Errorf("invalid action %d (%s): %w", i+1, action.Name, err)
+		}
+		if actionNames[action.Name] {
+			return fmt.Errorf("duplicate action name %q", action.Name)
+		}
+		actionNames[action.Name] = true
+	}
+	for _, rule := range config.Rules {
+		for _, name := range rule.Actions {
+			if !actionNames[name] {
+				return fmt.Errorf("rule %s refers to unknown action %q", rule.Name, name)
 			}
-			config.Rules[i].regex = regex
-			if config.Rules[i].ExcludePattern != "" {
-				excludeRegex, err := regexp.Compile(config.Rules[i].ExcludePattern)
-				if err != nil {
-					return fmt.Errorf("invalid exclude pattern for rule %s: %w", config.Rules[i].Name, err)
+		}
+	}
+	if config.ThreatIntel != nil {
+		if err := validateThreatIntel(config.ThreatIntel); err != nil {
+			return fmt.Errorf("invalid threat_intel: %w", err)
+		}
+		for _, feed := range config.ThreatIntel.Feeds {
+			for _, name := range feed.Actions {
+				if !actionNames[name] {
+					return fmt.Errorf("feed %s refers to unknown action %q", feed.Name, name)
 				}
-				config.Rules[i].excludeRegex = excludeRegex
 			}
 		}
 	}
+	if config.DNS != nil && config.DNS.Enabled {
+		if err := validateDNS(*config.DNS); err != nil {
+			return fmt.Errorf("invalid dns: %w", err)
+		}
+	}
+	var geo *geoip.DB
+	if config.GeoIP != nil {
+		if geo, err = openGeoIP(config.GeoIP); err != nil {
+			return fmt.Errorf("invalid geoip: %w", err)
+		}
+	}
+	for i := range config.Rules {
+		if geo == nil && config.Rules[i].Enabled && config.Rules[i].usesGeo() {
+			return fmt.Errorf("rule %s uses geo fields but no geoip databases are configured", config.Rules[i].Name)
+		}
+		config.Rules[i].geo = geo
+	}
 	
 	m.config = &config
+	m.engine = newMatchEngine(config.Rules)
+	m.geo = geo
+	return nil
+}
+
+func validateInput(input Input) error {
+	if input.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch input.Type {
+	case "syslog":
+		if input.Syslog == nil || input.Syslog.Address == "" {
+			return fmt.Errorf("syslog address is required")
+		}
+		switch input.Syslog.Protocol {
+		case "", "udp", "tcp":
+		case "tls":
+			if input.Syslog.TLSCert == "" || input.Syslog.TLSKey == "" {
+				return fmt.Errorf("tls_cert and tls_key are required for tls")
+			}
+		default:
+			return fmt.Errorf("unsupported syslog protocol %q", input.Syslog.Protocol)
+		}
+		switch input.Syslog.Framing {
+		case "", "auto", "octet-counting", "newline":
+		default:
+			return fmt.Errorf("unsupported syslog framing %q", input.Syslog.Framing)
+		}
+	case "journal":
+		if input.Journal == nil {
+			return nil
+		}
+		if input.Journal.Format != "" && !journal.IsFormat(input.Journal.Format) {
+			return fmt.Errorf("unsupported journal format %q", input.Journal.Format)
+		}
+		if input.Journal.Path != "" && len(input.Journal.Command) > 0 {
+			return fmt.Errorf("journal path and command are mutually exclusive")
+		}
+	default:
+		return fmt.Errorf("unsupported input type %q", input.Type)
+	}
 	return nil
 }
 
@@ -114,6 +511,18 @@ func (m *Manager) GetLogFiles() []LogFile {
 	return files
 }
 
+func (m *Manager) GetLogFile(path string) (LogFile, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	for _, file := range m.config.LogFiles {
+		if file.Path == path {
+			return file, true
+		}
+	}
+	return LogFile{Path: path}, false
+}
+
 func (m *Manager) GetEnabledLogFiles() []LogFile {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -127,19 +536,86 @@ func (m *Manager) GetEnabledLogFiles() []LogFile {
 	return enabled
 }
 
-func (m *Manager) MatchRules(line string) []Rule {
+func (m *Manager) GetInputs() []Input {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	
+	inputs := make([]Input, len(m.config.Inputs))
+	copy(inputs, m.config.Inputs)
+	return inputs
+}
+
+func (m *Manager) GetEnabledInputs() []Input {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	var enabled []Input
+	for _, input := range m.config.Inputs {
+		if input.Enabled {
+			enabled = append(enabled, input)
+		}
+	}
+	return enabled
+}
+
+// MatchRules matches a single line as if it were read from logFile, without
+// recording statistics. A zero LogFile ignores rule scopes.
+func (m *Manager) MatchRules(logFile LogFile, line string) []Rule {
+	entry := parser.NewEntry(line)
 	var matches []Rule
-	for _, rule := range m.config.Rules {
-		if rule.Enabled && rule.regex != nil && rule.regex.MatchString(line) {
-			if rule.excludeRegex != nil && rule.excludeRegex.MatchString(line) {
-				continue
-			}
+	for _, rule := range m.MatchEntry(entry) {
+		if (logFile.Path == "" && logFile.Type == "") || rule.AppliesTo(logFile) {
 			matches = append(matches, rule)
 		}
 	}
 	return matches
 }
 
+// MatchEntryFrom matches an entry read live from logFile and records per-rule
+// statistics. MatchEntry is the side-effect free variant for ad-hoc checks.
+// Rules skipped by the literal prefilter are not counted as evaluated.
+// Matches silenced by a suppression are counted but not returned.
+func (m *Manager) MatchEntryFrom(logFile LogFile, entry *parser.Entry) []Rule {
+	// The engine only refers to the config it was built from, which is never
+	// modified, so matching does not need to hold the lock.
+	m.mu.RLock()
+	engine, geo := m.engine, m.geo
+	m.mu.RUnlock()
+	
+	var evaluated []string
+	var durations []time.Duration
+	matches := engine.match(&logFile, entry, func(rule *Rule, start time.Time) {
+		evaluated = append(evaluated, rule.Name)
+		durations = append(durations, time.Since(start))
+	})
+	
+	seen := entry.Timestamp
+	if seen.IsZero() {
+		seen = time.Now()
+	}
+	kept, suppressed := m.suppressions.filter(entry, matches, true, geo)
+	m.stats.record(logFile.Path, seen, evaluated, durations, matches, suppressed)
+	return kept
+}
+
+// MatchBatchEntry matches an entry of a one-off analysis such as `cli
+// analyze` or POST /api/analyze. Suppressions apply, but neither rule nor
+// suppression counters change, so analysing a file again does not count
+// its lines twice.
+func (m *Manager) MatchBatchEntry(logFile LogFile, entry *parser.Entry) []Rule {
+	m.mu.RLock()
+	engine, geo := m.engine, m.geo
+	m.mu.RUnlock()
+	
+	kept, _ := m.suppressions.filter(entry, engine.match(&logFile, entry, nil), false, geo)
+	return kept
+}
+
+func (m *Manager) MatchEntry(entry *parser.Entry) []Rule {
+	m.mu.RLock()
+	engine := m.engine
+	m.mu.RUnlock()
+	
+	return engine.match(nil, entry, nil)
+}
+
@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"strings"
+
+	"log-analyzer/backend/internal/journal"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/syslog"
+)
+
+// TestFailure is a single embedded example line that did not behave as the
+// rule declares.
+type TestFailure struct {
+	Line          string `json:"line"`
+	ExpectedMatch bool   `json:"expectedMatch"`
+}
+
+// TestResult summarises the embedded tests of one rule.
+type TestResult struct {
+	Rule     string        `json:"rule"`
+	Enabled  bool          `json:"enabled"`
+	Passed   int           `json:"passed"`
+	Failures []TestFailure `json:"failures,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (r TestResult) Failed() bool {
+	return len(r.Failures) > 0 || r.Error != ""
+}
+
+// RunTests runs the should_match and should_not_match examples of every rule
+// that has them. Disabled rules are tested too, so they can be fixed before
+// being turned on.
+func RunTests(ruleList []Rule) []TestResult {
+	var results []TestResult
+	for _, rule := range ruleList {
+		if len(rule.ShouldMatch) == 0 && len(rule.ShouldNotMatch) == 0 {
+			continue
+		}
+		results = append(results, TestRule(rule))
+	}
+	return results
+}
+
+func TestRule(rule Rule) TestResult {
+	result := TestResult{Rule: rule.Name, Enabled: rule.Enabled}
+	if rule.regex == nil {
+		if err := rule.Compile(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	// Examples go through the same engine live matching uses, including
+	// the literal prefilter; disabled rules are tested as if enabled.
+	enabled := rule
+	enabled.Enabled = true
+	engine := newMatchEngine([]Rule{enabled})
+	check := func(lines []string, expected bool) {
+		for _, line := range lines {
+			matched := len(engine.match(nil, sampleEntry(rule, line), nil)) > 0
+			if matched == expected {
+				result.Passed++
+				continue
+			}
+			result.Failures = append(result.Failures, TestFailure{Line: line, ExpectedMatch: expected})
+		}
+	}
+	check(rule.ShouldMatch, true)
+	check(rule.ShouldNotMatch, false)
+	return result
+}
+
+// sampleEntry builds the entry a source would hand the engine for an example
+// line. Rules on the whole line see a plain file line. Rules with a field
+// selector see a structured record, as only syslog and journal sources fill
+// fields: a journal JSON entry when the line is one, a syslog message
+// otherwise.
+func sampleEntry(rule Rule, line string) *parser.Entry {
+	switch strings.ToLower(rule.Field) {
+	case "", "line":
+		return parser.NewEntry(line)
+	}
+	if strings.HasPrefix(line, "{") {
+		if reader, err := journal.NewReader(strings.NewReader(line), journal.FormatJSON); err == nil {
+			if entry, err := reader.Next(); err == nil {
+				return entry
+			}
+		}
+	}
+	data := line
+	if !strings.HasPrefix(data, "<") {
+		data = "<13>" + data
+	}
+	msg, err := syslog.Parse([]byte(data))
+	if err != nil {
+		return parser.NewEntry(line)
+	}
+	return msg.Entry()
+}
+
+func (m *Manager) RunTests() []TestResult {
+	return RunTests(m.GetRules())
+}
@@ -0,0 +1,268 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const statsFile = "rule_stats.json"
+
+// RuleStats are the hit counters kept for one rule. MatchTime is the total
+// time spent evaluating the rule, whether it matched or not. TotalHits
+// includes SuppressedHits, the matches silenced by a suppression.
+type RuleStats struct {
+	Rule           string           `json:"rule"`
+	Enabled        bool             `json:"enabled"`
+	Severity       string           `json:"severity"`
+	TotalHits      int64            `json:"totalHits"`
+	SuppressedHits int64            `json:"suppressedHits"`
+	HitsPerFile    map[string]int64 `json:"hitsPerFile"`
+	FirstSeen      *time.Time       `json:"firstSeen,omitempty"`
+	LastSeen       *time.Time       `json:"lastSeen,omitempty"`
+	AvgHitsPerHour float64          `json:"avgHitsPerHour"`
+	Evaluations    int64            `json:"evaluations"`
+	MatchTime      time.Duration    `json:"matchTimeNs"`
+}
+
+type statsTracker struct {
+	mu    sync.Mutex
+	rules map[string]*RuleStats
+	dirty bool
+}
+
+func newStatsTracker() *statsTracker {
+	return &statsTracker{rules: make(map[string]*RuleStats)}
+}
+
+func (s *statsTracker) get(name string) *RuleStats {
+	stats, ok := s.rules[name]
+	if !ok {
+		stats = &RuleStats{Rule: name, HitsPerFile: make(map[string]int64)}
+		s.rules[name] = stats
+	}
+	return stats
+}
+
+// record adds the outcome of matching one entry: the time spent on each
+// evaluated rule, which of them matched and which matches were suppressed.
+func (s *statsTracker) record(path string, seen time.Time, evaluated []string, durations []time.Duration, matched, suppressed []Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, name := range evaluated {
+		stats := s.get(name)
+		stats.Evaluations++
+		stats.MatchTime += durations[i]
+	}
+	for _, rule := range matched {
+		stats := s.get(rule.Name)
+		stats.TotalHits++
+		stats.HitsPerFile[path]++
+		if stats.FirstSeen == nil || seen.Before(*stats.FirstSeen) {
+			first := seen
+			stats.FirstSeen = &first
+		}
+		if stats.LastSeen == nil || seen.After(*stats.LastSeen) {
+			last := seen
+			stats.LastSeen = &last
+		}
+	}
+	for _, rule := range suppressed {
+		s.get(rule.Name).SuppressedHits++
+	}
+	s.dirty = true
+}
+
+// StateDir is where runtime state such as rule statistics is kept. Relative
+// paths are resolved against the config file's directory.
+func (m *Manager) StateDir() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dir := m.config.Settings.StateDir
+	if dir == "" {
+		dir = "state"
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(m.configPath), dir)
+	}
+	return dir
+}
+
+// RuleStats returns counters for every configured rule, including rules that
+// never fired, followed by counters of rules no longer in the config.
+func (m *Manager) RuleStats() []RuleStats {
+	ruleList := m.GetRules()
+	m.stats.mu.Lock()
+	defer m.stats.mu.Unlock()
+
+	now := time.Now()
+	var result []RuleStats
+	configured := make(map[string]bool)
+	snapshot := func(stats *RuleStats) RuleStats {
+		copied := *stats
+		copied.HitsPerFile = make(map[string]int64, len(stats.HitsPerFile))
+		for path, hits := range stats.HitsPerFile {
+			copied.HitsPerFile[path] = hits
+		}
+		if stats.FirstSeen != nil {
+			hours := now.Sub(*stats.FirstSeen).Hours()
+			if hours < 1 {
+				hours = 1
+			}
+			copied.AvgHitsPerHour = float64(stats.TotalHits) / hours
+		}
+		return copied
+	}
+	for _, rule := range ruleList {
+		configured[rule.Name] = true
+		stats := snapshot(m.stats.get(rule.Name))
+		stats.Enabled = rule.Enabled
+		stats.Severity = rule.Severity
+		result = append(result, stats)
+	}
+	var removed []RuleStats
+	for name, stats := range m.stats.rules {
+		if !configured[name] {
+			removed = append(removed, snapshot(stats))
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Rule < removed[j].Rule })
+	return append(result, removed...)
+}
+
+func (m *Manager) ResetStats() {
+	m.stats.mu.Lock()
+	defer m.stats.mu.Unlock()
+	m.stats.rules = make(map[string]*RuleStats)
+	m.stats.dirty = true
+}
+
+func (m *Manager) loadStats() error {
+	data, err := os.ReadFile(filepath.Join(m.StateDir(), statsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read rule stats: %w", err)
+	}
+	var saved []RuleStats
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to parse rule stats: %w", err)
+	}
+
+	m.stats.mu.Lock()
+	defer m.stats.mu.Unlock()
+	for i := range saved {
+		if saved[i].HitsPerFile == nil {
+			saved[i].HitsPerFile = make(map[string]int64)
+		}
+		m.stats.rules[saved[i].Rule] = &saved[i]
+	}
+	return nil
+}
+
+// SaveStats writes the rule statistics and suppression hit counters to the
+// state directory if they have changed since the last save.
+func (m *Manager) SaveStats() error {
+	if err := m.SaveSuppressions(); err != nil {
+		return err
+	}
+	m.stats.mu.Lock()
+	if !m.stats.dirty {
+		m.stats.mu.Unlock()
+		return nil
+	}
+	saved := make([]RuleStats, 0, len(m.stats.rules))
+	for _, stats := range m.stats.rules {
+		saved = append(saved, *stats)
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	m.stats.dirty = false
+	m.stats.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode rule stats: %w", err)
+	}
+	return writeState(m.StateDir(), statsFile, data)
+}
+
+// WriteState stores data under name in the state directory, for packages
+// that keep their own runtime state next to the rule statistics.
+func (m *Manager) WriteState(name string, data []byte) error {
+	return writeState(m.StateDir(), name, data)
+}
+
+// ReadState returns a file written by WriteState; a missing file is nil data
+// without error.
+func (m *Manager) ReadState(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(m.StateDir(), name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+const (
+	lockTimeout  = 5 * time.Second
+	staleLockAge = 30 * time.Second
+)
+
+// lockState takes an exclusive lock on a state file that more than one
+// process writes. The lock is a file created next to it; one left behind by
+// a crashed process is taken over once it is staleLockAge old.
+func lockState(dir, name string) (unlock func(), err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state dir: %w", err)
+	}
+	path := filepath.Join(dir, name+".lock")
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", name, err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the %s lock", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeState replaces a state file atomically so a crash never leaves a
+// half-written file behind.
+func writeState(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
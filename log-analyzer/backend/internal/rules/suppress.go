@@ -0,0 +1,356 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/geoip"
+	"log-analyzer/backend/internal/ids"
+	"log-analyzer/backend/internal/parser"
+)
+
+const (
+	suppressionsFile    = "suppressions.json"
+	suppressionHitsFile = "suppression_hits.json"
+)
+
+// Suppression silences matches of known-benign events without touching the
+// rule itself. Every condition that is set must hold: the rule name, the
+// value of a field and a regex over the line. Field may name any entry field
+// or an entity pseudo field such as "ip", "user" or "domain", which are
+// looked up in the line; an ip value may be a CIDR range.
+type Suppression struct {
+	ID        string     `json:"id"`
+	Rule      string     `json:"rule,omitempty"`
+	Field     string     `json:"field,omitempty"`
+	Value     string     `json:"value,omitempty"`
+	Pattern   string     `json:"pattern,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	AddedBy   string     `json:"addedBy,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Hits      int64      `json:"hits,omitempty"`
+	LastHit   *time.Time `json:"lastHit,omitempty"`
+	Expired   bool       `json:"expired,omitempty"`
+
+	regex   *regexp.Regexp
+	network *net.IPNet
+	// pending are the hits counted since the last save.
+	pending int64
+}
+
+// suppressionHits are the saved hit counters of one suppression. They are
+// kept apart from the definitions so counting never rewrites the list.
+type suppressionHits struct {
+	Hits    int64      `json:"hits"`
+	LastHit *time.Time `json:"lastHit,omitempty"`
+}
+
+func (s *Suppression) compile() error {
+	if s.Rule == "" && s.Value == "" && s.Pattern == "" {
+		return fmt.Errorf("suppression needs a rule, a field value or a pattern")
+	}
+	if s.Value != "" && s.Field == "" {
+		return fmt.Errorf("suppression value needs a field")
+	}
+	s.regex, s.network = nil, nil
+	if s.Pattern != "" {
+		regex, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid suppression pattern: %w", err)
+		}
+		s.regex = regex
+	}
+	if strings.EqualFold(s.Field, "ip") && strings.Contains(s.Value, "/") {
+		_, network, err := net.ParseCIDR(s.Value)
+		if err != nil {
+			return fmt.Errorf("invalid suppression network: %w", err)
+		}
+		s.network = network
+	}
+	return nil
+}
+
+func (s *Suppression) expiredAt(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// matches reports whether the suppression silences rule for entry.
+func (s *Suppression) matches(rule string, entry *parser.Entry, geo *geoip.DB) bool {
+	if s.Rule != "" && s.Rule != rule {
+		return false
+	}
+	if s.regex != nil && !s.regex.MatchString(entry.Line) {
+		return false
+	}
+	if s.Field == "" {
+		return true
+	}
+	values := fieldValues(geo, entry, s.Field)
+	if strings.EqualFold(s.Field, "ip") {
+		for _, value := range values {
+			ip := net.ParseIP(value)
+			if ip != nil && (s.network != nil && s.network.Contains(ip) || ip.Equal(net.ParseIP(s.Value))) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(value, s.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+type suppressionList struct {
+	mu    sync.Mutex
+	items []*Suppression
+	dirty bool
+}
+
+// filter drops matches silenced by an active suppression and returns them
+// separately. count records the hits on the suppressions.
+func (l *suppressionList) filter(entry *parser.Entry, matches []Rule, count bool, geo *geoip.DB) (kept, suppressed []Rule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.items) == 0 || len(matches) == 0 {
+		return matches, nil
+	}
+
+	now := time.Now()
+	for _, rule := range matches {
+		silenced := false
+		for _, s := range l.items {
+			if s.expiredAt(now) || !s.matches(rule.Name, entry, geo) {
+				continue
+			}
+			if count {
+				s.Hits++
+				s.pending++
+				hit := now
+				s.LastHit = &hit
+				l.dirty = true
+			}
+			silenced = true
+			break
+		}
+		if silenced {
+			suppressed = append(suppressed, rule)
+		} else {
+			kept = append(kept, rule)
+		}
+	}
+	return kept, suppressed
+}
+
+// Suppressions lists the configured suppressions, expired ones included.
+func (m *Manager) Suppressions() []Suppression {
+	m.suppressions.mu.Lock()
+	defer m.suppressions.mu.Unlock()
+
+	now := time.Now()
+	result := make([]Suppression, 0, len(m.suppressions.items))
+	for _, s := range m.suppressions.items {
+		copied := *s
+		copied.Expired = s.expiredAt(now)
+		result = append(result, copied)
+	}
+	return result
+}
+
+// AddSuppression validates s, assigns it an ID and saves the list.
+func (m *Manager) AddSuppression(s Suppression) (Suppression, error) {
+	if err := s.compile(); err != nil {
+		return Suppression{}, err
+	}
+	s.ID = ids.New()
+	s.CreatedAt = time.Now()
+	s.Hits, s.LastHit, s.Expired, s.pending = 0, nil, false, 0
+
+	added := s
+	err := m.syncSuppressions(func(saved []*Suppression) ([]*Suppression, error) {
+		return append(saved, &added), nil
+	})
+	return s, err
+}
+
+// RemoveSuppression deletes a suppression by ID.
+func (m *Manager) RemoveSuppression(id string) error {
+	return m.syncSuppressions(func(saved []*Suppression) ([]*Suppression, error) {
+		for i, s := range saved {
+			if s.ID == id {
+				return append(saved[:i], saved[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("suppression %s not found", id)
+	})
+}
+
+// RemoveExpiredSuppressions deletes suppressions past their expiry and
+// returns how many were removed.
+func (m *Manager) RemoveExpiredSuppressions() (int, error) {
+	removed := 0
+	err := m.syncSuppressions(func(saved []*Suppression) ([]*Suppression, error) {
+		now := time.Now()
+		active := saved[:0]
+		for _, s := range saved {
+			if !s.expiredAt(now) {
+				active = append(active, s)
+			}
+		}
+		removed = len(saved) - len(active)
+		return active, nil
+	})
+	return removed, err
+}
+
+func (m *Manager) loadSuppressions() error {
+	dir := m.StateDir()
+	saved, err := readSuppressions(dir)
+	if err != nil {
+		return err
+	}
+	hits, err := readSuppressionHits(dir, saved)
+	if err != nil {
+		return err
+	}
+	for _, s := range saved {
+		s.Hits, s.LastHit = hits[s.ID].Hits, hits[s.ID].LastHit
+	}
+
+	m.suppressions.mu.Lock()
+	defer m.suppressions.mu.Unlock()
+	m.suppressions.items = saved
+	return nil
+}
+
+// SaveSuppressions adds the hits counted since the last save to the saved
+// counters and picks up suppressions added or removed by other processes.
+func (m *Manager) SaveSuppressions() error {
+	return m.syncSuppressions(nil)
+}
+
+// syncSuppressions is the only writer of the suppression files. The CLI and
+// a running API server both edit the list, so under the state lock it
+// re-reads the definitions, applies change (if any) to them, merges the
+// pending hit counters by ID and replaces the in-memory list with the result.
+func (m *Manager) syncSuppressions(change func([]*Suppression) ([]*Suppression, error)) error {
+	dir := m.StateDir()
+	unlock, err := lockState(dir, suppressionsFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved, err := readSuppressions(dir)
+	if err != nil {
+		return err
+	}
+	hits, err := readSuppressionHits(dir, saved)
+	if err != nil {
+		return err
+	}
+	if change != nil {
+		if saved, err = change(saved); err != nil {
+			return err
+		}
+		definitions := make([]Suppression, 0, len(saved))
+		for _, s := range saved {
+			copied := *s
+			copied.Hits, copied.LastHit, copied.Expired = 0, nil, false
+			definitions = append(definitions, copied)
+		}
+		data, err := json.MarshalIndent(definitions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode suppressions: %w", err)
+		}
+		if err := writeState(dir, suppressionsFile, data); err != nil {
+			return err
+		}
+	}
+
+	m.suppressions.mu.Lock()
+	counted := m.suppressions.dirty
+	for _, s := range m.suppressions.items {
+		if s.pending == 0 {
+			continue
+		}
+		h := hits[s.ID]
+		h.Hits += s.pending
+		if h.LastHit == nil || s.LastHit != nil && s.LastHit.After(*h.LastHit) {
+			h.LastHit = s.LastHit
+		}
+		hits[s.ID] = h
+	}
+	// Counters of suppressions removed elsewhere are dropped with them.
+	merged := make(map[string]suppressionHits, len(saved))
+	for _, s := range saved {
+		if h, ok := hits[s.ID]; ok {
+			merged[s.ID] = h
+			s.Hits, s.LastHit = h.Hits, h.LastHit
+		}
+	}
+	m.suppressions.items = saved
+	m.suppressions.dirty = false
+	m.suppressions.mu.Unlock()
+
+	if !counted && change == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode suppression hits: %w", err)
+	}
+	return writeState(dir, suppressionHitsFile, data)
+}
+
+func readSuppressions(dir string) ([]*Suppression, error) {
+	data, err := os.ReadFile(filepath.Join(dir, suppressionsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppressions: %w", err)
+	}
+	var saved []*Suppression
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse suppressions: %w", err)
+	}
+	for _, s := range saved {
+		if err := s.compile(); err != nil {
+			return nil, fmt.Errorf("suppression %s: %w", s.ID, err)
+		}
+	}
+	return saved, nil
+}
+
+// readSuppressionHits returns the saved hit counters by suppression ID. Lists
+// saved before the counters had their own file carry them inline.
+func readSuppressionHits(dir string, saved []*Suppression) (map[string]suppressionHits, error) {
+	hits := make(map[string]suppressionHits)
+	data, err := os.ReadFile(filepath.Join(dir, suppressionHitsFile))
+	if os.IsNotExist(err) {
+		for _, s := range saved {
+			if s.Hits > 0 {
+				hits[s.ID] = suppressionHits{Hits: s.Hits, LastHit: s.LastHit}
+			}
+		}
+		return hits, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suppression hits: %w", err)
+	}
+	if err := json.Unmarshal(data, &hits); err != nil {
+		return nil, fmt.Errorf("failed to parse suppression hits: %w", err)
+	}
+	return hits, nil
+}
@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"testing"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+func newStateManager(t *testing.T, dir string) *Manager {
+	t.Helper()
+	m := &Manager{
+		config:       &Config{Settings: Settings{StateDir: dir}},
+		stats:        newStatsTracker(),
+		suppressions: &suppressionList{},
+	}
+	if err := m.loadSuppressions(); err != nil {
+		t.Fatalf("load suppressions: %v", err)
+	}
+	return m
+}
+
+func suppressionIDs(m *Manager) map[string]bool {
+	ids := make(map[string]bool)
+	for _, s := range m.Suppressions() {
+		ids[s.ID] = true
+	}
+	return ids
+}
+
+// TestSuppressionsMergeAcrossManagers stands for the CLI and the API server
+// sharing a state dir: neither may drop the other's suppressions or hits.
+func TestSuppressionsMergeAcrossManagers(t *testing.T) {
+	dir := t.TempDir()
+	api := newStateManager(t, dir)
+	cli := newStateManager(t, dir)
+
+	fromAPI, err := api.AddSuppression(Suppression{Rule: "Parola Denemesi", Field: "user", Value: "deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromCLI, err := cli.AddSuppression(Suppression{Rule: "Parola Denemesi", Pattern: "scanner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := []Rule{{Name: "Parola Denemesi"}}
+	byUser := parser.NewEntry("Failed password for deploy from 10.0.0.1 port 22 ssh2")
+	byPattern := parser.NewEntry("Failed password for root from 10.0.0.2 port 22 ssh2 (scanner)")
+	for _, entry := range []*parser.Entry{byUser, byUser} {
+		if kept, _ := api.suppressions.filter(entry, matches, true, nil); len(kept) != 0 {
+			t.Fatalf("%q was not suppressed", entry.Line)
+		}
+	}
+	if kept, _ := cli.suppressions.filter(byPattern, matches, true, nil); len(kept) != 0 {
+		t.Fatalf("%q was not suppressed", byPattern.Line)
+	}
+	if err := api.SaveSuppressions(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.SaveSuppressions(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := newStateManager(t, dir)
+	ids := suppressionIDs(reloaded)
+	if !ids[fromAPI.ID] || !ids[fromCLI.ID] || len(ids) != 2 {
+		t.Fatalf("saved suppressions = %v, want %s and %s", ids, fromAPI.ID, fromCLI.ID)
+	}
+	for _, s := range reloaded.Suppressions() {
+		want := int64(2)
+		if s.ID == fromCLI.ID {
+			want = 1
+		}
+		if s.Hits != want {
+			t.Errorf("suppression %s hits = %d, want %d", s.ID, s.Hits, want)
+		}
+	}
+
+	if err := cli.RemoveSuppression(fromAPI.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.SaveSuppressions(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := suppressionIDs(api); ids[fromAPI.ID] || !ids[fromCLI.ID] {
+		t.Fatalf("after removal elsewhere the API has %v", ids)
+	}
+	if err := api.RemoveSuppression(fromAPI.ID); err == nil {
+		t.Fatal("removing a suppression twice succeeded")
+	}
+}
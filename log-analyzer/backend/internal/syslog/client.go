@@ -0,0 +1,148 @@
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// FacilityCode returns the numeric code of a facility name such as "local4".
+func FacilityCode(name string) (int, bool) {
+	code, ok := facilities[strings.ToLower(name)]
+	return code, ok
+}
+
+// Format5424 renders the message in the RFC 5424 layout.
+func (m Message) Format5424() []byte {
+	field := func(value string, limit int) string {
+		value = strings.Map(func(r rune) rune {
+			if r <= ' ' || r > '~' {
+				return '_'
+			}
+			return r
+		}, value)
+		if value == "" {
+			return "-"
+		}
+		if len(value) > limit {
+			value = value[:limit]
+		}
+		return value
+	}
+	structured := m.Structured
+	if structured == "" {
+		structured = "-"
+	}
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		m.Facility*8+m.Severity,
+		m.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		field(m.Hostname, 255), field(m.AppName, 48), field(m.ProcID, 128), field(m.MsgID, 32),
+		structured, m.Message))
+}
+
+// Client sends messages to a syslog server. A stream connection is kept
+// open between messages and dialled again after an error.
+type Client struct {
+	config Config
+	mu     sync.Mutex
+	conn   net.Conn
+}
+
+func NewClient(config Config) *Client {
+	if config.Protocol == "" {
+		config.Protocol = "udp"
+	}
+	if config.Framing == "" || config.Framing == FramingAuto {
+		config.Framing = FramingOctetCounting
+	}
+	return &Client{config: config}
+}
+
+func (c *Client) Send(ctx context.Context, msg Message) error {
+	data := msg.Format5424()
+	if c.config.Protocol != "udp" {
+		if c.config.Framing == FramingNewline {
+			data = append([]byte(strings.ReplaceAll(string(data), "\n", " ")), '\n')
+		} else {
+			data = append([]byte(strconv.Itoa(len(data))+" "), data...)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && c.config.Protocol != "udp" && !alive(c.conn) {
+		c.conn.Close()
+		c.conn = nil
+	}
+	if c.conn == nil {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	c.conn.SetWriteDeadline(deadline)
+	if _, err := c.conn.Write(data); err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// alive reports whether the server has not closed a stream connection. A
+// write to a closed connection succeeds once and loses the message, so the
+// connection is checked for EOF first; servers never send anything back.
+func alive(conn net.Conn) bool {
+	conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	defer conn.SetReadDeadline(time.Time{})
+	var buf [1]byte
+	_, err := conn.Read(buf[:])
+	var netErr net.Error
+	return err == nil || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+	switch c.config.Protocol {
+	case "udp", "tcp":
+		return dialer.DialContext(ctx, c.config.Protocol, c.config.Address)
+	case "tls":
+		tlsDialer := tls.Dialer{NetDialer: &dialer, Config: c.config.TLSConfig}
+		return tlsDialer.DialContext(ctx, "tcp", c.config.Address)
+	default:
+		return nil, fmt.Errorf("unsupported syslog protocol %q", c.config.Protocol)
+	}
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && c.config.Protocol != "udp" && !alive(c.conn) {
+		c.conn.Close()
+		c.conn = nil
+	}
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
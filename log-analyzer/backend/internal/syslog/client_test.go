@@ -0,0 +1,56 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientReconnectsAfterServerClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// The server reads one frame per connection and then hangs up.
+	frames := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			frame, err := bufio.NewReader(conn).ReadString('\n')
+			conn.Close()
+			if err == nil {
+				frames <- frame
+			}
+		}
+	}()
+
+	client := NewClient(Config{Protocol: "tcp", Address: listener.Addr().String(), Framing: FramingNewline})
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, text := range []string{"first", "second"} {
+		msg := Message{Facility: 16, Severity: 4, Timestamp: time.Now(), Hostname: "web", AppName: "test", Message: text}
+		if err := client.Send(ctx, msg); err != nil {
+			t.Fatalf("send %s: %v", text, err)
+		}
+		select {
+		case frame := <-frames:
+			if !strings.HasSuffix(frame, " "+text+"\n") {
+				t.Errorf("frame = %q, want %s", frame, text)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s was lost after the server closed the connection", text)
+		}
+		// Give the close time to reach the client before the next send.
+		time.Sleep(20 * time.Millisecond)
+	}
+}
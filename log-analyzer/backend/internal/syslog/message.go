@@ -0,0 +1,199 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"log-analyzer/backend/internal/parser"
+)
+
+type Message struct {
+	Facility   int
+	Severity   int
+	Timestamp  time.Time
+	Hostname   string
+	AppName    string
+	ProcID     string
+	MsgID      string
+	Structured string
+	Message    string
+	RemoteAddr string
+}
+
+// Entry converts the message into the structured record used by rules. The
+// line is rendered in the classic BSD syslog file layout so rules written for
+// /var/log files match it unchanged.
+func (m Message) Entry() *parser.Entry {
+	entry := &parser.Entry{
+		Timestamp: m.Timestamp,
+		Host:      m.Host(),
+		Program:   m.AppName,
+		PID:       m.ProcID,
+		Message:   m.Message,
+		Fields: map[string]string{
+			"facility": strconv.Itoa(m.Facility),
+			"severity": strconv.Itoa(m.Severity),
+		},
+	}
+	if m.MsgID != "" {
+		entry.Fields["msgid"] = m.MsgID
+	}
+	if m.Structured != "" {
+		entry.Fields["structured_data"] = m.Structured
+	}
+	entry.Line = entry.SyslogLine()
+	return entry
+}
+
+// Host returns the sending host, falling back to the peer address when the
+// message carries no hostname.
+func (m Message) Host() string {
+	if m.Hostname != "" {
+		return m.Hostname
+	}
+	host := m.RemoteAddr
+	if idx := strings.LastIndex(host, ":"); idx >= 0 {
+		host = strings.Trim(host[:idx], "[]")
+	}
+	if host == "" {
+		return "-"
+	}
+	return host
+}
+
+// Parse decodes an RFC 5424 or RFC 3164 message. Messages without a valid
+// priority are accepted as plain text with the user.notice priority.
+func Parse(data []byte) (Message, error) {
+	raw := strings.TrimRight(string(data), "\r\n\x00")
+	msg := Message{Facility: 1, Severity: 5, Timestamp: time.Now()}
+	if raw == "" {
+		return msg, fmt.Errorf("empty message")
+	}
+	if raw[0] != '<' {
+		msg.Message = raw
+		return msg, nil
+	}
+	end := strings.IndexByte(raw, '>')
+	if end < 2 || end > 4 {
+		return msg, fmt.Errorf("invalid priority")
+	}
+	pri, err := strconv.Atoi(raw[1:end])
+	if err != nil || pri > 191 {
+		return msg, fmt.Errorf("invalid priority %q", raw[1:end])
+	}
+	msg.Facility = pri / 8
+	msg.Severity = pri % 8
+	rest := raw[end+1:]
+
+	if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		return parse5424(msg, rest[2:])
+	}
+	return parse3164(msg, rest), nil
+}
+
+func parse5424(msg Message, rest string) (Message, error) {
+	fields := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		var field string
+		field, rest = nextField(rest)
+		fields = append(fields, field)
+	}
+	if len(fields[4]) == 0 {
+		return msg, fmt.Errorf("truncated RFC 5424 header")
+	}
+	if fields[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return msg, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		msg.Timestamp = ts
+	}
+	msg.Hostname = nilValue(fields[1])
+	msg.AppName = nilValue(fields[2])
+	msg.ProcID = nilValue(fields[3])
+	msg.MsgID = nilValue(fields[4])
+
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else if strings.HasPrefix(rest, "[") {
+		end := structuredDataEnd(rest)
+		if end < 0 {
+			return msg, fmt.Errorf("unterminated structured data")
+		}
+		msg.Structured = rest[:end]
+		rest = rest[end:]
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	msg.Message = strings.TrimPrefix(rest, "\ufeff")
+	return msg, nil
+}
+
+func parse3164(msg Message, rest string) Message {
+	if len(rest) >= len(time.Stamp) {
+		if ts, err := time.ParseInLocation(time.Stamp, rest[:len(time.Stamp)], time.Local); err == nil {
+			now := time.Now()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			msg.Timestamp = ts
+			rest = strings.TrimPrefix(rest[len(time.Stamp):], " ")
+			var host string
+			host, rest = nextField(rest)
+			if strings.HasSuffix(host, ":") || strings.Contains(host, "[") {
+				rest = host + " " + rest
+			} else {
+				msg.Hostname = host
+			}
+		}
+	}
+
+	if tagEnd := strings.Index(rest, ": "); tagEnd > 0 && !strings.ContainsAny(rest[:tagEnd], " ") {
+		tag := rest[:tagEnd]
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			msg.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		msg.AppName = tag
+		rest = rest[tagEnd+2:]
+	}
+	msg.Message = strings.TrimSpace(rest)
+	return msg
+}
+
+func nextField(s string) (string, string) {
+	if idx := strings.IndexByte(s, ' '); idx >= 0 {
+		return s[:idx], s[idx+1:]
+	}
+	return s, ""
+}
+
+func nilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func structuredDataEnd(s string) int {
+	inElement := false
+	inValue := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inValue && c == '\\':
+			i++
+		case c == '"' && inElement:
+			inValue = !inValue
+		case c == '[' && !inValue:
+			inElement = true
+		case c == ']' && !inValue:
+			inElement = false
+			if i+1 == len(s) || s[i+1] != '[' {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
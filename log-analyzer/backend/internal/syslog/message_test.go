@@ -0,0 +1,106 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Message
+		// when is the expected timestamp; zero skips the check.
+		when time.Time
+	}{
+		{
+			name:  "rfc 5424",
+			input: "<165>1 2026-10-18T10:00:00.003Z web.example.com sshd 811 ID47 [origin ip=\"203.0.113.7\"][meta note=\"a \\\"]\\\" b\"] \ufeffFailed password for root\n",
+			want: Message{
+				Facility: 20, Severity: 5, Hostname: "web.example.com", AppName: "sshd", ProcID: "811", MsgID: "ID47",
+				Structured: `[origin ip="203.0.113.7"][meta note="a \"]\" b"]`,
+				Message:    "Failed password for root",
+			},
+			when: time.Date(2026, 10, 18, 10, 0, 0, 3e6, time.UTC),
+		},
+		{
+			name:  "rfc 5424 nil values",
+			input: "<34>1 - - - - - - disk full",
+			want:  Message{Facility: 4, Severity: 2, Message: "disk full"},
+		},
+		{
+			name:  "rfc 5424 without message",
+			input: "<14>1 2026-10-18T10:00:00+03:00 web app - - -",
+			want:  Message{Facility: 1, Severity: 6, Hostname: "web", AppName: "app"},
+			when:  time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "rfc 3164",
+			input: "<38>Oct 18 10:00:00 web sshd[811]: Failed password for root\r\n",
+			want:  Message{Facility: 4, Severity: 6, Hostname: "web", AppName: "sshd", ProcID: "811", Message: "Failed password for root"},
+		},
+		{
+			name:  "rfc 3164 without hostname",
+			input: "<78>Oct  8 09:05:01 CRON[99]: (root) CMD (run-parts)",
+			want:  Message{Facility: 9, Severity: 6, AppName: "CRON", ProcID: "99", Message: "(root) CMD (run-parts)"},
+		},
+		{
+			name:  "rfc 3164 without timestamp",
+			input: "<13>myapp: started",
+			want:  Message{Facility: 1, Severity: 5, AppName: "myapp", Message: "started"},
+		},
+		{
+			name:  "no priority",
+			input: "plain text\x00",
+			want:  Message{Facility: 1, Severity: 5, Message: "plain text"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Parse([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !tt.when.IsZero() && !got.Timestamp.Equal(tt.when) {
+			t.Errorf("%s: timestamp = %v, want %v", tt.name, got.Timestamp, tt.when)
+		}
+		got.Timestamp = time.Time{}
+		if got != tt.want {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParse3164Timestamp(t *testing.T) {
+	msg, err := Parse([]byte("<38>Oct  8 09:05:01 web sshd: x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := msg.Timestamp
+	if ts.Month() != time.October || ts.Day() != 8 || ts.Hour() != 9 || ts.Minute() != 5 || ts.Second() != 1 {
+		t.Errorf("timestamp = %v, want Oct 8 09:05:01", ts)
+	}
+	if ts.After(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("timestamp %v is in the future; the year was not rolled back", ts)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, input string
+	}{
+		{"empty", "\r\n"},
+		{"empty priority", "<>1 - - - - - - x"},
+		{"long priority", "<1234>x"},
+		{"priority out of range", "<192>1 - - - - - - x"},
+		{"priority not a number", "<1a>x"},
+		{"truncated header", "<13>1 2026-10-18T10:00:00Z web app"},
+		{"bad timestamp", "<13>1 18/10/2026 web app - - - x"},
+		{"unterminated structured data", `<13>1 - web app - - [meta note="x"`},
+	}
+	for _, tt := range tests {
+		if msg, err := Parse([]byte(tt.input)); err == nil {
+			t.Errorf("%s: parsed as %+v", tt.name, msg)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package syslog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FramingAuto          = "auto"
+	FramingOctetCounting = "octet-counting"
+	FramingNewline       = "newline"
+
+	maxMessageSize = 64 * 1024
+)
+
+type Config struct {
+	Protocol  string
+	Address   string
+	Framing   string
+	TLSConfig *tls.Config
+}
+
+// Server receives syslog messages over UDP, TCP or TLS and hands every
+// parsed message to the handler.
+type Server struct {
+	config   Config
+	handler  func(Message)
+	packet   net.PacketConn
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	closed   bool
+}
+
+func NewServer(config Config, handler func(Message)) *Server {
+	if config.Protocol == "" {
+		config.Protocol = "udp"
+	}
+	if config.Framing == "" {
+		config.Framing = FramingAuto
+	}
+	return &Server{
+		config:  config,
+		handler: handler,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+func (s *Server) Start() error {
+	switch s.config.Protocol {
+	case "udp":
+		conn, err := net.ListenPacket("udp", s.config.Address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on udp %s: %w", s.config.Address, err)
+		}
+		s.packet = conn
+		s.wg.Add(1)
+		go s.servePacket()
+	case "tcp", "tls":
+		var listener net.Listener
+		var err error
+		if s.config.Protocol == "tls" {
+			if s.config.TLSConfig == nil {
+				return fmt.Errorf("tls protocol requires a certificate")
+			}
+			listener, err = tls.Listen("tcp", s.config.Address, s.config.TLSConfig)
+		} else {
+			listener, err = net.Listen("tcp", s.config.Address)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s %s: %w", s.config.Protocol, s.config.Address, err)
+		}
+		s.listener = listener
+		s.wg.Add(1)
+		go s.serveStream()
+	default:
+		return fmt.Errorf("unsupported syslog protocol %q", s.config.Protocol)
+	}
+	return nil
+}
+
+func (s *Server) Addr() net.Addr {
+	if s.packet != nil {
+		return s.packet.LocalAddr()
+	}
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.packet != nil {
+		err = s.packet.Close()
+	}
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) servePacket() {
+	defer s.wg.Done()
+	buf := make([]byte, maxMessageSize)
+	var delay time.Duration
+	for {
+		n, addr, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			delay = retryDelay(delay)
+			time.Sleep(delay)
+			continue
+		}
+		delay = 0
+		s.deliver(buf[:n], addr.String())
+	}
+}
+
+func (s *Server) serveStream() {
+	defer s.wg.Done()
+	var delay time.Duration
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			delay = retryDelay(delay)
+			time.Sleep(delay)
+			continue
+		}
+		delay = 0
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// retryDelay returns the pause after a failed read or accept, doubling the
+// previous one from 5ms up to a second as net/http.Server.Serve does, so a
+// lasting error such as EMFILE does not spin the loop.
+func retryDelay(previous time.Duration) time.Duration {
+	if previous == 0 {
+		return 5 * time.Millisecond
+	}
+	if delay := previous * 2; delay < time.Second {
+		return delay
+	}
+	return time.Second
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	remote := conn.RemoteAddr().String()
+	reader := bufio.NewReaderSize(conn, maxMessageSize)
+	for {
+		frame, err := s.readFrame(reader)
+		if len(frame) > 0 {
+			s.deliver(frame, remote)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) readFrame(reader *bufio.Reader) ([]byte, error) {
+	framing := s.config.Framing
+	if framing == FramingAuto {
+		first, err := reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		framing = FramingNewline
+		if first[0] >= '1' && first[0] <= '9' {
+			framing = FramingOctetCounting
+		}
+	}
+
+	if framing == FramingOctetCounting {
+		prefix, err := reader.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil || length <= 0 || length > maxMessageSize {
+			return nil, fmt.Errorf("invalid octet count %q", prefix)
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	line, err := reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		frame := append([]byte(nil), line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = reader.ReadSlice('\n')
+		}
+		return frame, err
+	}
+	return append([]byte(nil), line...), err
+}
+
+func (s *Server) deliver(data []byte, remote string) {
+	msg, err := Parse(data)
+	if err != nil {
+		return
+	}
+	msg.RemoteAddr = remote
+	s.handler(msg)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,124 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadFrame(t *testing.T) {
+	long := strings.Repeat("a", maxMessageSize+100)
+	tests := []struct {
+		name    string
+		framing string
+		input   string
+		want    []string
+		// wantErr is the error that ends the stream; nil means io.EOF.
+		wantErr error
+		invalid bool
+	}{
+		{name: "octet counting", framing: FramingOctetCounting, input: "5 hello6 world!", want: []string{"hello", "world!"}},
+		{name: "octet counting detected", framing: FramingAuto, input: "5 hello5 a\nb c", want: []string{"hello", "a\nb c"}},
+		{name: "newline", framing: FramingNewline, input: "first\nsecond\r\n", want: []string{"first\n", "second\r\n"}},
+		{name: "newline detected", framing: FramingAuto, input: "<13>first\nlast", want: []string{"<13>first\n", "last"}},
+		{name: "truncated frame", framing: FramingOctetCounting, input: "5 hello10 short", want: []string{"hello"}, wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated count", framing: FramingOctetCounting, input: "5 hello12", want: []string{"hello"}},
+		{name: "bad octet count", framing: FramingOctetCounting, input: "abc hello", invalid: true},
+		{name: "zero octet count", framing: FramingOctetCounting, input: "0 hello", invalid: true},
+		{name: "oversized octet count", framing: FramingAuto, input: "70000 hello", invalid: true},
+		{name: "oversized line", framing: FramingNewline, input: long + "\nnext\n", want: []string{long[:maxMessageSize], "next\n"}},
+	}
+	for _, tt := range tests {
+		s := NewServer(Config{Protocol: "tcp", Framing: tt.framing}, nil)
+		reader := bufio.NewReaderSize(strings.NewReader(tt.input), maxMessageSize)
+		var got []string
+		var err error
+		for err == nil {
+			var frame []byte
+			frame, err = s.readFrame(reader)
+			if len(frame) > 0 {
+				got = append(got, string(frame))
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: frames = %.80q, want %.80q", tt.name, got, tt.want)
+		}
+		switch {
+		case tt.invalid:
+			if !strings.Contains(err.Error(), "invalid octet count") {
+				t.Errorf("%s: err = %v, want an invalid octet count", tt.name, err)
+			}
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case err != io.EOF:
+			t.Errorf("%s: err = %v, want EOF", tt.name, err)
+		}
+	}
+}
+
+func TestServerTCP(t *testing.T) {
+	var mu sync.Mutex
+	var received []Message
+	server := NewServer(Config{Protocol: "tcp", Address: "127.0.0.1:0"}, func(msg Message) {
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+	})
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	// One sender uses octet counting, the other newline framing; the
+	// server tells them apart per connection.
+	payloads := []string{
+		"28 <38>1 - web sshd - - - login",
+		"<38>Oct 18 10:00:00 db postgres[7]: ready\n",
+	}
+	for _, payload := range payloads {
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Write([]byte(payload)); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n == len(payloads) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d messages, want %d", n, len(payloads))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	hosts := map[string]string{}
+	for _, msg := range received {
+		hosts[msg.Hostname] = msg.AppName + ": " + msg.Message
+		if !strings.HasPrefix(msg.RemoteAddr, "127.0.0.1:") {
+			t.Errorf("remote address = %q", msg.RemoteAddr)
+		}
+	}
+	want := map[string]string{"web": "sshd: login", "db": "postgres: ready"}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("messages = %v, want %v", hosts, want)
+	}
+}
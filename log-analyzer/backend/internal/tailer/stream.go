@@ -0,0 +1,216 @@
+package tailer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"log-analyzer/backend/internal/journal"
+	"log-analyzer/backend/internal/multiline"
+	"log-analyzer/backend/internal/parser"
+	"log-analyzer/backend/internal/rules"
+)
+
+var defaultJournalCommand = []string{"journalctl", "--follow", "--lines=0", "--output=json"}
+
+// stream feeds a continuous reader (journal file, named pipe, spawned
+// command) through a decoder until it is closed.
+type stream struct {
+	stop      chan struct{}
+	done      chan struct{}
+	closer    io.Closer
+	closeOnce sync.Once
+}
+
+func (s *stream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		if s.closer != nil {
+			err = s.closer.Close()
+		}
+		<-s.done
+	})
+	return err
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// followReader keeps reading a regular file past EOF like `tail -f`.
+type followReader struct {
+	reader io.Reader
+	stop   <-chan struct{}
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.reader.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		select {
+		case <-f.stop:
+			return 0, io.EOF
+		case <-time.After(1 * time.Second):
+		}
+	}
+}
+
+func (t *Tailer) startStream(logFile rules.LogFile, r io.Reader, closer io.Closer) *stream {
+	s := &stream{
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		closer: closer,
+	}
+	if file, ok := r.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+			r = &followReader{reader: file, stop: s.stop}
+		}
+	}
+	t.runStream(s, func() {
+		if journal.IsFormat(logFile.Format) {
+			t.readJournal(logFile.Path, logFile, r, logFile.Format)
+		} else {
+			t.readLines(s, logFile, r)
+		}
+	})
+	return s
+}
+
+func (t *Tailer) startJournalInput(input rules.Input) (*stream, error) {
+	cfg := input.Journal
+	if cfg == nil {
+		cfg = &rules.JournalInput{}
+	}
+	format := cfg.Format
+	if format == "" {
+		format = journal.FormatJSON
+	}
+	logFile := rules.LogFile{Path: "journal://" + input.Name, Type: input.LogType, Format: format}
+
+	if cfg.Path != "" {
+		file, err := os.Open(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open journal stream: %w", err)
+		}
+		return t.startStream(logFile, file, file), nil
+	}
+
+	command := cfg.Command
+	if len(command) == 0 {
+		command = defaultJournalCommand
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+	s := &stream{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		closer: closerFunc(func() error {
+			cmd.Process.Kill()
+			return nil
+		}),
+	}
+	t.runStream(s, func() {
+		t.readJournal(logFile.Path, logFile, stdout, format)
+		cmd.Wait()
+	})
+	return s, nil
+}
+
+func (t *Tailer) runStream(s *stream, read func()) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(s.done)
+		read()
+	}()
+}
+
+func (t *Tailer) readJournal(source string, logFile rules.LogFile, r io.Reader, format string) {
+	reader, err := journal.NewReader(r, format)
+	if err != nil {
+		return
+	}
+	for {
+		entry, err := reader.Next()
+		if errors.Is(err, journal.ErrInvalidEntry) {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		t.emit(source, logFile, entry)
+	}
+}
+
+func (t *Tailer) readLines(s *stream, logFile rules.LogFile, r io.Reader) {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				select {
+				case lines <- strings.TrimRight(line, "\r\n"):
+				case <-s.stop:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	assembler := multiline.NewAssembler(logFile.Multiline)
+	emit := func(event string) {
+		t.emit(logFile.Path, logFile, parser.NewEntry(strings.TrimSpace(event)))
+	}
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				if event, ok := assembler.Flush(); ok {
+					emit(event)
+				}
+				return
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			for _, event := range assembler.Add(line) {
+				emit(event)
+			}
+		case <-ticker.C:
+			if assembler.Expired(time.Now()) {
+				if event, ok := assembler.Flush(); ok {
+					emit(event)
+				}
+			}
+		case <-s.stop:
+			if event, ok := assembler.Flush(); ok {
+				emit(event)
+			}
+			return
+		}
+	}
+}
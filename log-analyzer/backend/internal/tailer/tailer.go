@@ -1,15 +1,25 @@
 package tailer
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"log-analyzer/backend/internal/dedup"
+	"log-analyzer/backend/internal/intel"
+	"log-analyzer/backend/internal/journal"
+	"log-analyzer/backend/internal/multiline"
+	"log-analyzer/backend/internal/parser"
 	"log-analyzer/backend/internal/rules"
+	"log-analyzer/backend/internal/syslog"
 )
 
 type Alert struct {
@@ -19,42 +29,92 @@ type Alert struct {
 	Line        string
 	MatchedRules []string
 	Severity    string
+	Host        string
+	Fields      map[string]string
+	// Entities are the typed values found in the line, nil when none.
+	Entities    *parser.Entities
+	// Grouped alerts share a GroupKey; a repeat carries the group's size in
+	// Count, the first occurrence in Timestamp and the latest in LastSeen.
+	GroupKey    string
+	Count       int
+	LastSeen    time.Time
 }
 
+// sendTimeout is how long a source waits for the alert consumer before the
+// alert is dropped. Once a wait timed out, alerts are dropped without
+// waiting until the consumer takes one again.
+const sendTimeout = 5 * time.Second
+
 type Tailer struct {
 	ruleManager *rules.Manager
+	grouper     *dedup.Grouper
+	intel       *intel.Matcher
 	alerts      chan Alert
 	stopChan    chan struct{}
 	wg          sync.WaitGroup
 	mu          sync.Mutex
 	watchers    map[string]*fileWatcher
+	streams     map[string]*stream
+	inputs      map[string]io.Closer
+	dropped     atomic.Int64
+	stalled     atomic.Bool
 }
 
 type fileWatcher struct {
-	file    *os.File
-	path    string
-	stop    chan struct{}
-	mu      sync.Mutex
-	lastPos int64
+	file      *os.File
+	path      string
+	logFile   rules.LogFile
+	stop      chan struct{}
+	mu        sync.Mutex
+	lastPos   int64
+	assembler *multiline.Assembler
 }
 
 func NewTailer(ruleManager *rules.Manager) *Tailer {
 	return &Tailer{
 		ruleManager: ruleManager,
+		grouper:     dedup.NewGrouper(),
 		alerts:      make(chan Alert, 100),
 		stopChan:    make(chan struct{}),
 		watchers:    make(map[string]*fileWatcher),
+		streams:     make(map[string]*stream),
+		inputs:      make(map[string]io.Closer),
 	}
 }
 
+// SetIntel makes the tailer raise alerts for lines containing indicators
+// of the IOC feeds. Call it before starting any source.
+func (t *Tailer) SetIntel(matcher *intel.Matcher) {
+	t.intel = matcher
+}
+
 func (t *Tailer) StartWatching(filePath string) error {
+	logFile, _ := t.ruleManager.GetLogFile(filePath)
+	return t.StartLogFile(logFile)
+}
+
+// StartLogFile watches logFile.Path using the given type, format and
+// multiline settings instead of looking them up in the config.
+func (t *Tailer) StartLogFile(logFile rules.LogFile) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
-	if _, exists := t.watchers[filePath]; exists {
+	filePath := logFile.Path
+	if t.isWatching(filePath) {
 		return fmt.Errorf("already watching %s", filePath)
 	}
 	
+	if info, err := os.Stat(filePath); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		// Opening read-write neither blocks until a writer appears nor
+		// reports EOF when a writer goes away.
+		fifo, err := os.OpenFile(filePath, os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open pipe: %w", err)
+		}
+		t.streams[filePath] = t.startStream(logFile, fifo, fifo)
+		return nil
+	}
+	
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -79,11 +139,17 @@ func (t *Tailer) StartWatching(filePath string) error {
 	
 	file.Seek(fileInfo.Size(), 0)
 	
+	if journal.IsFormat(logFile.Format) {
+		t.streams[filePath] = t.startStream(logFile, file, file)
+		return nil
+	}
 	watcher := &fileWatcher{
-		file:    file,
-		path:    filePath,
-		stop:    make(chan struct{}),
-		lastPos: fileInfo.Size(),
+		file:      file,
+		path:      filePath,
+		logFile:   logFile,
+		stop:      make(chan struct{}),
+		lastPos:   fileInfo.Size(),
+		assembler: multiline.NewAssembler(logFile.Multiline),
 	}
 	
 	t.watchers[filePath] = watcher
@@ -93,6 +159,25 @@ func (t *Tailer) StartWatching(filePath string) error {
 	return nil
 }
 
+// StartReader tails an already opened stream such as stdin. The LogFile
+// supplies the source name (Path), log type and format. The returned channel
+// is closed once the stream has ended.
+func (t *Tailer) StartReader(logFile rules.LogFile, r io.Reader) (<-chan struct{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	if t.isWatching(logFile.Path) {
+		return nil, fmt.Errorf("already watching %s", logFile.Path)
+	}
+	var closer io.Closer
+	if c, ok := r.(io.Closer); ok {
+		closer = c
+	}
+	stream := t.startStream(logFile, r, closer)
+	t.streams[logFile.Path] = stream
+	return stream.done, nil
+}
+
 func (t *Tailer) watchFile(watcher *fileWatcher) {
 	defer t.wg.Done()
 	defer watcher.file.Close()
@@ -103,71 +188,174 @@ func (t *Tailer) watchFile(watcher *fileWatcher) {
 	for {
 		select {
 		case <-watcher.stop:
+			t.flushPending(watcher)
 			return
 		case <-t.stopChan:
+			t.flushPending(watcher)
 			return
 		case <-ticker.C:
 			t.readNewLines(watcher)
+			t.flushExpired(watcher)
 		}
 	}
 }
 
+// readNewLines matches the lines appended since the last read. Alerts are
+// sent after watcher.mu is released, so the lock is never held while
+// waiting for the consumer.
 func (t *Tailer) readNewLines(watcher *fileWatcher) {
+	for _, event := range t.readEvents(watcher) {
+		t.processLine(watcher, event)
+	}
+}
+
+func (t *Tailer) readEvents(watcher *fileWatcher) []string {
 	watcher.mu.Lock()
 	defer watcher.mu.Unlock()
 	fileInfo, err := os.Stat(watcher.path)
 	if err != nil {
-		return
+		return nil
 	}
+	var events []string
 	currentSize := fileInfo.Size()
 	if currentSize > watcher.lastPos {
 		toRead := currentSize - watcher.lastPos
 		if _, err := watcher.file.Seek(watcher.lastPos, 0); err != nil {
 			t.reopenWatcher(watcher, currentSize)
-			return
+			return nil
 		}
 		buf := make([]byte, toRead)
 		n, err := io.ReadFull(watcher.file, buf)
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			t.reopenWatcher(watcher, currentSize)
-			return
+			return nil
 		}
 		watcher.lastPos += int64(n)
 		chunk := string(buf[:n])
 		for _, rawLine := range strings.Split(chunk, "\n") {
-			line := strings.TrimSpace(rawLine)
-			if line == "" {
+			line := strings.TrimRight(rawLine, "\r")
+			if strings.TrimSpace(line) == "" {
 				continue
 			}
-			matchedRules := t.ruleManager.MatchRules(line)
-			if len(matchedRules) > 0 {
-				var ruleNames []string
-				maxSeverity := "low"
-				for _, rule := range matchedRules {
-					ruleNames = append(ruleNames, rule.Name)
-					ruleSevLevel := severityLevel(rule.Severity)
-					maxSevLevel := severityLevel(maxSeverity)
-					if ruleSevLevel > maxSevLevel {
-						maxSeverity = rule.Severity
-					}
-				}
-				alert := Alert{
-					Timestamp:    time.Now(),
-					Source:       watcher.path,
-					LogFile:      watcher.path,
-					Line:         line,
-					MatchedRules: ruleNames,
-					Severity:     maxSeverity,
-				}
-				select {
-				case t.alerts <- alert:
-				default:
-				}
-			}
+			events = append(events, watcher.assembler.Add(line)...)
 		}
 	} else if currentSize < watcher.lastPos {
 		t.reopenWatcher(watcher, currentSize)
 	}
+	return events
+}
+
+func (t *Tailer) flushExpired(watcher *fileWatcher) {
+	watcher.mu.Lock()
+	if !watcher.assembler.Expired(time.Now()) {
+		watcher.mu.Unlock()
+		return
+	}
+	event, ok := watcher.assembler.Flush()
+	watcher.mu.Unlock()
+	if ok {
+		t.processLine(watcher, event)
+	}
+}
+
+func (t *Tailer) flushPending(watcher *fileWatcher) {
+	watcher.mu.Lock()
+	event, ok := watcher.assembler.Flush()
+	watcher.mu.Unlock()
+	if ok {
+		t.processLine(watcher, event)
+	}
+}
+
+func (t *Tailer) processLine(watcher *fileWatcher, rawLine string) {
+	t.emit(watcher.path, watcher.logFile, parser.NewEntry(strings.TrimSpace(rawLine)))
+}
+
+func (t *Tailer) emit(source string, logFile rules.LogFile, entry *parser.Entry) {
+	matchedRules := t.ruleManager.MatchEntryFrom(logFile, entry)
+	hits := t.intel.Match(entry)
+	matchedRules = intel.Rules(matchedRules, hits)
+	if len(matchedRules) == 0 {
+		return
+	}
+	var ruleNames []string
+	maxSeverity := "low"
+	for _, rule := range matchedRules {
+		ruleNames = append(ruleNames, rule.Name)
+		ruleSevLevel := severityLevel(rule.Severity)
+		maxSevLevel := severityLevel(maxSeverity)
+		if ruleSevLevel > maxSevLevel {
+			maxSeverity = rule.Severity
+		}
+	}
+	now := time.Now()
+	alert := Alert{
+		Timestamp:    now,
+		Source:       source,
+		LogFile:      logFile.Path,
+		Line:         entry.Line,
+		MatchedRules: ruleNames,
+		Severity:     maxSeverity,
+		Host:         entry.Host,
+		Fields:       rules.WithCaptures(matchedRules, entry, entry.Attributes()),
+		Count:        1,
+		LastSeen:     now,
+	}
+	if len(hits) > 0 {
+		if alert.Fields == nil {
+			alert.Fields = make(map[string]string)
+		}
+		for key, value := range intel.Annotate(hits) {
+			alert.Fields[key] = value
+		}
+	}
+	if entities := entry.Entities(); !entities.Empty() {
+		alert.Entities = &entities
+	}
+	if key, window := t.ruleManager.DedupKey(matchedRules, entry); key != "" {
+		alert.GroupKey = key
+		alert.Count, alert.Timestamp = t.grouper.Observe(key, window, now)
+	}
+	t.send(alert)
+}
+
+// send hands alert to the consumer, waiting up to sendTimeout while it is
+// busy. Alerts that still do not fit are counted, except while the tailer
+// stops. After a timeout the tailer counts as stalled and drops alerts
+// right away until the consumer takes one again, so a source such as a
+// syslog receiver is not held up for sendTimeout on every alert.
+func (t *Tailer) send(alert Alert) {
+	select {
+	case t.alerts <- alert:
+		if t.stalled.Swap(false) {
+			log.Printf("Alert consumer caught up again (%d alerts dropped so far)", t.dropped.Load())
+		}
+		return
+	default:
+	}
+	if t.stalled.Load() {
+		t.dropped.Add(1)
+		return
+	}
+	timer := time.NewTimer(sendTimeout)
+	defer timer.Stop()
+	select {
+	case t.alerts <- alert:
+		return
+	case <-t.stopChan:
+		// Stopping: the consumer is going away, nothing is lost unexpectedly.
+		return
+	case <-timer.C:
+	}
+	dropped := t.dropped.Add(1)
+	t.stalled.Store(true)
+	log.Printf("Alert from %s dropped, consumer is not keeping up (%d dropped so far); dropping alerts until it catches up: %s", alert.Source, dropped, strings.Join(alert.MatchedRules, ", "))
+}
+
+// DroppedAlerts returns how many alerts were dropped because the consumer
+// did not keep up.
+func (t *Tailer) DroppedAlerts() int64 {
+	return t.dropped.Load()
 }
 
 func (t *Tailer) reopenWatcher(watcher *fileWatcher, newSize int64) {
@@ -190,6 +378,105 @@ func (t *Tailer) StopWatching(filePath string) {
 		close(watcher.stop)
 		delete(t.watchers, filePath)
 	}
+	if stream, exists := t.streams[filePath]; exists {
+		delete(t.streams, filePath)
+		go stream.Close()
+	}
+}
+
+func (t *Tailer) StartInput(input rules.Input) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	if _, exists := t.inputs[input.Name]; exists {
+		return fmt.Errorf("input %s already running", input.Name)
+	}
+	
+	switch input.Type {
+	case "syslog":
+		server, err := t.newSyslogServer(input)
+		if err != nil {
+			return err
+		}
+		if err := server.Start(); err != nil {
+			return err
+		}
+		t.inputs[input.Name] = server
+	case "journal":
+		stream, err := t.startJournalInput(input)
+		if err != nil {
+			return err
+		}
+		t.inputs[input.Name] = stream
+	default:
+		return fmt.Errorf("unsupported input type %q", input.Type)
+	}
+	return nil
+}
+
+func (t *Tailer) newSyslogServer(input rules.Input) (*syslog.Server, error) {
+	cfg := syslog.Config{
+		Protocol: input.Syslog.Protocol,
+		Address:  input.Syslog.Address,
+		Framing:  input.Syslog.Framing,
+	}
+	if cfg.Protocol == "tls" {
+		tlsConfig, err := loadTLSConfig(input.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLSConfig = tlsConfig
+	}
+	logFile := rules.LogFile{Path: "syslog://" + input.Name, Type: input.LogType}
+	return syslog.NewServer(cfg, func(msg syslog.Message) {
+		t.emit(msg.Host(), logFile, msg.Entry())
+	}), nil
+}
+
+func loadTLSConfig(cfg *rules.SyslogInput) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.TLSClientCA != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLSClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+func (t *Tailer) StopInput(name string) {
+	t.mu.Lock()
+	input, exists := t.inputs[name]
+	delete(t.inputs, name)
+	t.mu.Unlock()
+	
+	if exists {
+		input.Close()
+	}
+}
+
+func (t *Tailer) GetInputs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	
+	names := make([]string, 0, len(t.inputs))
+	for name := range t.inputs {
+		names = append(names, name)
+	}
+	return names
 }
 
 func (t *Tailer) Stop() {
@@ -199,8 +486,18 @@ func (t *Tailer) Stop() {
 	for _, watcher := range t.watchers {
 		close(watcher.stop)
 	}
+	inputs := t.inputs
+	t.inputs = make(map[string]io.Closer)
+	for path, stream := range t.streams {
+		inputs[path] = stream
+	}
+	t.streams = make(map[string]*stream)
 	t.mu.Unlock()
 	
+	for _, input := range inputs {
+		input.Close()
+	}
+	
 	t.wg.Wait()
 	close(t.alerts)
 }
@@ -213,10 +510,13 @@ func (t *Tailer) GetWatchedFiles() []string {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
-	files := make([]string, 0, len(t.watchers))
+	files := make([]string, 0, len(t.watchers)+len(t.streams))
 	for path := range t.watchers {
 		files = append(files, path)
 	}
+	for path := range t.streams {
+		files = append(files, path)
+	}
 	return files
 }
 
@@ -224,8 +524,13 @@ func (t *Tailer) IsWatching(filePath string) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	
-	_, exists := t.watchers[filePath]
-	return exists
+	return t.isWatching(filePath)
+}
+
+func (t *Tailer) isWatching(filePath string) bool {
+	_, watching := t.watchers[filePath]
+	_, streaming := t.streams[filePath]
+	return watching || streaming
 }
 
 func severityLevel(severity string) int {
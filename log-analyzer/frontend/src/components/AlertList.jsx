@@ -1,4 +1,5 @@
 import React from 'react'
+import axios from 'axios'
 import { AlertCircle } from 'lucide-react'
 import './AlertList.css'
 
@@ -11,7 +12,49 @@ function severityToLabel(severity) {
   return severity || 'Bilinmiyor'
 }
 
-function AlertList({ alerts }) {
+const STATUS_LABELS = {
+  new: 'Yeni',
+  acknowledged: 'Onaylandı',
+  resolved: 'Çözüldü',
+  false_positive: 'Yanlış pozitif'
+}
+
+const EVENT_LABELS = {
+  created: 'oluşturuldu',
+  status: 'durum değişti',
+  reopen: 'yeniden açıldı',
+  assign: 'atandı',
+  comment: 'yorum ekledi'
+}
+
+function AlertList({ alerts, onChange }) {
+  const updateAlert = async (alert, action, body) => {
+    try {
+      const res = await axios.post(`/api/alerts/${alert.id}/${action}`, body)
+      onChange?.(res.data)
+    } catch (err) {
+      window.alert(err.response?.data?.error || 'İşlem başarısız')
+    }
+  }
+
+  const setStatus = (alert, status) => {
+    const note = status === 'acknowledged' ? '' : window.prompt('Not (isteğe bağlı):', '')
+    if (note === null) return
+    updateAlert(alert, 'status', { status, note })
+  }
+
+  const assign = (alert) => {
+    const assignee = window.prompt('Atanacak kişi (boş bırakılırsa atama kaldırılır):', alert.assignee || '')
+    if (assignee === null) return
+    updateAlert(alert, 'assign', { assignee })
+  }
+
+  const comment = (alert) => {
+    const text = window.prompt('Yorum:', '')
+    if (!text) return
+    updateAlert(alert, 'comments', { text })
+  }
+
   const getSeverityColor = (severity) => {
     const s = severity?.toLowerCase()
     if (s === 'critical' || s === 'kritik') return '#dc2626'
@@ -43,11 +86,17 @@ function AlertList({ alerts }) {
       </div>
       <div className="alert-list-items">
         {alerts.slice().reverse().map((alert, index) => (
-          <div key={index} className="alert-item">
+          <div key={alert.id || index} className={`alert-item${alert.status && alert.status !== 'new' && alert.status !== 'acknowledged' ? ' alert-item-closed' : ''}`}>
             <div className="alert-item-header">
               <div className="alert-severity" style={{ backgroundColor: getSeverityColor(alert.severity) }}>
                 {severityToLabel(alert.severity)}
               </div>
+              {alert.count > 1 && (
+                <div className="alert-count" title={`Son: ${formatTime(alert.lastSeen)}`}>×{alert.count}</div>
+              )}
+              {alert.status && (
+                <div className={`alert-status alert-status-${alert.status}`}>{STATUS_LABELS[alert.status] || alert.status}</div>
+              )}
               <div className="alert-time">{formatTime(alert.timestamp)}</div>
             </div>
             <div className="alert-rules">
@@ -56,6 +105,22 @@ function AlertList({ alerts }) {
             <div className="alert-source">
               <strong>Kaynak:</strong> {alert.source || alert.logFile || 'N/A'}
             </div>
+            {(alert.fields?.rdns || alert.fields?.resolved_ip) && (
+              <div className="alert-geo">
+                <strong>DNS:</strong> {[alert.fields.rdns, alert.fields.resolved_ip].filter(Boolean).join(' · ')}
+              </div>
+            )}
+            {alert.geo?.length > 0 && (
+              <div className="alert-geo">
+                <strong>Konum:</strong>{' '}
+                {alert.geo.map((g, i) => (
+                  <span key={i} className="alert-geo-item" title={g.asn ? `AS${g.asn} ${g.asOrg || ''}` : undefined}>
+                    {g.ip}: {[g.city, g.countryName || g.country].filter(Boolean).join(', ') || '?'}
+                    {g.asOrg && ` · ${g.asOrg}`}
+                  </span>
+                ))}
+              </div>
+            )}
             <div className="alert-summary">
               {alert.summary || alert.line || 'N/A'}
             </div>
@@ -65,6 +130,50 @@ function AlertList({ alerts }) {
                 <div className="alert-line">{alert.line}</div>
               </details>
             )}
+            {alert.id && (
+              <div className="alert-lifecycle">
+                {alert.assignee && (
+                  <div className="alert-assignee"><strong>Atanan:</strong> {alert.assignee}</div>
+                )}
+                <div className="alert-actions">
+                  {alert.status === 'new' && (
+                    <button onClick={() => setStatus(alert, 'acknowledged')}>Onayla</button>
+                  )}
+                  {(alert.status === 'new' || alert.status === 'acknowledged') && (
+                    <>
+                      <button onClick={() => setStatus(alert, 'resolved')}>Çöz</button>
+                      <button onClick={() => setStatus(alert, 'false_positive')}>Yanlış pozitif</button>
+                    </>
+                  )}
+                  {(alert.status === 'resolved' || alert.status === 'false_positive') && (
+                    <button onClick={() => setStatus(alert, 'new')}>Yeniden aç</button>
+                  )}
+                  <button onClick={() => assign(alert)}>Ata</button>
+                  <button onClick={() => comment(alert)}>Yorum</button>
+                </div>
+                {alert.comments?.length > 0 && (
+                  <div className="alert-comments">
+                    {alert.comments.map((c, i) => (
+                      <div key={i} className="alert-comment">
+                        <strong>{c.author}</strong> ({formatTime(c.time)}): {c.text}
+                      </div>
+                    ))}
+                  </div>
+                )}
+                {alert.history?.length > 0 && (
+                  <details className="alert-raw">
+                    <summary>Geçmiş ({alert.history.length})</summary>
+                    {alert.history.map((e, i) => (
+                      <div key={i} className="alert-event">
+                        {formatTime(e.time)} · {e.actor} {EVENT_LABELS[e.action] || e.action}
+                        {e.to && ` → ${STATUS_LABELS[e.to] || e.to}`}
+                        {e.note && ` (${e.note})`}
+                      </div>
+                    ))}
+                  </details>
+                )}
+              </div>
+            )}
           </div>
         ))}
       </div>
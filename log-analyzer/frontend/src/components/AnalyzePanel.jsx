@@ -1,15 +1,19 @@
 import React, { useState } from 'react'
 import axios from 'axios'
-import { Search, Loader } from 'lucide-react'
+import { Search, Loader, Download } from 'lucide-react'
 import AlertList from './AlertList'
 import './AnalyzePanel.css'
 
 const API_BASE = '/api'
 
+const EXPORT_FORMATS = ['json', 'ndjson', 'csv', 'sarif', 'markdown', 'html']
+
 function AnalyzePanel({ logFiles }) {
   const [selectedFiles, setSelectedFiles] = useState([])
   const [analyzing, setAnalyzing] = useState(false)
   const [results, setResults] = useState([])
+  const [exportFormat, setExportFormat] = useState('html')
+  const [exporting, setExporting] = useState(false)
 
   const toggleFile = (filePath) => {
     setSelectedFiles(prev => {
@@ -39,6 +43,28 @@ function AnalyzePanel({ logFiles }) {
     }
   }
 
+  const exportReport = async () => {
+    setExporting(true)
+    try {
+      const res = await axios.post(`${API_BASE}/export`, {
+        files: selectedFiles,
+        format: exportFormat
+      }, { responseType: 'blob' })
+      const disposition = res.headers['content-disposition'] || ''
+      const match = disposition.match(/filename="([^"]+)"/)
+      const url = URL.createObjectURL(res.data)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = match ? match[1] : `rapor.${exportFormat}`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (err) {
+      alert('Dışa aktarma hatası: ' + err.message)
+    } finally {
+      setExporting(false)
+    }
+  }
+
   return (
     <div className="analyze-panel">
       <h2>Log Dosyası Analizi</h2>
@@ -82,6 +108,16 @@ function AnalyzePanel({ logFiles }) {
         <div className="analyze-results">
           <div className="results-header">
             <h3>Analiz Sonuçları ({results.length} uyarı bulundu)</h3>
+            <div className="export-controls">
+              <select value={exportFormat} onChange={(e) => setExportFormat(e.target.value)}>
+                {EXPORT_FORMATS.map(format => (
+                  <option key={format} value={format}>{format.toUpperCase()}</option>
+                ))}
+              </select>
+              <button className="btn-export" onClick={exportReport} disabled={exporting}>
+                <Download size={16} /> {exporting ? 'Hazırlanıyor...' : 'Dışa Aktar'}
+              </button>
+            </div>
           </div>
           <AlertList alerts={results} />
         </div>
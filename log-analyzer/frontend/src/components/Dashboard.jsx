@@ -24,6 +24,11 @@ function Dashboard({ onLogout }) {
   const [isTailing, setIsTailing] = useState(false)
   const wsRef = useRef(null)
 
+  const replaceAlert = (alert) => {
+    setAlerts(prev => prev.map(a => (a.id === alert.id ? alert : a)))
+    loadStats()
+  }
+
   const connectWebSocket = () => {
     if (wsRef.current && wsRef.current.readyState === WebSocket.OPEN) {
       return
@@ -44,7 +49,17 @@ function Dashboard({ onLogout }) {
         const alert = JSON.parse(event.data)
         if (alert.line === undefined || alert.source === undefined) return
         setAlerts(prev => {
-          const isNew = !prev.some(a => 
+          if (alert.id) {
+            const idx = prev.findIndex(a => a.id === alert.id)
+            if (idx !== -1) {
+              const updated = [...prev]
+              updated[idx] = alert
+              return updated
+            }
+          }
+          // Grouped repeats arrive as the stored alert with its id; any
+          // other alert is new, even if it shares a groupKey.
+          const isNew = alert.id || !prev.some(a => 
             a.line === alert.line && 
             a.source === alert.source &&
             Math.abs(new Date(a.timestamp) - new Date(alert.timestamp)) < 5000
@@ -363,12 +378,12 @@ function Dashboard({ onLogout }) {
                 )}
               </div>
             )}
-            <AlertList alerts={alerts.slice(-20)} />
+            <AlertList alerts={alerts.slice(-20)} onChange={replaceAlert} />
           </div>
         )}
 
         {activeTab === 'alerts' && (
-          <AlertList alerts={alerts} />
+          <AlertList alerts={alerts} onChange={replaceAlert} />
         )}
 
         {activeTab === 'analyze' && (
@@ -1,8 +1,23 @@
-import React from 'react'
-import { Shield, CheckCircle, XCircle } from 'lucide-react'
+import React, { useEffect, useState } from 'react'
+import axios from 'axios'
+import { Shield, CheckCircle, XCircle, Activity } from 'lucide-react'
 import './RulesPanel.css'
 
+const API_BASE = '/api'
+
 function RulesPanel({ rules }) {
+  const [stats, setStats] = useState({})
+
+  useEffect(() => {
+    axios.get(`${API_BASE}/rules/stats`)
+      .then(res => {
+        const byRule = {}
+        for (const s of res.data || []) byRule[s.rule] = s
+        setStats(byRule)
+      })
+      .catch(err => console.error('Kural istatistikleri alınamadı:', err))
+  }, [rules])
+
   const getSeverityColor = (severity) => {
     const s = severity?.toLowerCase()
     if (s === 'critical' || s === 'kritik') return '#dc2626'
@@ -48,6 +63,35 @@ function RulesPanel({ rules }) {
             <div className="rule-pattern">
               <strong>Desen:</strong> <code>{rule.pattern}</code>
             </div>
+            <div className="rule-scope">
+              <strong>Kapsam:</strong>{' '}
+              {(rule.log_types?.length || rule.paths?.length) ? (
+                <>
+                  {(rule.log_types || []).map(t => (
+                    <span key={`t-${t}`} className="scope-badge">{t}</span>
+                  ))}
+                  {(rule.paths || []).map(p => (
+                    <span key={`p-${p}`} className="scope-badge scope-path">{p}</span>
+                  ))}
+                </>
+              ) : (
+                <span className="scope-all">Tüm kaynaklar</span>
+              )}
+            </div>
+            {stats[rule.name] && (
+              <div className="rule-stats">
+                <Activity size={14} />
+                {stats[rule.name].totalHits > 0 ? (
+                  <span>
+                    {stats[rule.name].totalHits} eşleşme · saatte {stats[rule.name].avgHitsPerHour.toFixed(1)} ·
+                    {stats[rule.name].suppressedHits > 0 && ` ${stats[rule.name].suppressedHits} bastırıldı ·`}
+                    {' '}son: {new Date(stats[rule.name].lastSeen).toLocaleString('tr-TR')}
+                  </span>
+                ) : (
+                  <span>Henüz eşleşme yok</span>
+                )}
+              </div>
+            )}
           </div>
         ))}
       </div>